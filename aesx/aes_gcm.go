@@ -3,6 +3,8 @@ package aesx
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	crand "crypto/rand"
+	"errors"
 	"fmt"
 	"math/rand"
 	"time"
@@ -19,14 +21,10 @@ func GCMDecrypt(cipherText, nonce, additional, key []byte) ([]byte, error) {
 }
 
 func gcmDecrypt(secretData, nonce, additional, key []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key)
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, fmt.Errorf("cipher.NewGCM(),error:%w", err)
-	}
 	originByte, err := gcm.Open(nil, nonce, secretData, additional)
 	if err != nil {
 		return nil, err
@@ -40,17 +38,57 @@ func gcmDecrypt(secretData, nonce, additional, key []byte) ([]byte, error) {
 // key: 加密密钥。
 // 返回加密后的密文、随机生成的nonce和可能的错误。
 func gcmEncrypt(originText, additional, key []byte) ([]byte, []byte, error) {
-	block, err := aes.NewCipher(key)
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, nil, err
 	}
 	nonce := []byte(RandomString(12))
+	cipherBytes := gcm.Seal(nil, nonce, originText, additional)
+	return nonce, cipherBytes, nil
+}
+
+// ErrAuthentication 密文被篡改、截断或密钥错误
+var ErrAuthentication = errors.New("aesx: message authentication failed")
+
+// GCMSeal AES-GCM 加密, 使用 crypto/rand 生成的 nonce 并放在密文之前
+func GCMSeal(originText, additional, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(originText)+gcm.Overhead())
+	if _, err = crand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, originText, additional), nil
+}
+
+// GCMOpen 解密 GCMSeal 的结果
+func GCMOpen(sealed, additional, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrAuthentication
+	}
+	originByte, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additional)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return originByte, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return nil, nil, fmt.Errorf("cipher.NewGCM(),error:%w", err)
+		return nil, fmt.Errorf("cipher.NewGCM(),error:%w", err)
 	}
-	cipherBytes := gcm.Seal(nil, nonce, originText, additional)
-	return nonce, cipherBytes, nil
+	return gcm, nil
 }
 
 func RandomString(l int) string {
@@ -0,0 +1,179 @@
+package aesx
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// GCMStream 分块 AES-GCM 流加密, 第 i 块的 nonce 由块序号与结束标记组成,
+// 可检测块的篡改、重排与截断, 也可只解密其中连续的几块.
+// nonce 不含随机数, 同一个 key 只能加密一个流.
+type GCMStream struct {
+	aead      cipher.AEAD
+	chunkSize int
+}
+
+// NewGCMStream 创建流加密, chunkSize 为每块明文的字节数
+func NewGCMStream(key []byte, chunkSize int) (*GCMStream, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("aesx: invalid chunk size %d", chunkSize)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &GCMStream{aead: aead, chunkSize: chunkSize}, nil
+}
+
+// ChunkSize 每块明文的字节数
+func (s *GCMStream) ChunkSize() int {
+	return s.chunkSize
+}
+
+// Chunks 明文大小对应的块数, 空明文也有一块
+func (s *GCMStream) Chunks(size int64) int64 {
+	return max((size+int64(s.chunkSize)-1)/int64(s.chunkSize), 1)
+}
+
+// CipherSize 明文大小对应的密文大小
+func (s *GCMStream) CipherSize(size int64) int64 {
+	return size + s.Chunks(size)*int64(s.aead.Overhead())
+}
+
+// PlainSize 密文大小对应的明文大小, 密文大小不合法时返回 ErrAuthentication
+func (s *GCMStream) PlainSize(size int64) (int64, error) {
+	sealed := int64(s.chunkSize + s.aead.Overhead())
+	n := (size + sealed - 1) / sealed
+	plain := size - n*int64(s.aead.Overhead())
+	if n == 0 || plain < 0 || (n > 1 && plain <= (n-1)*int64(s.chunkSize)) {
+		return 0, ErrAuthentication
+	}
+	return plain, nil
+}
+
+func (s *GCMStream) nonce(dst []byte, counter uint64, final bool) []byte {
+	dst = dst[:s.aead.NonceSize()]
+	clear(dst)
+	binary.BigEndian.PutUint64(dst[len(dst)-9:], counter)
+	if final {
+		dst[len(dst)-1] = 1
+	}
+	return dst
+}
+
+// EncryptReader 返回 r 的密文
+func (s *GCMStream) EncryptReader(r io.Reader) io.Reader {
+	return &gcmEncryptReader{
+		s:     s,
+		r:     bufio.NewReaderSize(r, s.chunkSize),
+		chunk: make([]byte, s.chunkSize, s.chunkSize+s.aead.Overhead()),
+		nonce: make([]byte, s.aead.NonceSize()),
+	}
+}
+
+// DecryptReader 返回 r 的明文, r 从第 first 块开始;
+// final 为 true 时 r 需读到最后一块, 否则 r 只包含中间的若干块.
+func (s *GCMStream) DecryptReader(r io.Reader, first uint64, final bool) io.Reader {
+	return &gcmDecryptReader{
+		s:       s,
+		r:       bufio.NewReaderSize(r, s.chunkSize+s.aead.Overhead()),
+		chunk:   make([]byte, s.chunkSize+s.aead.Overhead()),
+		nonce:   make([]byte, s.aead.NonceSize()),
+		counter: first,
+		final:   final,
+	}
+}
+
+type gcmEncryptReader struct {
+	s       *GCMStream
+	r       *bufio.Reader
+	chunk   []byte
+	nonce   []byte
+	out     []byte
+	counter uint64
+	err     error
+}
+
+func (e *gcmEncryptReader) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+		e.fill()
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+func (e *gcmEncryptReader) fill() {
+	n, err := io.ReadFull(e.r, e.chunk)
+	final := err != nil
+	if err == nil {
+		_, err = e.r.Peek(1)
+		final = err == io.EOF
+	}
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		e.err = err
+		return
+	}
+	e.out = e.s.aead.Seal(e.chunk[:0], e.s.nonce(e.nonce, e.counter, final), e.chunk[:n], nil)
+	e.counter++
+	if final {
+		e.err = io.EOF
+	}
+}
+
+type gcmDecryptReader struct {
+	s       *GCMStream
+	r       *bufio.Reader
+	chunk   []byte
+	nonce   []byte
+	out     []byte
+	counter uint64
+	final   bool
+	err     error
+}
+
+func (d *gcmDecryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.fill()
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+func (d *gcmDecryptReader) fill() {
+	n, err := io.ReadFull(d.r, d.chunk)
+	if n == 0 && err == io.EOF {
+		// 没有任何块
+		d.err = io.ErrUnexpectedEOF
+		return
+	}
+	last := err != nil
+	if err == nil {
+		_, err = d.r.Peek(1)
+		last = err == io.EOF
+	}
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		d.err = err
+		return
+	}
+	out, err := d.s.aead.Open(d.chunk[:0], d.s.nonce(d.nonce, d.counter, d.final && last), d.chunk[:n], nil)
+	if err != nil {
+		d.err = ErrAuthentication
+		return
+	}
+	d.out = out
+	d.counter++
+	if last {
+		d.err = io.EOF
+	}
+}
@@ -0,0 +1,80 @@
+package aesx
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCMSeal(t *testing.T) {
+	sealed, err := GCMSeal([]byte("data key"), []byte("key-1"), []byte(secretKey))
+	require.NoError(t, err)
+	again, err := GCMSeal([]byte("data key"), []byte("key-1"), []byte(secretKey))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	data, err := GCMOpen(sealed, []byte("key-1"), []byte(secretKey))
+	require.NoError(t, err)
+	assert.Equal(t, "data key", string(data))
+	_, err = GCMOpen(sealed, []byte("key-2"), []byte(secretKey))
+	assert.ErrorIs(t, err, ErrAuthentication)
+	_, err = GCMOpen(sealed[:5], nil, []byte(secretKey))
+	assert.ErrorIs(t, err, ErrAuthentication)
+}
+
+func TestGCMStream(t *testing.T) {
+	s, err := NewGCMStream([]byte(secretKey), 16)
+	require.NoError(t, err)
+	_, err = NewGCMStream([]byte(secretKey), 0)
+	assert.Error(t, err)
+
+	for _, size := range []int{0, 1, 15, 16, 17, 32, 100} {
+		plain := bytes.Repeat([]byte{'x'}, size)
+		sealed, err := io.ReadAll(iotest.OneByteReader(s.EncryptReader(bytes.NewReader(plain))))
+		require.NoError(t, err)
+		assert.Equal(t, s.CipherSize(int64(size)), int64(len(sealed)), size)
+		n, err := s.PlainSize(int64(len(sealed)))
+		require.NoError(t, err)
+		assert.Equal(t, int64(size), n)
+
+		data, err := io.ReadAll(s.DecryptReader(iotest.HalfReader(bytes.NewReader(sealed)), 0, true))
+		require.NoError(t, err, size)
+		assert.Equal(t, plain, data, size)
+	}
+
+	plain := []byte("0123456789abcdef0123456789ABCDEF0123")
+	sealed, err := io.ReadAll(s.EncryptReader(bytes.NewReader(plain)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), s.Chunks(int64(len(plain))))
+	chunk := 16 + 16
+
+	// 只解密中间一块, 或从中间读到结尾
+	data, err := io.ReadAll(s.DecryptReader(bytes.NewReader(sealed[chunk:2*chunk]), 1, false))
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789ABCDEF", string(data))
+	data, err = io.ReadAll(s.DecryptReader(bytes.NewReader(sealed[chunk:]), 1, true))
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789ABCDEF0123", string(data))
+
+	// 截断、重排与篡改
+	for name, bad := range map[string][]byte{
+		"truncated": sealed[:2*chunk],
+		"reordered": append(append(append([]byte{}, sealed[chunk:2*chunk]...), sealed[:chunk]...), sealed[2*chunk:]...),
+		"modified":  append(append([]byte{}, sealed[:len(sealed)-1]...), sealed[len(sealed)-1]^1),
+		"empty":     nil,
+	} {
+		_, err = io.ReadAll(s.DecryptReader(bytes.NewReader(bad), 0, true))
+		assert.Error(t, err, name)
+	}
+	_, err = io.ReadAll(s.DecryptReader(bytes.NewReader(sealed[2*chunk:]), 2, false))
+	assert.ErrorIs(t, err, ErrAuthentication)
+
+	for _, size := range []int64{0, 15, 33} {
+		_, err = s.PlainSize(size)
+		assert.ErrorIs(t, err, ErrAuthentication, size)
+	}
+}
@@ -0,0 +1,224 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zmicro-team/ztlib/authorize"
+	"github.com/zmicro-team/ztlib/authorize/policy"
+	"github.com/zmicro-team/ztlib/extractor"
+	"github.com/zmicro-team/ztlib/random"
+)
+
+// HeaderAPIKey 默认读取 api key 的请求头
+const HeaderAPIKey = "X-API-Key"
+
+const (
+	defaultPrefix = "zt"
+	bodyLength    = 32 // base62, 约 190 位
+	hintLength    = 6  // 展示用的 body 前缀长度
+)
+
+var (
+	// ErrInvalidKey api key 格式错误或不存在
+	ErrInvalidKey = errors.New("apikey: invalid api key")
+	// ErrKeyExpired api key 已过期
+	ErrKeyExpired = errors.New("apikey: api key expired")
+	// ErrKeyRevoked api key 已吊销
+	ErrKeyRevoked = errors.New("apikey: api key revoked")
+	// ErrInsufficientScope api key 没有所需的 scope
+	ErrInsufficientScope = errors.New("apikey: insufficient scope")
+)
+
+// APIKey 服务端保存的 api key 信息, 不包含明文
+type APIKey struct {
+	Id         string    `json:"id"`
+	Prefix     string    `json:"prefix"`
+	Hint       string    `json:"hint"` // 明文的前几位, 用于展示, 如 zt_AbC123...
+	Hash       string    `json:"hash"`
+	Name       string    `json:"name"`
+	OwnerId    string    `json:"owner_id"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpireAt   time.Time `json:"expire_at,omitempty"` // 零值表示不过期
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+	Revoked    bool      `json:"revoked"`
+}
+
+// HasScope 是否拥有 scope, 支持 order:* 形式的通配
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if policy.MatchPermission(s, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// HashKey 计算 api key 明文的哈希, 服务端只保存哈希
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseKey 拆分 prefix_body, body 为 base62, prefix 可以包含 _
+func parseKey(key string) (prefix, body string, ok bool) {
+	idx := strings.LastIndexByte(key, '_')
+	if idx <= 0 || len(key)-idx-1 != bodyLength {
+		return "", "", false
+	}
+	return key[:idx], key[idx+1:], true
+}
+
+// CreateArgs 创建 api key 的参数
+type CreateArgs struct {
+	Name    string
+	OwnerId string
+	Scopes  []string
+	TTL     time.Duration // 0 表示不过期
+}
+
+// Manager api key 管理
+type Manager struct {
+	store         Store
+	prefix        string
+	extractor     extractor.Extractor
+	touchInterval time.Duration
+	now           func() time.Time
+}
+
+// Option api key 管理配置
+type Option func(*Manager)
+
+// WithPrefix 设置 api key 前缀, 如 zt_live, 默认 zt
+func WithPrefix(prefix string) Option {
+	return func(m *Manager) {
+		if prefix != "" {
+			m.prefix = prefix
+		}
+	}
+}
+
+// WithExtractor 设置从请求中读取 api key 的方式, 默认读取 X-API-Key 请求头
+func WithExtractor(e extractor.Extractor) Option {
+	return func(m *Manager) {
+		if e != nil {
+			m.extractor = e
+		}
+	}
+}
+
+// WithTouchInterval 更新最后使用时间的最小间隔, 默认 1 分钟, 减少写入
+func WithTouchInterval(d time.Duration) Option {
+	return func(m *Manager) {
+		if d >= 0 {
+			m.touchInterval = d
+		}
+	}
+}
+
+// NewManager 创建 api key 管理
+func NewManager(store Store, opts ...Option) *Manager {
+	m := &Manager{
+		store:         store,
+		prefix:        defaultPrefix,
+		extractor:     extractor.NewHeaderExtractor(HeaderAPIKey),
+		touchInterval: time.Minute,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Create 创建 api key, 返回的明文只展示给用户一次
+func (m *Manager) Create(ctx context.Context, args *CreateArgs) (string, *APIKey, error) {
+	body := random.SecureRandn(bodyLength, "")
+	plain := m.prefix + "_" + body
+	now := m.now()
+	k := &APIKey{
+		Id:        random.RandId(),
+		Prefix:    m.prefix,
+		Hint:      m.prefix + "_" + body[:hintLength],
+		Hash:      HashKey(plain),
+		Name:      args.Name,
+		OwnerId:   args.OwnerId,
+		Scopes:    args.Scopes,
+		CreatedAt: now,
+	}
+	if args.TTL > 0 {
+		k.ExpireAt = now.Add(args.TTL)
+	}
+	if err := m.store.Save(ctx, k); err != nil {
+		return "", nil, err
+	}
+	return plain, k, nil
+}
+
+// Verify 校验 api key 明文, 成功时更新最后使用时间
+func (m *Manager) Verify(ctx context.Context, key string) (*APIKey, error) {
+	prefix, _, ok := parseKey(key)
+	if !ok || prefix != m.prefix {
+		return nil, ErrInvalidKey
+	}
+	k, err := m.store.GetByHash(ctx, HashKey(key))
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	if k.Revoked {
+		return nil, ErrKeyRevoked
+	}
+	if !k.ExpireAt.IsZero() && !now.Before(k.ExpireAt) {
+		return nil, ErrKeyExpired
+	}
+	if now.Sub(k.LastUsedAt) >= m.touchInterval {
+		if err := m.store.Touch(ctx, k.Id, now); err != nil {
+			return nil, err
+		}
+		k.LastUsedAt = now
+	}
+	return k, nil
+}
+
+// VerifyRequest 从请求中读取并校验 api key, 返回可写入 context 的 Principal
+func (m *Manager) VerifyRequest(r *http.Request, scopes ...string) (*authorize.Principal[*APIKey], error) {
+	key, err := m.extractor.ExtractRequest(r)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	k, err := m.Verify(r.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		if !k.HasScope(scope) {
+			return nil, ErrInsufficientScope
+		}
+	}
+	return NewPrincipal(k), nil
+}
+
+// NewPrincipal api key 对应的 Principal, Id 为 api key 的所有者
+func NewPrincipal(k *APIKey) *authorize.Principal[*APIKey] {
+	return authorize.NewPrincipal(k.OwnerId, k, authorize.WithPlainClaims())
+}
+
+// Revoke 吊销 api key
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	return m.store.Revoke(ctx, id)
+}
+
+// List 获取所有者的 api key
+func (m *Manager) List(ctx context.Context, ownerId string) ([]*APIKey, error) {
+	return m.store.List(ctx, ownerId)
+}
@@ -0,0 +1,121 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zmicro-team/ztlib/authorize"
+	"github.com/zmicro-team/ztlib/extractor"
+)
+
+func TestManager_CreateVerify(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	m := NewManager(store, WithPrefix("zt_live"))
+
+	plain, k, err := m.Create(ctx, &CreateArgs{Name: "ci", OwnerId: "1001", Scopes: []string{"order:*"}})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plain, "zt_live_"))
+	assert.True(t, strings.HasPrefix(plain, k.Hint))
+	assert.NotContains(t, k.Hash, plain)
+	assert.Equal(t, HashKey(plain), k.Hash)
+
+	got, err := m.Verify(ctx, plain)
+	assert.NoError(t, err)
+	assert.Equal(t, k.Id, got.Id)
+	assert.False(t, got.LastUsedAt.IsZero())
+	assert.True(t, got.HasScope("order:read"))
+	assert.False(t, got.HasScope("user:read"))
+
+	for _, bad := range []string{"", "zt_live", plain[:len(plain)-1], "zt_test_" + plain[len("zt_live_"):], plain[:len(plain)-1] + "x"} {
+		_, err = m.Verify(ctx, bad)
+		assert.ErrorIs(t, err, ErrInvalidKey, bad)
+	}
+
+	assert.NoError(t, m.Revoke(ctx, k.Id))
+	_, err = m.Verify(ctx, plain)
+	assert.ErrorIs(t, err, ErrKeyRevoked)
+
+	list, err := m.List(ctx, "1001")
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestManager_ExpireTouch(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	start := time.Now()
+	now := start
+	m := NewManager(store)
+	m.now = func() time.Time { return now }
+
+	plain, k, err := m.Create(ctx, &CreateArgs{OwnerId: "1001", TTL: time.Hour})
+	assert.NoError(t, err)
+
+	got, err := m.Verify(ctx, plain)
+	assert.NoError(t, err)
+	assert.Equal(t, now, got.LastUsedAt)
+
+	// 间隔内不重复更新
+	now = now.Add(30 * time.Second)
+	got, err = m.Verify(ctx, plain)
+	assert.NoError(t, err)
+	assert.Equal(t, start, got.LastUsedAt)
+
+	now = now.Add(time.Hour)
+	_, err = m.Verify(ctx, plain)
+	assert.ErrorIs(t, err, ErrKeyExpired)
+
+	stored, err := store.GetByHash(ctx, k.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, start, stored.LastUsedAt)
+}
+
+func TestAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(NewMemoryStore(), WithExtractor(extractor.NewHeaderExtractor(HeaderAPIKey, "Token")))
+	plain, _, err := m.Create(ctx, &CreateArgs{OwnerId: "1001", Scopes: []string{"order:read"}})
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	a := &Authenticator{Manager: m}
+	r := gin.New()
+	handler := func(c *gin.Context) {
+		p, ok := authorize.PrincipalFromContext[*APIKey](c.Request.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "1001", p.GetId(c))
+		c.String(http.StatusOK, p.Data.Id)
+	}
+	r.GET("/orders", a.Authenticated("order:read"), handler)
+	r.POST("/orders", a.Authenticated("order:write"), handler)
+
+	tests := []struct {
+		name   string
+		method string
+		header string
+		key    string
+		want   int
+	}{
+		{"ok", http.MethodGet, HeaderAPIKey, plain, http.StatusOK},
+		{"fallback header", http.MethodGet, "Token", plain, http.StatusOK},
+		{"missing", http.MethodGet, HeaderAPIKey, "", http.StatusUnauthorized},
+		{"invalid", http.MethodGet, HeaderAPIKey, "zt_invalid", http.StatusUnauthorized},
+		{"scope", http.MethodPost, HeaderAPIKey, plain, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/orders", nil)
+			req.Header.Set(tt.header, tt.key)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
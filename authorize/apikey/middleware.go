@@ -0,0 +1,43 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/zmicro-team/ztlib/authorize"
+)
+
+// Authenticator is the gin api key authenticator middleware.
+type Authenticator struct {
+	Manager     *Manager
+	ErrFallback func(c *gin.Context, statusCode int, err error)
+}
+
+// Authenticated returns a gin middleware which requires a valid api key with given scopes.
+// 校验通过后 Principal 写入 request context, 使用 authorize.PrincipalFromContext[*APIKey] 读取
+func (a *Authenticator) Authenticated(scopes ...string) gin.HandlerFunc {
+	if a.Manager == nil {
+		panic("apikey manager must be initialized.")
+	}
+	if a.ErrFallback == nil {
+		a.ErrFallback = func(c *gin.Context, statusCode int, err error) {
+			c.String(statusCode, err.Error())
+		}
+	}
+	return func(c *gin.Context) {
+		p, err := a.Manager.VerifyRequest(c.Request, scopes...)
+		if err != nil {
+			statusCode := http.StatusUnauthorized
+			if errors.Is(err, ErrInsufficientScope) {
+				statusCode = http.StatusForbidden
+			}
+			c.Abort()
+			a.ErrFallback(c, statusCode, err)
+			return
+		}
+		c.Request = c.Request.WithContext(authorize.NewContext(c.Request.Context(), p))
+		c.Next()
+	}
+}
@@ -0,0 +1,101 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound api key 不存在
+var ErrNotFound = errors.New("apikey: not found")
+
+// Store api key 存储, 以哈希索引
+type Store interface {
+	// Save 保存 api key
+	Save(ctx context.Context, k *APIKey) error
+	// GetByHash 根据明文哈希获取 api key
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	// Touch 更新最后使用时间
+	Touch(ctx context.Context, id string, t time.Time) error
+	// Revoke 吊销 api key
+	Revoke(ctx context.Context, id string) error
+	// List 获取所有者的 api key, 按创建时间升序
+	List(ctx context.Context, ownerId string) ([]*APIKey, error)
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore 内存存储, 用于单机或测试
+type MemoryStore struct {
+	mutex  sync.RWMutex
+	keys   map[string]*APIKey // id -> key
+	hashes map[string]string  // hash -> id
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys:   make(map[string]*APIKey),
+		hashes: make(map[string]string),
+	}
+}
+
+func (s *MemoryStore) Save(_ context.Context, k *APIKey) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	cp := *k
+	s.keys[k.Id] = &cp
+	s.hashes[k.Hash] = k.Id
+	return nil
+}
+
+func (s *MemoryStore) GetByHash(_ context.Context, hash string) (*APIKey, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	k, ok := s.keys[s.hashes[hash]]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *k
+	return &cp, nil
+}
+
+func (s *MemoryStore) Touch(_ context.Context, id string, t time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	k.LastUsedAt = t
+	return nil
+}
+
+func (s *MemoryStore) Revoke(_ context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	k.Revoked = true
+	return nil
+}
+
+func (s *MemoryStore) List(_ context.Context, ownerId string) ([]*APIKey, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var list []*APIKey
+	for _, k := range s.keys {
+		if k.OwnerId == ownerId {
+			cp := *k
+			list = append(list, &cp)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
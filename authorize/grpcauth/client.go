@@ -0,0 +1,82 @@
+package grpcauth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/zmicro-team/ztlib/authorize"
+)
+
+// tokenSource 签发并缓存 token, 直到接近过期
+type tokenSource struct {
+	auth authorize.IAuthorize
+	user authorize.IAuthorizeOther
+	opts *options
+
+	mutex    sync.Mutex
+	token    string
+	expireAt time.Time // 零值表示不过期
+}
+
+func newTokenSource(auth authorize.IAuthorize, user authorize.IAuthorizeOther, opts *options) *tokenSource {
+	return &tokenSource{auth: auth, user: user, opts: opts}
+}
+
+// Token 返回缓存的 token, 过期前 refreshBefore 内重新签发
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.token != "" && (s.expireAt.IsZero() || time.Until(s.expireAt) > s.opts.refreshBefore) {
+		return s.token, nil
+	}
+	token, err := s.auth.GenerateToken(ctx, s.user)
+	if err != nil {
+		return "", err
+	}
+	// token 由本进程签发, 只需读取过期时间
+	jwtToken, err := jwt.ParseInsecure([]byte(token))
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.expireAt = jwtToken.Expiration()
+	return s.token, nil
+}
+
+func (s *tokenSource) appendToContext(ctx context.Context) (context.Context, error) {
+	token, err := s.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, s.opts.metadataKey, s.opts.formatToken(token)), nil
+}
+
+// UnaryClientInterceptor 为请求签发内部 token 并写入 metadata
+func UnaryClientInterceptor(auth authorize.IAuthorize, user authorize.IAuthorizeOther, opts ...Option) grpc.UnaryClientInterceptor {
+	source := newTokenSource(auth, user, newOptions(opts...))
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		ctx, err := source.appendToContext(ctx)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, callOpts...)
+	}
+}
+
+// StreamClientInterceptor 为流请求签发内部 token 并写入 metadata
+func StreamClientInterceptor(auth authorize.IAuthorize, user authorize.IAuthorizeOther, opts ...Option) grpc.StreamClientInterceptor {
+	source := newTokenSource(auth, user, newOptions(opts...))
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := source.appendToContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, callOpts...)
+	}
+}
@@ -0,0 +1,190 @@
+package grpcauth
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/zmicro-team/ztlib/authorize"
+)
+
+var testInnerConfig = authorize.InnerAuthorizeConfig{
+	Secret: "*&@^!&#$*$@#*!(SD~AD><?)",
+	Expire: time.Hour,
+}
+
+// healthServer 通过 Status 返回调用方 id, 便于断言
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (healthServer) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if _, ok := CallerFromContext(ctx); !ok {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_UNKNOWN}, nil
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (healthServer) Watch(_ *healthpb.HealthCheckRequest, ss healthpb.Health_WatchServer) error {
+	caller, ok := CallerFromContext(ss.Context())
+	if !ok || caller.GetId(ss.Context()) != "app_1" {
+		return status.Error(codes.Internal, "caller not found")
+	}
+	return ss.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+// countAuthorize 统计 token 签发次数
+type countAuthorize struct {
+	authorize.IAuthorize
+	count atomic.Int32
+}
+
+func (c *countAuthorize) GenerateToken(ctx context.Context, user authorize.IAuthorizeOther) (string, error) {
+	c.count.Add(1)
+	return c.IAuthorize.GenerateToken(ctx, user)
+}
+
+func newUser() authorize.IAuthorizeOther {
+	return new(authorize.UserAuthorizeOther)
+}
+
+func startServer(t *testing.T, auth authorize.IAuthorize, opts ...Option) *bufconn.Listener {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(auth, newUser, opts...)),
+		grpc.StreamInterceptor(StreamServerInterceptor(auth, newUser, opts...)),
+	)
+	healthpb.RegisterHealthServer(srv, healthServer{})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis
+}
+
+func dial(t *testing.T, lis *bufconn.Listener, dialOpts ...grpc.DialOption) healthpb.HealthClient {
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.DialContext(context.Background(), "bufnet", dialOpts...)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestInterceptor_Unary(t *testing.T) {
+	cfg := testInnerConfig
+	inner := authorize.NewInnerAuthorize(&cfg)
+	lis := startServer(t, inner)
+
+	auth := &countAuthorize{IAuthorize: inner}
+	user := &authorize.UserAuthorizeOther{Id: "app_1", Type: "service"}
+	client := dial(t, lis, grpc.WithUnaryInterceptor(UnaryClientInterceptor(auth, user)))
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	}
+	assert.Equal(t, int32(1), auth.count.Load())
+}
+
+func TestInterceptor_Stream(t *testing.T) {
+	cfg := testInnerConfig
+	inner := authorize.NewInnerAuthorize(&cfg)
+	lis := startServer(t, inner)
+
+	user := &authorize.UserAuthorizeOther{Id: "app_1", Type: "service"}
+	client := dial(t, lis, grpc.WithStreamInterceptor(StreamClientInterceptor(inner, user)))
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+}
+
+func TestInterceptor_Unauthenticated(t *testing.T) {
+	cfg := testInnerConfig
+	lis := startServer(t, authorize.NewInnerAuthorize(&cfg))
+
+	// 无 token
+	client := dial(t, lis)
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// 密钥不一致
+	other := authorize.NewInnerAuthorize(&authorize.InnerAuthorizeConfig{Secret: "another-secret-of-24byte"})
+	user := &authorize.UserAuthorizeOther{Id: "app_1"}
+	client = dial(t, lis, grpc.WithUnaryInterceptor(UnaryClientInterceptor(other, user)))
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// 缺少前缀
+	ctx := metadata.AppendToOutgoingContext(context.Background(), defaultMetadataKey, "token")
+	_, err = dial(t, lis).Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestInterceptor_SkipMethods(t *testing.T) {
+	cfg := testInnerConfig
+	lis := startServer(t, authorize.NewInnerAuthorize(&cfg), WithSkipMethods(healthpb.Health_Check_FullMethodName))
+
+	resp, err := dial(t, lis).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, resp.Status)
+}
+
+func TestTokenSource_Refresh(t *testing.T) {
+	cfg := testInnerConfig
+	cfg.Expire = 2 * time.Second
+	auth := &countAuthorize{IAuthorize: authorize.NewInnerAuthorize(&cfg)}
+	user := &authorize.UserAuthorizeOther{Id: "app_1"}
+
+	// 过期前 refreshBefore 内会重新签发
+	source := newTokenSource(auth, user, newOptions(WithRefreshBefore(time.Hour)))
+	_, err := source.Token(context.Background())
+	assert.NoError(t, err)
+	_, err = source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), auth.count.Load())
+
+	source = newTokenSource(auth, user, newOptions(WithRefreshBefore(time.Second)))
+	token, err := source.Token(context.Background())
+	assert.NoError(t, err)
+	jwtToken, err := jwt.ParseInsecure([]byte(token))
+	assert.NoError(t, err)
+	assert.False(t, jwtToken.Expiration().IsZero())
+	cached, err := source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, token, cached)
+	assert.Equal(t, int32(3), auth.count.Load())
+}
+
+func TestOptions_ParseToken(t *testing.T) {
+	o := newOptions()
+	token, ok := o.parseToken("bearer abc")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", token)
+	_, ok = o.parseToken("Basic abc")
+	assert.False(t, ok)
+
+	o = newOptions(WithScheme(""), WithMetadataKey("X-Token"))
+	assert.Equal(t, "x-token", o.metadataKey)
+	token, ok = o.parseToken("abc")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", token)
+}
@@ -0,0 +1,87 @@
+package grpcauth
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	defaultMetadataKey   = "authorization"
+	defaultScheme        = "Bearer"
+	defaultRefreshBefore = time.Minute
+)
+
+type options struct {
+	metadataKey   string              // metadata 中 token 的 key
+	scheme        string              // token 前缀, 为空时不带前缀
+	refreshBefore time.Duration       // 过期前多久重新签发 token
+	skipMethods   map[string]struct{} // 服务端跳过校验的方法
+}
+
+// Option 拦截器配置
+type Option func(*options)
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		metadataKey:   defaultMetadataKey,
+		scheme:        defaultScheme,
+		refreshBefore: defaultRefreshBefore,
+		skipMethods:   make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithMetadataKey 设置 metadata 中 token 的 key, 默认 authorization
+func WithMetadataKey(key string) Option {
+	return func(o *options) {
+		if key != "" {
+			o.metadataKey = strings.ToLower(key)
+		}
+	}
+}
+
+// WithScheme 设置 token 前缀, 默认 Bearer, 传空字符串表示不带前缀
+func WithScheme(scheme string) Option {
+	return func(o *options) {
+		o.scheme = scheme
+	}
+}
+
+// WithRefreshBefore 客户端在 token 过期前多久重新签发, 默认 1 分钟
+func WithRefreshBefore(d time.Duration) Option {
+	return func(o *options) {
+		if d >= 0 {
+			o.refreshBefore = d
+		}
+	}
+}
+
+// WithSkipMethods 服务端跳过校验的方法全名, 如 /grpc.health.v1.Health/Check
+func WithSkipMethods(methods ...string) Option {
+	return func(o *options) {
+		for _, m := range methods {
+			o.skipMethods[m] = struct{}{}
+		}
+	}
+}
+
+func (o *options) formatToken(token string) string {
+	if o.scheme == "" {
+		return token
+	}
+	return o.scheme + " " + token
+}
+
+func (o *options) parseToken(value string) (string, bool) {
+	if o.scheme == "" {
+		return value, value != ""
+	}
+	prefix := o.scheme + " "
+	if len(value) <= len(prefix) || !strings.EqualFold(value[:len(prefix)], prefix) {
+		return "", false
+	}
+	return value[len(prefix):], true
+}
@@ -0,0 +1,97 @@
+package grpcauth
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/zmicro-team/ztlib/authorize"
+)
+
+type callerKey struct{}
+
+// WithCaller 将调用方身份写入 context
+func WithCaller(ctx context.Context, user authorize.IAuthorizeOther) context.Context {
+	return context.WithValue(ctx, callerKey{}, user)
+}
+
+// CallerFromContext 获取调用方身份
+func CallerFromContext(ctx context.Context) (authorize.IAuthorizeOther, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	user, ok := ctx.Value(callerKey{}).(authorize.IAuthorizeOther)
+	return user, ok
+}
+
+type verifier struct {
+	auth    authorize.IAuthorize
+	newUser func() authorize.IAuthorizeOther
+	opts    *options
+}
+
+func (v *verifier) verify(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "grpcauth: missing metadata")
+	}
+	values := md.Get(v.opts.metadataKey)
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "grpcauth: missing token")
+	}
+	token, ok := v.opts.parseToken(values[0])
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "grpcauth: invalid token scheme")
+	}
+	user := v.newUser()
+	if _, err := v.auth.VerifyToken(ctx, token, user); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "grpcauth: %v", err)
+	}
+	if user.GetBan(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "grpcauth: caller is banned")
+	}
+	return WithCaller(ctx, user), nil
+}
+
+// UnaryServerInterceptor 校验内部 token, 并将调用方身份写入 context
+// newUser 每次请求创建一个用于解密的空对象
+func UnaryServerInterceptor(auth authorize.IAuthorize, newUser func() authorize.IAuthorizeOther, opts ...Option) grpc.UnaryServerInterceptor {
+	v := &verifier{auth: auth, newUser: newUser, opts: newOptions(opts...)}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := v.opts.skipMethods[info.FullMethod]; ok {
+			return handler(ctx, req)
+		}
+		ctx, err := v.verify(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor 校验流请求的内部 token, 并将调用方身份写入 context
+func StreamServerInterceptor(auth authorize.IAuthorize, newUser func() authorize.IAuthorizeOther, opts ...Option) grpc.StreamServerInterceptor {
+	v := &verifier{auth: auth, newUser: newUser, opts: newOptions(opts...)}
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if _, ok := v.opts.skipMethods[info.FullMethod]; ok {
+			return handler(srv, ss)
+		}
+		ctx, err := v.verify(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
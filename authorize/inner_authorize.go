@@ -2,6 +2,8 @@ package authorize
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
@@ -10,9 +12,13 @@ import (
 
 const InnerAuthorizeInfo = "innerAuthorizeInfo"
 
+// ErrTokenClaimNotFound token 中缺少授权数据
+var ErrTokenClaimNotFound = errors.New("authorize: token claim not found")
+
 type InnerAuthorizeConfig struct {
 	Secret                string                     // 密钥
 	KeySignatureAlgorithm jwa.KeyEncryptionAlgorithm // 默认 A128KW (必须是对称加密算法)
+	Expire                time.Duration              // token 有效期, 0 表示不过期
 }
 
 // InnerAuthorize 用于内部授权
@@ -21,6 +27,7 @@ type InnerAuthorize struct {
 	signatureAlgorithm    jwa.SignatureAlgorithm     // token 签名算法
 	keySignatureAlgorithm jwa.KeyEncryptionAlgorithm // key 加密算法
 	key                   jwk.Key                    // 秘钥创建的key jwk.FromRaw([]byte(option.Secret))
+	expire                time.Duration              // token 有效期
 }
 
 type InnerAuthorizeOption func(*InnerAuthorize)
@@ -40,7 +47,8 @@ func NewInnerAuthorize(option *InnerAuthorizeConfig) *InnerAuthorize {
 		key:                   key,
 		keySignatureAlgorithm: option.KeySignatureAlgorithm,
 		signatureAlgorithm:    jwa.HS256,
-		secret:                option.Secret}
+		secret:                option.Secret,
+		expire:                option.Expire}
 	return auth
 }
 
@@ -49,10 +57,14 @@ func (innerAuthorize *InnerAuthorize) GenerateToken(ctx context.Context, user IA
 	if err != nil {
 		return "", err
 	}
-	jwtToken, err := jwt.NewBuilder().
+	builder := jwt.NewBuilder().
 		Issuer(InnerAuthorizeInfo).
-		Claim(InnerAuthorizeInfo, userEncrypt).
-		Build()
+		Claim(InnerAuthorizeInfo, userEncrypt)
+	if innerAuthorize.expire > 0 {
+		now := time.Now()
+		builder = builder.IssuedAt(now).Expiration(now.Add(innerAuthorize.expire))
+	}
+	jwtToken, err := builder.Build()
 	if err != nil {
 		return "", err
 	}
@@ -69,11 +81,11 @@ func (innerAuthorize *InnerAuthorize) VerifyToken(ctx context.Context, token str
 		return nil, err
 	}
 	claims := jwtToken.PrivateClaims()
-	userEncrypt, ok := claims[InnerAuthorizeInfo]
+	userEncrypt, ok := claims[InnerAuthorizeInfo].(string)
 	if !ok {
-		return nil, err
+		return nil, ErrTokenClaimNotFound
 	}
-	err = user.Decrypt(ctx, userEncrypt.(string), innerAuthorize.keySignatureAlgorithm, innerAuthorize.key)
+	err = user.Decrypt(ctx, userEncrypt, innerAuthorize.keySignatureAlgorithm, innerAuthorize.key)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,103 @@
+package mfa
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+
+	"github.com/zmicro-team/ztlib/authorize"
+)
+
+// token 中认证方式相关的 claims
+const (
+	ClaimAMR      = "amr"       // 认证方式 (RFC 8176)
+	ClaimMFA      = "mfa"       // 是否完成二次验证
+	ClaimAuthTime = "auth_time" // 认证时间
+)
+
+// 认证方式 (RFC 8176)
+const (
+	MethodPassword = "pwd"
+	MethodOTP      = "otp"
+	MethodSMS      = "sms"
+	MethodMFA      = "mfa"
+)
+
+// Claims 生成认证方式的 claims, 包含 otp/sms 时视为完成二次验证
+func Claims(methods ...string) map[string]any {
+	mfa := false
+	for _, m := range methods {
+		if m == MethodOTP || m == MethodSMS || m == MethodMFA {
+			mfa = true
+		}
+	}
+	return map[string]any{
+		ClaimAMR:      methods,
+		ClaimMFA:      mfa,
+		ClaimAuthTime: time.Now().Unix(),
+	}
+}
+
+// GenerateToken 签发带认证方式的 token
+func GenerateToken(ctx context.Context, auth *authorize.UserAuthorize, user authorize.IAuthorizeOther, methods ...string) (string, error) {
+	return auth.GenerateTokenWithClaims(ctx, user, Claims(methods...))
+}
+
+// Methods 获取 token 中的认证方式
+func Methods(token jwt.Token) []string {
+	v, ok := token.Get(ClaimAMR)
+	if !ok {
+		return nil
+	}
+	var methods []string
+	switch l := v.(type) {
+	case []string:
+		methods = l
+	case []any:
+		for _, m := range l {
+			if s, ok := m.(string); ok {
+				methods = append(methods, s)
+			}
+		}
+	}
+	return methods
+}
+
+// AuthTime 获取 token 中的认证时间
+func AuthTime(token jwt.Token) (time.Time, bool) {
+	v, ok := token.Get(ClaimAuthTime)
+	if !ok {
+		return time.Time{}, false
+	}
+	var sec int64
+	switch n := v.(type) {
+	case float64:
+		sec = int64(n)
+	case int64:
+		sec = n
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		sec = i
+	default:
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
+
+// HasMFA token 是否完成二次验证, maxAge > 0 时还要求认证时间在 maxAge 内
+func HasMFA(token jwt.Token, maxAge time.Duration) bool {
+	v, _ := token.Get(ClaimMFA)
+	if b, ok := v.(bool); !ok || !b {
+		return false
+	}
+	if maxAge <= 0 {
+		return true
+	}
+	t, ok := AuthTime(token)
+	return ok && time.Since(t) <= maxAge
+}
@@ -0,0 +1,61 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// ErrStepUpRequired 需要完成二次验证
+var ErrStepUpRequired = errors.New("mfa: step-up authentication required")
+
+type ctxTokenKey struct{}
+
+// WithToken 将已校验的 token 写入 context, 一般在认证中间件中调用
+func WithToken(ctx context.Context, token jwt.Token) context.Context {
+	return context.WithValue(ctx, ctxTokenKey{}, token)
+}
+
+// FromToken 获取 context 中的 token
+func FromToken(ctx context.Context) (v jwt.Token, ok bool) {
+	v, ok = ctx.Value(ctxTokenKey{}).(jwt.Token)
+	return v, ok && v != nil
+}
+
+// StepUp is the gin step-up authentication middleware.
+type StepUp struct {
+	// Token 获取已校验的 token, 默认从 request context 中读取 (WithToken)
+	Token func(c *gin.Context) (jwt.Token, bool)
+	// ErrFallback 未完成二次验证时的处理
+	ErrFallback func(c *gin.Context, statusCode int, err error)
+}
+
+// Required returns a gin middleware which requires the token to carry mfa claim,
+// maxAge > 0 时要求二次验证在 maxAge 内完成.
+func (s *StepUp) Required(maxAge time.Duration) gin.HandlerFunc {
+	if s.Token == nil {
+		s.Token = func(c *gin.Context) (jwt.Token, bool) {
+			return FromToken(c.Request.Context())
+		}
+	}
+	if s.ErrFallback == nil {
+		s.ErrFallback = func(c *gin.Context, statusCode int, err error) {
+			c.String(statusCode, err.Error())
+		}
+	}
+	return func(c *gin.Context) {
+		token, ok := s.Token(c)
+		if !ok || !HasMFA(token, maxAge) {
+			// RFC 9470
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+			c.Abort()
+			s.ErrFallback(c, http.StatusUnauthorized, ErrStepUpRequired)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,240 @@
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSecret secret 不是合法的 base32
+	ErrInvalidSecret = errors.New("mfa: invalid secret")
+	// ErrInvalidDigits 位数不在 6 到 8 之间
+	ErrInvalidDigits = errors.New("mfa: digits must be between 6 and 8")
+	// ErrInvalidPeriod 时间步长不是正整数秒
+	ErrInvalidPeriod = errors.New("mfa: period must be a positive whole number of seconds")
+)
+
+// Algorithm HMAC 算法
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret 生成 base32 编码的随机密钥, size 为字节数, 默认 20 (160 位)
+func GenerateSecret(size int) (string, error) {
+	if size <= 0 {
+		size = 20
+	}
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(b), nil
+}
+
+// DecodeSecret 解码 base32 密钥, 忽略空格、大小写和填充
+func DecodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	b, err := b32.DecodeString(secret)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return b, nil
+}
+
+// HOTP 计算一次性密码 (RFC 4226)
+func HOTP(key []byte, counter uint64, digits int, algorithm Algorithm) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(algorithm.hash(), key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%mod)
+}
+
+// OTP HOTP/TOTP 配置
+type OTP struct {
+	Secret    string        // base32 密钥
+	Digits    int           // 位数, 6 到 8, 默认 6
+	Algorithm Algorithm     // 默认 SHA1
+	Period    time.Duration // TOTP 时间步长, 默认 30s
+	Skew      uint          // 允许前后偏移的步数, 用于容忍时钟漂移/计数器不同步
+}
+
+// Validate 校验位数、时间步长与密钥
+func (o *OTP) Validate() error {
+	_, err := o.key()
+	return err
+}
+
+func (o *OTP) key() ([]byte, error) {
+	if d := o.digits(); d < 6 || d > 8 {
+		return nil, ErrInvalidDigits
+	}
+	if p := o.period(); p%time.Second != 0 {
+		return nil, ErrInvalidPeriod
+	}
+	return DecodeSecret(o.Secret)
+}
+
+func (o *OTP) digits() int {
+	if o.Digits <= 0 {
+		return 6
+	}
+	return o.Digits
+}
+
+func (o *OTP) algorithm() Algorithm {
+	if o.Algorithm == "" {
+		return SHA1
+	}
+	return o.Algorithm
+}
+
+func (o *OTP) period() time.Duration {
+	if o.Period <= 0 {
+		return 30 * time.Second
+	}
+	return o.Period
+}
+
+// Counter 时间 t 对应的 TOTP 计数 (RFC 6238), Period 非法时返回 0
+func (o *OTP) Counter(t time.Time) uint64 {
+	p := o.period()
+	if p%time.Second != 0 {
+		return 0
+	}
+	return uint64(t.Unix()) / uint64(p/time.Second)
+}
+
+// HOTP 计算计数 counter 的一次性密码
+func (o *OTP) HOTP(counter uint64) (string, error) {
+	key, err := o.key()
+	if err != nil {
+		return "", err
+	}
+	return HOTP(key, counter, o.digits(), o.algorithm()), nil
+}
+
+// TOTP 计算时间 t 的一次性密码
+func (o *OTP) TOTP(t time.Time) (string, error) {
+	if err := o.Validate(); err != nil {
+		return "", err
+	}
+	return o.HOTP(o.Counter(t))
+}
+
+// VerifyHOTP 在 [counter, counter+Skew] 内校验, 成功返回匹配的计数, 调用方应保存 next = 匹配计数 + 1
+func (o *OTP) VerifyHOTP(code string, counter uint64) (uint64, bool) {
+	key, err := o.key()
+	if err != nil || len(code) != o.digits() {
+		return 0, false
+	}
+	for i := uint64(0); i <= uint64(o.Skew); i++ {
+		if equal(HOTP(key, counter+i, o.digits(), o.algorithm()), code) {
+			return counter + i, true
+		}
+	}
+	return 0, false
+}
+
+// VerifyTOTP 在时间 t 前后 Skew 个步长内校验, 成功返回匹配的计数
+// 调用方可保存最后使用的计数, 拒绝小于等于该计数的 code 以防重放
+func (o *OTP) VerifyTOTP(code string, t time.Time) (uint64, bool) {
+	key, err := o.key()
+	if err != nil || len(code) != o.digits() {
+		return 0, false
+	}
+	current := o.Counter(t)
+	for i := -int64(o.Skew); i <= int64(o.Skew); i++ {
+		counter := int64(current) + i
+		if counter < 0 {
+			continue
+		}
+		if equal(HOTP(key, uint64(counter), o.digits(), o.algorithm()), code) {
+			return uint64(counter), true
+		}
+	}
+	return 0, false
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// Kind otpauth 类型
+type Kind string
+
+const (
+	KindTOTP Kind = "totp"
+	KindHOTP Kind = "hotp"
+)
+
+// ProvisioningURI 生成 otpauth:// 链接, 可直接作为二维码内容供验证器 App 扫描
+// https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func (o *OTP) ProvisioningURI(kind Kind, issuer, account string, counter ...uint64) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+	q := url.Values{}
+	q.Set("secret", strings.TrimRight(strings.ToUpper(o.Secret), "="))
+	if issuer != "" {
+		q.Set("issuer", issuer)
+	}
+	q.Set("algorithm", string(o.algorithm()))
+	q.Set("digits", strconv.Itoa(o.digits()))
+	if kind == KindHOTP {
+		var c uint64
+		if len(counter) > 0 {
+			c = counter[0]
+		}
+		q.Set("counter", strconv.FormatUint(c, 10))
+	} else {
+		kind = KindTOTP
+		q.Set("period", strconv.Itoa(int(o.period()/time.Second)))
+	}
+	return "otpauth://" + string(kind) + "/" + label + "?" + q.Encode()
+}
+
+// QRPayload 二维码内容, 与 ProvisioningURI 相同
+func (o *OTP) QRPayload(kind Kind, issuer, account string, counter ...uint64) []byte {
+	return []byte(o.ProvisioningURI(kind, issuer, account, counter...))
+}
@@ -0,0 +1,155 @@
+package mfa
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func b32secret(s string) string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte(s))
+}
+
+// RFC 4226 附录 D
+func TestHOTP_RFC4226(t *testing.T) {
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	key := []byte("12345678901234567890")
+	for i, w := range want {
+		assert.Equal(t, w, HOTP(key, uint64(i), 6, SHA1))
+	}
+}
+
+func TestOTP_Digits(t *testing.T) {
+	secret := b32secret("12345678901234567890")
+	for _, digits := range []int{1, 5, 9, 10} {
+		otp := &OTP{Secret: secret, Digits: digits}
+		assert.ErrorIs(t, otp.Validate(), ErrInvalidDigits, digits)
+		_, err := otp.TOTP(time.Now())
+		assert.ErrorIs(t, err, ErrInvalidDigits, digits)
+		_, ok := otp.VerifyHOTP(strings.Repeat("0", digits), 0)
+		assert.False(t, ok, digits)
+	}
+	assert.NoError(t, (&OTP{Secret: secret}).Validate())
+	assert.NoError(t, (&OTP{Secret: secret, Digits: 8}).Validate())
+	// 包级 HOTP 不溢出
+	assert.Len(t, HOTP([]byte("12345678901234567890"), 0, 10, SHA1), 10)
+}
+
+func TestOTP_Period(t *testing.T) {
+	secret := b32secret("12345678901234567890")
+	for _, period := range []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond} {
+		otp := &OTP{Secret: secret, Period: period}
+		assert.ErrorIs(t, otp.Validate(), ErrInvalidPeriod, period)
+		_, err := otp.TOTP(time.Now())
+		assert.ErrorIs(t, err, ErrInvalidPeriod, period)
+		_, ok := otp.VerifyTOTP("000000", time.Now())
+		assert.False(t, ok, period)
+		assert.Zero(t, otp.Counter(time.Now()), period)
+	}
+	assert.NoError(t, (&OTP{Secret: secret, Period: time.Minute}).Validate())
+}
+
+// RFC 6238 附录 B
+func TestTOTP_RFC6238(t *testing.T) {
+	secrets := map[Algorithm]string{
+		SHA1:   b32secret("12345678901234567890"),
+		SHA256: b32secret("12345678901234567890123456789012"),
+		SHA512: b32secret("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	tests := []struct {
+		unix int64
+		alg  Algorithm
+		want string
+	}{
+		{59, SHA1, "94287082"},
+		{59, SHA256, "46119246"},
+		{59, SHA512, "90693936"},
+		{1111111109, SHA1, "07081804"},
+		{1111111109, SHA256, "68084774"},
+		{1234567890, SHA512, "93441116"},
+		{20000000000, SHA1, "65353130"},
+	}
+	for _, tt := range tests {
+		otp := &OTP{Secret: secrets[tt.alg], Digits: 8, Algorithm: tt.alg}
+		code, err := otp.TOTP(time.Unix(tt.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, code, "%d %s", tt.unix, tt.alg)
+	}
+}
+
+func TestOTP_VerifyTOTP(t *testing.T) {
+	secret, err := GenerateSecret(0)
+	assert.NoError(t, err)
+	otp := &OTP{Secret: secret, Skew: 1}
+	now := time.Now()
+
+	code, err := otp.TOTP(now.Add(-30 * time.Second))
+	assert.NoError(t, err)
+	counter, ok := otp.VerifyTOTP(code, now)
+	assert.True(t, ok)
+	assert.Equal(t, otp.Counter(now)-1, counter)
+
+	code, err = otp.TOTP(now.Add(-90 * time.Second))
+	assert.NoError(t, err)
+	_, ok = otp.VerifyTOTP(code, now)
+	assert.False(t, ok)
+
+	_, ok = otp.VerifyTOTP("12345", now)
+	assert.False(t, ok)
+	_, ok = (&OTP{Secret: "!!"}).VerifyTOTP("123456", now)
+	assert.False(t, ok)
+}
+
+func TestOTP_VerifyHOTP(t *testing.T) {
+	otp := &OTP{Secret: b32secret("12345678901234567890"), Skew: 2}
+	counter, ok := otp.VerifyHOTP("359152", 0)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), counter)
+	_, ok = otp.VerifyHOTP("969429", 0)
+	assert.False(t, ok)
+}
+
+func TestOTP_ProvisioningURI(t *testing.T) {
+	otp := &OTP{Secret: "JBSWY3DPEHPK3PXP"}
+	uri := otp.ProvisioningURI(KindTOTP, "Example Co", "alice@example.com")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Example%20Co:alice@example.com?"))
+
+	u, err := url.Parse(uri)
+	assert.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", q.Get("secret"))
+	assert.Equal(t, "Example Co", q.Get("issuer"))
+	assert.Equal(t, "SHA1", q.Get("algorithm"))
+	assert.Equal(t, "6", q.Get("digits"))
+	assert.Equal(t, "30", q.Get("period"))
+	assert.Equal(t, []byte(uri), otp.QRPayload(KindTOTP, "Example Co", "alice@example.com"))
+
+	u, err = url.Parse(otp.ProvisioningURI(KindHOTP, "", "alice", 7))
+	assert.NoError(t, err)
+	assert.Equal(t, "hotp", u.Host)
+	assert.Equal(t, "7", u.Query().Get("counter"))
+	assert.Empty(t, u.Query().Get("period"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes := GenerateRecoveryCodes(10)
+	assert.Len(t, codes, 10)
+	assert.Len(t, hashes, 10)
+	assert.Regexp(t, `^[a-z0-9]{5}-[a-z0-9]{5}$`, codes[0])
+	assert.NotContains(t, hashes, codes[0])
+
+	remain, ok := UseRecoveryCode(hashes, strings.ToUpper(codes[3]))
+	assert.True(t, ok)
+	assert.Len(t, remain, 9)
+	assert.Len(t, hashes, 10)
+
+	// 只能使用一次
+	_, ok = UseRecoveryCode(remain, codes[3])
+	assert.False(t, ok)
+	_, ok = UseRecoveryCode(remain, "aaaaa-bbbbb")
+	assert.False(t, ok)
+}
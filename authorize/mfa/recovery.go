@@ -0,0 +1,50 @@
+package mfa
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"github.com/zmicro-team/ztlib/random"
+)
+
+// 恢复码字符集, 去掉容易混淆的 0/1/i/l/o
+const recoveryLetters = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// GenerateRecoveryCodes 生成 n 个恢复码, 格式 xxxxx-xxxxx
+// codes 只展示给用户一次, 服务端只保存 hashes
+func GenerateRecoveryCodes(n int) (codes []string, hashes []string) {
+	for i := 0; i < n; i++ {
+		raw := random.SecureRandn(10, recoveryLetters)
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes
+}
+
+// HashRecoveryCode 计算恢复码哈希, 忽略大小写、空格和 -
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// UseRecoveryCode 校验恢复码, 成功时返回去掉已使用恢复码后的 hashes, 调用方需保存以保证只能使用一次
+func UseRecoveryCode(hashes []string, code string) ([]string, bool) {
+	h := HashRecoveryCode(code)
+	idx := -1
+	for i, v := range hashes {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(h)) == 1 {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return hashes, false
+	}
+	remain := make([]string, 0, len(hashes)-1)
+	remain = append(remain, hashes[:idx]...)
+	return append(remain, hashes[idx+1:]...), true
+}
@@ -0,0 +1,70 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/zmicro-team/ztlib/limiter/limit"
+)
+
+var (
+	// ErrInvalidCode 验证码错误
+	ErrInvalidCode = errors.New("mfa: invalid code")
+	// ErrTooManyAttempts 失败次数过多, 已被限制
+	ErrTooManyAttempts = errors.New("mfa: too many failed attempts")
+)
+
+// Verifier 带失败次数限制的二次验证
+type Verifier struct {
+	limiter limit.PeriodFailureLimitDriver
+	now     func() time.Time
+}
+
+// NewVerifier 创建二次验证, limiter 一般为 limit.NewPeriodFailureLimit(store, limit.WithQuota(5), ...)
+func NewVerifier(limiter limit.PeriodFailureLimitDriver) *Verifier {
+	return &Verifier{limiter: limiter, now: time.Now}
+}
+
+// VerifyTOTP 校验 TOTP, key 为限制的维度 (如用户 id), 成功时返回匹配的计数
+func (v *Verifier) VerifyTOTP(ctx context.Context, key string, otp *OTP, code string) (uint64, error) {
+	// 配置错误不计入失败次数
+	if err := otp.Validate(); err != nil {
+		return 0, err
+	}
+	counter, ok := otp.VerifyTOTP(code, v.now())
+	return counter, v.check(ctx, key, ok)
+}
+
+// VerifyHOTP 校验 HOTP, 成功时返回匹配的计数
+func (v *Verifier) VerifyHOTP(ctx context.Context, key string, otp *OTP, code string, counter uint64) (uint64, error) {
+	if err := otp.Validate(); err != nil {
+		return 0, err
+	}
+	matched, ok := otp.VerifyHOTP(code, counter)
+	return matched, v.check(ctx, key, ok)
+}
+
+// VerifyRecoveryCode 校验恢复码, 成功时返回剩余的 hashes
+func (v *Verifier) VerifyRecoveryCode(ctx context.Context, key string, hashes []string, code string) ([]string, error) {
+	remain, ok := UseRecoveryCode(hashes, code)
+	if err := v.check(ctx, key, ok); err != nil {
+		return hashes, err
+	}
+	return remain, nil
+}
+
+func (v *Verifier) check(ctx context.Context, key string, ok bool) error {
+	sts, err := v.limiter.Check(ctx, key, ok)
+	if err != nil {
+		return err
+	}
+	switch {
+	case sts.IsOverQuota():
+		return ErrTooManyAttempts
+	case !ok:
+		return ErrInvalidCode
+	default:
+		return nil
+	}
+}
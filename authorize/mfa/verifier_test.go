@@ -0,0 +1,116 @@
+package mfa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zmicro-team/ztlib/authorize"
+	"github.com/zmicro-team/ztlib/limiter/limit"
+	v9 "github.com/zmicro-team/ztlib/limiter/limit/redis/v9"
+)
+
+func TestVerifier(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer mr.Close()
+
+	store := v9.NewPeriodFailureStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	v := NewVerifier(limit.NewPeriodFailureLimit(store, limit.WithQuota(2), limit.WithPeriod(time.Minute)))
+	ctx := context.Background()
+
+	secret, err := GenerateSecret(0)
+	assert.NoError(t, err)
+	otp := &OTP{Secret: secret}
+	code, err := otp.TOTP(time.Now())
+	assert.NoError(t, err)
+
+	// 配置错误不计入失败次数
+	_, err = v.VerifyTOTP(ctx, "1001", &OTP{Secret: secret, Digits: 10}, "0000000000")
+	assert.ErrorIs(t, err, ErrInvalidDigits)
+
+	_, err = v.VerifyTOTP(ctx, "1001", otp, code)
+	assert.NoError(t, err)
+
+	_, err = v.VerifyTOTP(ctx, "1001", otp, "000000")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	_, err = v.VerifyTOTP(ctx, "1001", otp, "000000")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	_, err = v.VerifyTOTP(ctx, "1001", otp, "000000")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	// 超过限制后正确的验证码也被拒绝
+	_, err = v.VerifyTOTP(ctx, "1001", otp, code)
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	codes, hashes := GenerateRecoveryCodes(2)
+	remain, err := v.VerifyRecoveryCode(ctx, "1002", hashes, codes[0])
+	assert.NoError(t, err)
+	assert.Len(t, remain, 1)
+	remain, err = v.VerifyRecoveryCode(ctx, "1002", remain, codes[0])
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	assert.Len(t, remain, 1)
+}
+
+func TestStepUp(t *testing.T) {
+	auth := authorize.NewUserAuthorize(&authorize.AuthorizeConfig{
+		Expire:                time.Hour,
+		KeySignatureAlgorithm: jwa.RSA_OAEP,
+		PrivateKeyPath:        "../tools/rsa-private.key",
+		PublicKeyPath:         "../tools/rsa-public.key",
+		SecretKey:             "secret",
+		SignatureAlgorithm:    jwa.HS256,
+	})
+	ctx := context.Background()
+	user := &authorize.UserAuthorizeOther{Id: "1001"}
+
+	pwdToken, err := GenerateToken(ctx, auth, user, MethodPassword)
+	assert.NoError(t, err)
+	mfaToken, err := GenerateToken(ctx, auth, user, MethodPassword, MethodOTP)
+	assert.NoError(t, err)
+
+	tok, err := auth.VerifyToken(ctx, mfaToken, new(authorize.UserAuthorizeOther))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{MethodPassword, MethodOTP}, Methods(tok))
+	assert.True(t, HasMFA(tok, time.Minute))
+	at, ok := AuthTime(tok)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now(), at, 5*time.Second)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		tok, err := auth.VerifyToken(c.Request.Context(), c.GetHeader("Token"), new(authorize.UserAuthorizeOther))
+		if err == nil {
+			c.Request = c.Request.WithContext(WithToken(c.Request.Context(), tok))
+		}
+	})
+	s := &StepUp{}
+	r.GET("/sensitive", s.Required(5*time.Minute), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for token, want := range map[string]int{pwdToken: http.StatusUnauthorized, mfaToken: http.StatusOK, "": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/sensitive", nil)
+		req.Header.Set("Token", token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code)
+		if want == http.StatusUnauthorized {
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), "insufficient_user_authentication")
+		}
+	}
+
+	old, err := jwt.NewBuilder().Claim(ClaimMFA, true).Claim(ClaimAuthTime, time.Now().Add(-time.Hour).Unix()).Build()
+	assert.NoError(t, err)
+	assert.True(t, HasMFA(old, 0))
+	assert.False(t, HasMFA(old, time.Minute))
+}
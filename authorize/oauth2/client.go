@@ -0,0 +1,137 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// GrantType 授权类型
+type GrantType string
+
+const (
+	GrantClientCredentials GrantType = "client_credentials"
+	GrantAuthorizationCode GrantType = "authorization_code"
+	GrantRefreshToken      GrantType = "refresh_token"
+)
+
+// ErrClientNotFound 客户端不存在
+var ErrClientNotFound = errors.New("oauth2: client not found")
+
+// Client 注册的第三方应用
+type Client struct {
+	Id           string      `json:"id"`
+	Name         string      `json:"name"`
+	SecretHash   string      `json:"secret_hash,omitempty"` // HashSecret(secret), 公开客户端为空
+	Public       bool        `json:"public"`                // 公开客户端 (SPA/移动端), 不能保存 secret, 必须使用 PKCE
+	RedirectURIs []string    `json:"redirect_uris"`
+	GrantTypes   []GrantType `json:"grant_types"`
+	Scopes       []string    `json:"scopes"` // 允许申请的 scope
+}
+
+// HashSecret 计算客户端密钥的哈希, 服务端只保存哈希
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySecret 校验客户端密钥
+func (c *Client) VerifySecret(secret string) bool {
+	if c.Public || c.SecretHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashSecret(secret)), []byte(c.SecretHash)) == 1
+}
+
+// AllowGrant 是否允许该授权类型
+func (c *Client) AllowGrant(grant GrantType) bool {
+	for _, g := range c.GrantTypes {
+		if g == grant {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowRedirectURI 回调地址是否已注册, 需完全匹配
+func (c *Client) AllowRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveScope 校验申请的 scope, 为空时返回客户端全部 scope
+func (c *Client) ResolveScope(scope string) (string, bool) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return strings.Join(c.Scopes, " "), true
+	}
+	for _, s := range requested {
+		if !containsString(c.Scopes, s) {
+			return "", false
+		}
+	}
+	return strings.Join(requested, " "), true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientStore 客户端注册表
+type ClientStore interface {
+	GetClient(ctx context.Context, id string) (*Client, error)
+}
+
+var _ ClientStore = (*MemoryClientStore)(nil)
+
+// MemoryClientStore 内存客户端注册表
+type MemoryClientStore struct {
+	mutex   sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewMemoryClientStore 创建内存客户端注册表
+func NewMemoryClientStore(clients ...*Client) *MemoryClientStore {
+	s := &MemoryClientStore{clients: make(map[string]*Client)}
+	for _, c := range clients {
+		s.Set(c)
+	}
+	return s
+}
+
+// Set 注册或更新客户端
+func (s *MemoryClientStore) Set(c *Client) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clients[c.Id] = c
+}
+
+// Delete 删除客户端
+func (s *MemoryClientStore) Delete(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.clients, id)
+}
+
+func (s *MemoryClientStore) GetClient(_ context.Context, id string) (*Client, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	c, ok := s.clients[id]
+	if !ok {
+		return nil, ErrClientNotFound
+	}
+	return c, nil
+}
@@ -0,0 +1,165 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// ParseAuthorizeRequest 从授权请求中解析参数
+func ParseAuthorizeRequest(r *http.Request) (*AuthorizeRequest, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, ErrInvalidRequest.WithDescription("%v", err)
+	}
+	return &AuthorizeRequest{
+		ResponseType:        r.Form.Get("response_type"),
+		ClientId:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		Scope:               r.Form.Get("scope"),
+		State:               r.Form.Get("state"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+	}, nil
+}
+
+// AuthenticateClient 客户端认证, 支持 HTTP Basic 和表单参数 (RFC 6749 2.3.1)
+// 公开客户端只需要 client_id
+func (s *Server) AuthenticateClient(r *http.Request) (*Client, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, ErrInvalidRequest.WithDescription("%v", err)
+	}
+	clientId, secret, basic := r.BasicAuth()
+	if basic {
+		var err1, err2 error
+		clientId, err1 = url.QueryUnescape(clientId)
+		secret, err2 = url.QueryUnescape(secret)
+		if err1 != nil || err2 != nil {
+			return nil, ErrInvalidClient
+		}
+	} else {
+		clientId = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+	if clientId == "" {
+		return nil, ErrInvalidClient
+	}
+	client, err := s.clients.GetClient(r.Context(), clientId)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+	if client.Public {
+		if secret != "" {
+			return nil, ErrInvalidClient
+		}
+		return client, nil
+	}
+	if !client.VerifySecret(secret) {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// HandleToken token 端点 (RFC 6749 3.2)
+func (s *Server) HandleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, ErrInvalidRequest.WithDescription("method must be POST"))
+		return
+	}
+	client, err := s.AuthenticateClient(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var resp *TokenResponse
+	switch GrantType(r.PostForm.Get("grant_type")) {
+	case GrantClientCredentials:
+		resp, err = s.ClientCredentials(r.Context(), client, r.PostForm.Get("scope"))
+	case GrantAuthorizationCode:
+		resp, err = s.ExchangeCode(r.Context(), client,
+			r.PostForm.Get("code"),
+			r.PostForm.Get("redirect_uri"),
+			r.PostForm.Get("code_verifier"),
+		)
+	case GrantRefreshToken:
+		resp, err = s.Refresh(r.Context(), client, r.PostForm.Get("refresh_token"), r.PostForm.Get("scope"))
+	default:
+		err = ErrUnsupportedGrantType
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// HandleIntrospect token 内省端点 (RFC 7662)
+func (s *Server) HandleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, ErrInvalidRequest.WithDescription("method must be POST"))
+		return
+	}
+	if _, err := s.AuthenticateClient(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeError(w, ErrInvalidRequest.WithDescription("token required"))
+		return
+	}
+	resp, err := s.Introspect(r.Context(), token, r.PostForm.Get("token_type_hint"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// HandleRevoke token 吊销端点 (RFC 7009)
+func (s *Server) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, ErrInvalidRequest.WithDescription("method must be POST"))
+		return
+	}
+	client, err := s.AuthenticateClient(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeError(w, ErrInvalidRequest.WithDescription("token required"))
+		return
+	}
+	switch hint := r.PostForm.Get("token_type_hint"); hint {
+	case "", "access_token", "refresh_token":
+		if err := s.Revoke(r.Context(), client, token, hint); err != nil {
+			writeError(w, err)
+			return
+		}
+	default:
+		writeError(w, ErrUnsupportedTokenType)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = ErrServerError
+	}
+	if e.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+	}
+	writeJSON(w, e.Status, e)
+}
@@ -0,0 +1,53 @@
+package oauth2
+
+import (
+	"context"
+	"time"
+
+	"github.com/zmicro-team/ztlib/authorize"
+)
+
+// Option 授权服务配置
+type Option func(*Server)
+
+// WithCodeExpire 授权码有效期, 默认 10 分钟
+func WithCodeExpire(d time.Duration) Option {
+	return func(s *Server) {
+		if d > 0 {
+			s.codeExpire = d
+		}
+	}
+}
+
+// WithRefreshTokenExpire 刷新令牌有效期, 默认 30 天, 0 表示不签发刷新令牌
+func WithRefreshTokenExpire(d time.Duration) Option {
+	return func(s *Server) {
+		if d >= 0 {
+			s.refreshTokenExpire = d
+		}
+	}
+}
+
+// WithRequirePKCE 所有客户端的授权码模式都必须使用 PKCE, 公开客户端始终需要
+func WithRequirePKCE() Option {
+	return func(s *Server) {
+		s.requirePKCE = true
+	}
+}
+
+// WithAllowPlainPKCE 允许 code_challenge_method=plain, 默认只允许 S256
+func WithAllowPlainPKCE() Option {
+	return func(s *Server) {
+		s.allowPlainPKCE = true
+	}
+}
+
+// WithUserLoader 根据用户 id 加载写入 access token 的用户数据
+// 默认只包含 id 的 authorize.UserAuthorizeOther
+func WithUserLoader(loader func(ctx context.Context, userId string) (authorize.IAuthorizeOther, error)) Option {
+	return func(s *Server) {
+		if loader != nil {
+			s.loadUser = loader
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package oauth2
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+// PKCE code_challenge_method (RFC 7636)
+const (
+	CodeChallengePlain = "plain"
+	CodeChallengeS256  = "S256"
+)
+
+// S256Challenge 根据 code_verifier 计算 S256 code_challenge
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// validVerifier code_verifier 长度 43-128, 字符集 [A-Z a-z 0-9 - . _ ~]
+func validVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for i := 0; i < len(verifier); i++ {
+		c := verifier[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// verifyCodeChallenge 校验 code_verifier 与 code_challenge 是否匹配
+func verifyCodeChallenge(challenge, method, verifier string) bool {
+	if !validVerifier(verifier) {
+		return false
+	}
+	var computed string
+	switch method {
+	case CodeChallengeS256:
+		computed = S256Challenge(verifier)
+	case CodeChallengePlain, "":
+		computed = verifier
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
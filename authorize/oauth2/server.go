@@ -0,0 +1,437 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+
+	"github.com/zmicro-team/ztlib/authorize"
+	"github.com/zmicro-team/ztlib/random"
+)
+
+// access token 中的 claims
+const (
+	ClaimJwtId    = "jti"
+	ClaimSubject  = "sub"
+	ClaimClientId = "client_id"
+	ClaimScope    = "scope"
+)
+
+const (
+	// TokenTypeBearer token_type
+	TokenTypeBearer = "Bearer"
+	// UserTypeClient client_credentials 模式下 token 中用户的 Type
+	UserTypeClient = "client"
+
+	// TokenUseAccess access token 的 authorize.ClaimTokenUse
+	TokenUseAccess = "oauth2_access"
+
+	tokenLength = 43
+)
+
+// TokenResponse token 接口的响应 (RFC 6749 5.1)
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// AuthorizeRequest 授权请求 (RFC 6749 4.1.1, RFC 7636 4.3)
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientId            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// RedirectURIDefault 请求未携带 redirect_uri, 使用了客户端唯一注册的地址
+	RedirectURIDefault bool
+}
+
+// Server OAuth2 授权服务, access token 由 UserAuthorize 签发并标记为 TokenUseAccess,
+// 只能通过 VerifyAccessToken 校验, UserAuthorize.VerifyToken 会拒绝
+type Server struct {
+	auth               *authorize.UserAuthorize
+	clients            ClientStore
+	storage            Storage
+	codeExpire         time.Duration
+	refreshTokenExpire time.Duration
+	requirePKCE        bool
+	allowPlainPKCE     bool
+	loadUser           func(ctx context.Context, userId string) (authorize.IAuthorizeOther, error)
+}
+
+// NewServer 创建授权服务
+func NewServer(auth *authorize.UserAuthorize, clients ClientStore, storage Storage, opts ...Option) *Server {
+	s := &Server{
+		auth:               auth,
+		clients:            clients,
+		storage:            storage,
+		codeExpire:         10 * time.Minute,
+		refreshTokenExpire: 30 * 24 * time.Hour,
+		loadUser: func(_ context.Context, userId string) (authorize.IAuthorizeOther, error) {
+			return &authorize.UserAuthorizeOther{Id: userId}, nil
+		},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ValidateAuthorizeRequest 校验授权请求, 通过后由业务完成登录和用户确认, 再调用 Authorize
+// 返回的错误为 *Error, client 或 redirect_uri 无效时不能重定向回客户端
+func (s *Server) ValidateAuthorizeRequest(ctx context.Context, req *AuthorizeRequest) (*Client, error) {
+	client, err := s.clients.GetClient(ctx, req.ClientId)
+	if err != nil {
+		return nil, ErrInvalidClient.WithDescription("unknown client")
+	}
+	if req.RedirectURI == "" && len(client.RedirectURIs) == 1 {
+		req.RedirectURI = client.RedirectURIs[0]
+		req.RedirectURIDefault = true
+	}
+	if !client.AllowRedirectURI(req.RedirectURI) {
+		return nil, ErrInvalidRequest.WithDescription("redirect_uri not registered")
+	}
+	if req.ResponseType != "code" {
+		return client, ErrUnsupportedResponseType
+	}
+	if !client.AllowGrant(GrantAuthorizationCode) {
+		return client, ErrUnauthorizedClient
+	}
+	scope, ok := client.ResolveScope(req.Scope)
+	if !ok {
+		return client, ErrInvalidScope
+	}
+	req.Scope = scope
+
+	if req.CodeChallenge == "" {
+		if client.Public || s.requirePKCE {
+			return client, ErrInvalidRequest.WithDescription("code_challenge required")
+		}
+		return client, nil
+	}
+	if req.CodeChallengeMethod == "" {
+		req.CodeChallengeMethod = CodeChallengePlain
+	}
+	switch req.CodeChallengeMethod {
+	case CodeChallengeS256:
+	case CodeChallengePlain:
+		if !s.allowPlainPKCE {
+			return client, ErrInvalidRequest.WithDescription("code_challenge_method plain not allowed")
+		}
+	default:
+		return client, ErrInvalidRequest.WithDescription("unsupported code_challenge_method")
+	}
+	return client, nil
+}
+
+// Authorize 用户同意授权后签发授权码, 返回重定向到客户端的地址
+func (s *Server) Authorize(ctx context.Context, req *AuthorizeRequest, userId string) (string, error) {
+	if _, err := s.ValidateAuthorizeRequest(ctx, req); err != nil {
+		return "", err
+	}
+	code := &AuthorizationCode{
+		Code:                random.SecureRandn(tokenLength, ""),
+		ClientId:            req.ClientId,
+		UserId:              userId,
+		RedirectURI:         req.RedirectURI,
+		RedirectURIDefault:  req.RedirectURIDefault,
+		Scope:               req.Scope,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpireAt:            time.Now().Add(s.codeExpire),
+	}
+	if err := s.storage.SaveAuthorizationCode(ctx, code); err != nil {
+		return "", err
+	}
+	return redirectWith(req.RedirectURI, url.Values{"code": {code.Code}}, req.State)
+}
+
+// AuthorizeErrorRedirect 授权失败时重定向到客户端的地址 (RFC 6749 4.1.2.1)
+func AuthorizeErrorRedirect(req *AuthorizeRequest, err error) (string, error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = ErrServerError
+	}
+	values := url.Values{"error": {e.Code}}
+	if e.Description != "" {
+		values.Set("error_description", e.Description)
+	}
+	return redirectWith(req.RedirectURI, values, req.State)
+}
+
+func redirectWith(redirectURI string, values url.Values, state string) (string, error) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, v := range values {
+		q[k] = v
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// ClientCredentials client_credentials 模式, 以客户端自身身份签发 token
+func (s *Server) ClientCredentials(ctx context.Context, client *Client, scope string) (*TokenResponse, error) {
+	if client.Public || !client.AllowGrant(GrantClientCredentials) {
+		return nil, ErrUnauthorizedClient
+	}
+	scope, ok := client.ResolveScope(scope)
+	if !ok {
+		return nil, ErrInvalidScope
+	}
+	user := &authorize.UserAuthorizeOther{Id: client.Id, Type: UserTypeClient, Name: client.Name}
+	return s.issue(ctx, client, user, scope, false)
+}
+
+// ExchangeCode authorization_code 模式, 使用授权码换取 token
+func (s *Server) ExchangeCode(ctx context.Context, client *Client, code, redirectURI, codeVerifier string) (*TokenResponse, error) {
+	if !client.AllowGrant(GrantAuthorizationCode) {
+		return nil, ErrUnauthorizedClient
+	}
+	ac, err := s.storage.TakeAuthorizationCode(ctx, code)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidGrant.WithDescription("invalid authorization code")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ac.ClientId != client.Id {
+		return nil, ErrInvalidGrant.WithDescription("authorization code was issued to another client")
+	}
+	// 授权请求携带了 redirect_uri 时必须一致 (RFC 6749 4.1.3)
+	if (redirectURI != "" || !ac.RedirectURIDefault) && redirectURI != ac.RedirectURI {
+		return nil, ErrInvalidGrant.WithDescription("redirect_uri mismatch")
+	}
+	if ac.CodeChallenge != "" {
+		if !verifyCodeChallenge(ac.CodeChallenge, ac.CodeChallengeMethod, codeVerifier) {
+			return nil, ErrInvalidGrant.WithDescription("code_verifier mismatch")
+		}
+	} else if codeVerifier != "" {
+		return nil, ErrInvalidGrant.WithDescription("code_verifier not expected")
+	}
+	user, err := s.loadUser(ctx, ac.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, client, user, ac.Scope, true)
+}
+
+// Refresh refresh_token 模式, 刷新令牌使用后轮换
+func (s *Server) Refresh(ctx context.Context, client *Client, refreshToken, scope string) (*TokenResponse, error) {
+	if !client.AllowGrant(GrantRefreshToken) {
+		return nil, ErrUnauthorizedClient
+	}
+	rt, err := s.storage.GetRefreshToken(ctx, refreshToken)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidGrant.WithDescription("invalid refresh token")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rt.ClientId != client.Id {
+		return nil, ErrInvalidGrant.WithDescription("refresh token was issued to another client")
+	}
+	// 刷新时只能缩小 scope
+	if scope == "" {
+		scope = rt.Scope
+	} else {
+		granted := strings.Fields(rt.Scope)
+		for _, sc := range strings.Fields(scope) {
+			if !containsString(granted, sc) {
+				return nil, ErrInvalidScope
+			}
+		}
+	}
+	user, err := s.loadUser(ctx, rt.UserId)
+	if err != nil {
+		return nil, err
+	}
+	// 校验通过后才取出, 并发刷新时只有一个请求能成功
+	if _, err = s.storage.TakeRefreshToken(ctx, refreshToken); errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidGrant.WithDescription("invalid refresh token")
+	} else if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, client, user, scope, true)
+}
+
+func (s *Server) issue(ctx context.Context, client *Client, user authorize.IAuthorizeOther, scope string, withRefresh bool) (*TokenResponse, error) {
+	userId := user.GetId(ctx)
+	token, err := s.auth.GenerateTokenWithClaims(ctx, user, map[string]any{
+		ClaimJwtId:              random.SecureRandn(tokenLength, ""),
+		ClaimSubject:            userId,
+		ClaimClientId:           client.Id,
+		ClaimScope:              scope,
+		authorize.ClaimTokenUse: TokenUseAccess,
+	})
+	if err != nil {
+		return nil, err
+	}
+	jwtToken, err := jwt.ParseInsecure([]byte(token))
+	if err != nil {
+		return nil, err
+	}
+	resp := &TokenResponse{
+		AccessToken: token,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   int64(time.Until(jwtToken.Expiration()).Seconds()),
+		Scope:       scope,
+	}
+	if withRefresh && s.refreshTokenExpire > 0 && client.AllowGrant(GrantRefreshToken) {
+		rt := &RefreshToken{
+			Token:    random.SecureRandn(tokenLength, ""),
+			ClientId: client.Id,
+			UserId:   userId,
+			Scope:    scope,
+			ExpireAt: time.Now().Add(s.refreshTokenExpire),
+		}
+		if err := s.storage.SaveRefreshToken(ctx, rt); err != nil {
+			return nil, err
+		}
+		resp.RefreshToken = rt.Token
+	}
+	return resp, nil
+}
+
+// VerifyAccessToken 供资源服务校验 access token, 已吊销的 token 校验失败
+func (s *Server) VerifyAccessToken(ctx context.Context, token string, user authorize.IAuthorizeOther) (jwt.Token, error) {
+	jwtToken, err := s.auth.VerifyTokenUse(ctx, token, TokenUseAccess, user)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.storage.IsAccessTokenRevoked(ctx, jwtToken.JwtID())
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidGrant.WithDescription("token revoked")
+	}
+	return jwtToken, nil
+}
+
+// IntrospectionResponse token 内省响应 (RFC 7662 2.2)
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientId  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpireAt  int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	JwtId     string `json:"jti,omitempty"`
+}
+
+// Introspect 查询 token 状态, 无效的 token 返回 active=false
+func (s *Server) Introspect(ctx context.Context, token, tokenTypeHint string) (*IntrospectionResponse, error) {
+	if tokenTypeHint != "refresh_token" {
+		if resp, ok, err := s.introspectAccessToken(ctx, token); err != nil || ok {
+			return resp, err
+		}
+	}
+	rt, err := s.storage.GetRefreshToken(ctx, token)
+	if errors.Is(err, ErrNotFound) {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &IntrospectionResponse{
+		Active:    true,
+		Scope:     rt.Scope,
+		ClientId:  rt.ClientId,
+		Subject:   rt.UserId,
+		TokenType: "refresh_token",
+		ExpireAt:  rt.ExpireAt.Unix(),
+	}, nil
+}
+
+// introspectAccessToken ok 为 false 表示不是本服务签发的 access token
+func (s *Server) introspectAccessToken(ctx context.Context, token string) (*IntrospectionResponse, bool, error) {
+	jwtToken, err := s.auth.VerifyTokenUse(ctx, token, TokenUseAccess, discardOther{})
+	if err != nil {
+		return nil, false, nil
+	}
+	// TokenUseAccess 已排除普通用户 token
+	claims := jwtToken.PrivateClaims()
+	clientId, _ := claims[ClaimClientId].(string)
+	if clientId == "" || jwtToken.JwtID() == "" {
+		return nil, false, nil
+	}
+	revoked, err := s.storage.IsAccessTokenRevoked(ctx, jwtToken.JwtID())
+	if err != nil {
+		return nil, false, err
+	}
+	if revoked {
+		return &IntrospectionResponse{Active: false}, true, nil
+	}
+	resp := &IntrospectionResponse{
+		Active:    true,
+		TokenType: TokenTypeBearer,
+		ExpireAt:  jwtToken.Expiration().Unix(),
+		IssuedAt:  jwtToken.IssuedAt().Unix(),
+		Issuer:    jwtToken.Issuer(),
+		Subject:   jwtToken.Subject(),
+		JwtId:     jwtToken.JwtID(),
+	}
+	resp.Scope, _ = claims[ClaimScope].(string)
+	resp.ClientId = clientId
+	return resp, true, nil
+}
+
+// Revoke 吊销 token (RFC 7009), 只能吊销签发给该客户端的 token, 无效的 token 不返回错误
+func (s *Server) Revoke(ctx context.Context, client *Client, token, tokenTypeHint string) error {
+	if tokenTypeHint != "refresh_token" {
+		jwtToken, err := s.auth.VerifyTokenUse(ctx, token, TokenUseAccess, discardOther{})
+		if err == nil {
+			if clientId, _ := jwtToken.PrivateClaims()[ClaimClientId].(string); clientId != client.Id {
+				return nil
+			}
+			return s.storage.RevokeAccessToken(ctx, jwtToken.JwtID(), jwtToken.Expiration())
+		}
+	}
+	rt, err := s.storage.GetRefreshToken(ctx, token)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if rt.ClientId != client.Id {
+		return nil
+	}
+	return s.storage.DeleteRefreshToken(ctx, token)
+}
+
+// discardOther 只校验签名, 不解析 token 中的用户数据
+type discardOther struct{}
+
+func (discardOther) Encrypt(context.Context, jwa.KeyEncryptionAlgorithm, jwk.Key) (string, error) {
+	return "", errors.New("oauth2: discardOther can not encrypt")
+}
+
+func (discardOther) Decrypt(context.Context, string, jwa.KeyEncryptionAlgorithm, jwk.Key) error {
+	return nil
+}
+
+func (discardOther) GetId(context.Context) string { return "" }
+
+func (discardOther) GetBan(context.Context) bool { return false }
+
+func (discardOther) SetBan(context.Context, bool) {}
@@ -0,0 +1,372 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zmicro-team/ztlib/authorize"
+)
+
+const testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func newTestServer(opts ...Option) *Server {
+	auth := authorize.NewUserAuthorize(&authorize.AuthorizeConfig{
+		Expire:                time.Hour,
+		Issuer:                "example.com",
+		KeySignatureAlgorithm: jwa.RSA_OAEP,
+		PrivateKeyPath:        "../tools/rsa-private.key",
+		PublicKeyPath:         "../tools/rsa-public.key",
+		SecretKey:             "secret",
+		SignatureAlgorithm:    jwa.HS256,
+	})
+	clients := NewMemoryClientStore(
+		&Client{
+			Id:         "partner",
+			Name:       "Partner",
+			SecretHash: HashSecret("partner-secret"),
+			GrantTypes: []GrantType{GrantClientCredentials},
+			Scopes:     []string{"order:read", "order:write"},
+		},
+		&Client{
+			Id:           "spa",
+			Public:       true,
+			RedirectURIs: []string{"https://app.example.com/callback"},
+			GrantTypes:   []GrantType{GrantAuthorizationCode, GrantRefreshToken},
+			Scopes:       []string{"profile", "order:read"},
+		},
+	)
+	return NewServer(auth, clients, NewMemoryStorage(), opts...)
+}
+
+func postForm(t *testing.T, handler http.HandlerFunc, form url.Values, basic ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if len(basic) == 2 {
+		r.SetBasicAuth(basic[0], basic[1])
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	var v T
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &v))
+	return v
+}
+
+func TestServer_ClientCredentials(t *testing.T) {
+	s := newTestServer()
+
+	w := postForm(t, s.HandleToken, url.Values{"grant_type": {"client_credentials"}, "scope": {"order:read"}}, "partner", "partner-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	resp := decode[TokenResponse](t, w)
+	assert.Equal(t, TokenTypeBearer, resp.TokenType)
+	assert.Equal(t, "order:read", resp.Scope)
+	assert.Empty(t, resp.RefreshToken)
+	assert.InDelta(t, 3600, resp.ExpiresIn, 5)
+
+	user := new(authorize.UserAuthorizeOther)
+	_, err := s.VerifyAccessToken(context.Background(), resp.AccessToken, user)
+	assert.NoError(t, err)
+	assert.Equal(t, "partner", user.Id)
+	assert.Equal(t, UserTypeClient, user.Type)
+
+	// 密钥错误
+	w = postForm(t, s.HandleToken, url.Values{"grant_type": {"client_credentials"}}, "partner", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "invalid_client", decode[Error](t, w).Code)
+
+	// scope 超出范围
+	w = postForm(t, s.HandleToken, url.Values{"grant_type": {"client_credentials"}, "scope": {"admin"}}, "partner", "partner-secret")
+	assert.Equal(t, "invalid_scope", decode[Error](t, w).Code)
+
+	// 公开客户端不能使用 client_credentials
+	w = postForm(t, s.HandleToken, url.Values{"grant_type": {"client_credentials"}, "client_id": {"spa"}})
+	assert.Equal(t, "unauthorized_client", decode[Error](t, w).Code)
+}
+
+func authorizeCode(t *testing.T, s *Server, challenge string) string {
+	r := httptest.NewRequest(http.MethodGet, "/authorize?"+url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"spa"},
+		"redirect_uri":          {"https://app.example.com/callback"},
+		"scope":                 {"profile"},
+		"state":                 {"xyz"},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {CodeChallengeS256},
+	}.Encode(), nil)
+	req, err := ParseAuthorizeRequest(r)
+	assert.NoError(t, err)
+	redirect, err := s.Authorize(context.Background(), req, "1001")
+	assert.NoError(t, err)
+	u, err := url.Parse(redirect)
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz", u.Query().Get("state"))
+	return u.Query().Get("code")
+}
+
+func TestServer_AuthorizationCodePKCE(t *testing.T) {
+	s := newTestServer()
+	code := authorizeCode(t, s, S256Challenge(testVerifier))
+	assert.NotEmpty(t, code)
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"spa"},
+		"code":          {code},
+		"redirect_uri":  {"https://app.example.com/callback"},
+		"code_verifier": {testVerifier},
+	}
+	w := postForm(t, s.HandleToken, form)
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := decode[TokenResponse](t, w)
+	assert.Equal(t, "profile", resp.Scope)
+	assert.NotEmpty(t, resp.RefreshToken)
+
+	user := new(authorize.UserAuthorizeOther)
+	_, err := s.VerifyAccessToken(context.Background(), resp.AccessToken, user)
+	assert.NoError(t, err)
+	assert.Equal(t, "1001", user.Id)
+
+	// 授权码只能使用一次
+	w = postForm(t, s.HandleToken, form)
+	assert.Equal(t, "invalid_grant", decode[Error](t, w).Code)
+
+	// code_verifier 不匹配
+	form.Set("code", authorizeCode(t, s, S256Challenge(testVerifier)))
+	form.Set("code_verifier", strings.Repeat("a", 43))
+	w = postForm(t, s.HandleToken, form)
+	assert.Equal(t, "invalid_grant", decode[Error](t, w).Code)
+
+	// 刷新令牌轮换
+	w = postForm(t, s.HandleToken, url.Values{"grant_type": {"refresh_token"}, "client_id": {"spa"}, "refresh_token": {resp.RefreshToken}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	refreshed := decode[TokenResponse](t, w)
+	assert.NotEqual(t, resp.RefreshToken, refreshed.RefreshToken)
+	w = postForm(t, s.HandleToken, url.Values{"grant_type": {"refresh_token"}, "client_id": {"spa"}, "refresh_token": {resp.RefreshToken}})
+	assert.Equal(t, "invalid_grant", decode[Error](t, w).Code)
+}
+
+func TestServer_ExchangeCodeRedirectURI(t *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+	client, err := s.clients.GetClient(ctx, "spa")
+	assert.NoError(t, err)
+
+	// 授权请求携带了 redirect_uri, 换取 token 时不能省略
+	code := authorizeCode(t, s, S256Challenge(testVerifier))
+	_, err = s.ExchangeCode(ctx, client, code, "", testVerifier)
+	assert.ErrorIs(t, err, ErrInvalidGrant)
+
+	// 授权请求未携带时可以省略
+	req := &AuthorizeRequest{ResponseType: "code", ClientId: "spa", CodeChallenge: S256Challenge(testVerifier), CodeChallengeMethod: CodeChallengeS256}
+	redirect, err := s.Authorize(ctx, req, "1001")
+	assert.NoError(t, err)
+	assert.True(t, req.RedirectURIDefault)
+	u, err := url.Parse(redirect)
+	assert.NoError(t, err)
+	_, err = s.ExchangeCode(ctx, client, u.Query().Get("code"), "", testVerifier)
+	assert.NoError(t, err)
+}
+
+func TestServer_RefreshConcurrent(t *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+	client, err := s.clients.GetClient(ctx, "spa")
+	assert.NoError(t, err)
+	resp, err := s.ExchangeCode(ctx, client, authorizeCode(t, s, S256Challenge(testVerifier)), "https://app.example.com/callback", testVerifier)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Refresh(ctx, client, resp.RefreshToken, ""); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), succeeded.Load())
+
+	// 加载用户失败时刷新令牌仍可使用
+	resp, err = s.ExchangeCode(ctx, client, authorizeCode(t, s, S256Challenge(testVerifier)), "https://app.example.com/callback", testVerifier)
+	assert.NoError(t, err)
+	s.loadUser = func(context.Context, string) (authorize.IAuthorizeOther, error) {
+		return nil, errors.New("user service unavailable")
+	}
+	_, err = s.Refresh(ctx, client, resp.RefreshToken, "")
+	assert.Error(t, err)
+	_, err = s.storage.GetRefreshToken(ctx, resp.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestServer_ValidateAuthorizeRequest(t *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+
+	// 公开客户端必须使用 PKCE
+	req := &AuthorizeRequest{ResponseType: "code", ClientId: "spa"}
+	_, err := s.ValidateAuthorizeRequest(ctx, req)
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+	assert.Equal(t, "https://app.example.com/callback", req.RedirectURI)
+
+	redirect, err := AuthorizeErrorRedirect(req, err)
+	assert.NoError(t, err)
+	assert.Contains(t, redirect, "error=invalid_request")
+
+	// 默认不允许 plain
+	req = &AuthorizeRequest{ResponseType: "code", ClientId: "spa", CodeChallenge: testVerifier}
+	_, err = s.ValidateAuthorizeRequest(ctx, req)
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+	_, err = newTestServer(WithAllowPlainPKCE()).ValidateAuthorizeRequest(ctx, req)
+	assert.NoError(t, err)
+
+	req = &AuthorizeRequest{ResponseType: "code", ClientId: "spa", RedirectURI: "https://evil.example.com"}
+	_, err = s.ValidateAuthorizeRequest(ctx, req)
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+
+	req = &AuthorizeRequest{ResponseType: "token", ClientId: "spa", CodeChallenge: S256Challenge(testVerifier), CodeChallengeMethod: CodeChallengeS256}
+	_, err = s.ValidateAuthorizeRequest(ctx, req)
+	assert.ErrorIs(t, err, ErrUnsupportedResponseType)
+}
+
+func TestServer_IntrospectRevoke(t *testing.T) {
+	s := newTestServer()
+	w := postForm(t, s.HandleToken, url.Values{"grant_type": {"client_credentials"}}, "partner", "partner-secret")
+	token := decode[TokenResponse](t, w).AccessToken
+
+	w = postForm(t, s.HandleIntrospect, url.Values{"token": {token}}, "partner", "partner-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	info := decode[IntrospectionResponse](t, w)
+	assert.True(t, info.Active)
+	assert.Equal(t, "partner", info.ClientId)
+	assert.Equal(t, "partner", info.Subject)
+	assert.Equal(t, "order:read order:write", info.Scope)
+	assert.NotEmpty(t, info.JwtId)
+
+	// 内省需要客户端认证
+	w = postForm(t, s.HandleIntrospect, url.Values{"token": {token}})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = postForm(t, s.HandleRevoke, url.Values{"token": {token}}, "partner", "partner-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postForm(t, s.HandleIntrospect, url.Values{"token": {token}}, "partner", "partner-secret")
+	assert.False(t, decode[IntrospectionResponse](t, w).Active)
+	_, err := s.VerifyAccessToken(context.Background(), token, new(authorize.UserAuthorizeOther))
+	assert.Error(t, err)
+
+	// 无效 token 也返回 200
+	w = postForm(t, s.HandleRevoke, url.Values{"token": {"invalid"}}, "partner", "partner-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postForm(t, s.HandleIntrospect, url.Values{"token": {"invalid"}}, "partner", "partner-secret")
+	assert.False(t, decode[IntrospectionResponse](t, w).Active)
+
+	// 不是本服务签发的 access token
+	plain, err := s.auth.GenerateToken(context.Background(), &authorize.UserAuthorizeOther{Id: "1001"})
+	assert.NoError(t, err)
+	w = postForm(t, s.HandleIntrospect, url.Values{"token": {plain}}, "partner", "partner-secret")
+	assert.False(t, decode[IntrospectionResponse](t, w).Active)
+
+	// 存储出错时不能当作无效 token
+	w = postForm(t, s.HandleToken, url.Values{"grant_type": {"client_credentials"}}, "partner", "partner-secret")
+	token = decode[TokenResponse](t, w).AccessToken
+	s.storage = failingStorage{s.storage}
+	_, err = s.Introspect(context.Background(), token, "")
+	assert.ErrorIs(t, err, errStorage)
+}
+
+func TestServer_TokenUse(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer()
+	w := postForm(t, s.HandleToken, url.Values{"grant_type": {"client_credentials"}}, "partner", "partner-secret")
+	clientToken := decode[TokenResponse](t, w).AccessToken
+	client, err := s.clients.GetClient(ctx, "spa")
+	assert.NoError(t, err)
+	resp, err := s.issue(ctx, client, &authorize.UserAuthorizeOther{Id: "1001"}, "profile", false)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Revoke(ctx, client, resp.AccessToken, ""))
+	_, err = s.VerifyAccessToken(ctx, resp.AccessToken, new(authorize.UserAuthorizeOther))
+	assert.Error(t, err)
+
+	// 普通用户 token 的校验 (中间件等) 不接受 access token
+	for _, token := range []string{clientToken, resp.AccessToken} {
+		user := new(authorize.UserAuthorizeOther)
+		_, err = s.auth.VerifyToken(ctx, token, user)
+		assert.ErrorIs(t, err, authorize.ErrTokenUse)
+		assert.Empty(t, user.Id)
+	}
+
+	// 反之普通用户 token 不能当作 access token
+	plain, err := s.auth.GenerateToken(ctx, &authorize.UserAuthorizeOther{Id: "1001"})
+	assert.NoError(t, err)
+	_, err = s.VerifyAccessToken(ctx, plain, new(authorize.UserAuthorizeOther))
+	assert.ErrorIs(t, err, authorize.ErrTokenUse)
+	_, err = s.auth.VerifyToken(ctx, plain, new(authorize.UserAuthorizeOther))
+	assert.NoError(t, err)
+}
+
+var errStorage = errors.New("storage unavailable")
+
+type failingStorage struct {
+	Storage
+}
+
+func (failingStorage) IsAccessTokenRevoked(context.Context, string) (bool, error) {
+	return false, errStorage
+}
+
+func TestServer_RevokeRefreshToken(t *testing.T) {
+	s := newTestServer()
+	code := authorizeCode(t, s, S256Challenge(testVerifier))
+	w := postForm(t, s.HandleToken, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"spa"},
+		"code":          {code},
+		"redirect_uri":  {"https://app.example.com/callback"},
+		"code_verifier": {testVerifier},
+	})
+	rt := decode[TokenResponse](t, w).RefreshToken
+
+	w = postForm(t, s.HandleIntrospect, url.Values{"token": {rt}, "token_type_hint": {"refresh_token"}}, "partner", "partner-secret")
+	info := decode[IntrospectionResponse](t, w)
+	assert.True(t, info.Active)
+	assert.Equal(t, "refresh_token", info.TokenType)
+
+	// 其他客户端不能吊销
+	w = postForm(t, s.HandleRevoke, url.Values{"token": {rt}}, "partner", "partner-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err := s.storage.GetRefreshToken(context.Background(), rt)
+	assert.NoError(t, err)
+
+	w = postForm(t, s.HandleRevoke, url.Values{"token": {rt}, "client_id": {"spa"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = s.storage.GetRefreshToken(context.Background(), rt)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// RFC 7636 附录 B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", S256Challenge(testVerifier))
+	assert.True(t, verifyCodeChallenge(S256Challenge(testVerifier), CodeChallengeS256, testVerifier))
+	assert.True(t, verifyCodeChallenge(testVerifier, CodeChallengePlain, testVerifier))
+	assert.False(t, verifyCodeChallenge("short", CodeChallengePlain, "short"))
+	assert.False(t, verifyCodeChallenge(testVerifier, "unknown", testVerifier))
+}
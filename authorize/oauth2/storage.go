@@ -0,0 +1,159 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound 授权码或刷新令牌不存在/已使用/已过期
+var ErrNotFound = errors.New("oauth2: not found")
+
+// AuthorizationCode 授权码
+type AuthorizationCode struct {
+	Code                string    `json:"code"`
+	ClientId            string    `json:"client_id"`
+	UserId              string    `json:"user_id"`
+	RedirectURI         string    `json:"redirect_uri"`
+	RedirectURIDefault  bool      `json:"redirect_uri_default,omitempty"`
+	Scope               string    `json:"scope"`
+	CodeChallenge       string    `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string    `json:"code_challenge_method,omitempty"`
+	ExpireAt            time.Time `json:"expire_at"`
+}
+
+// RefreshToken 刷新令牌
+type RefreshToken struct {
+	Token    string    `json:"token"`
+	ClientId string    `json:"client_id"`
+	UserId   string    `json:"user_id"`
+	Scope    string    `json:"scope"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// Storage 授权码、刷新令牌及吊销记录的存储
+type Storage interface {
+	// SaveAuthorizationCode 保存授权码
+	SaveAuthorizationCode(ctx context.Context, code *AuthorizationCode) error
+	// TakeAuthorizationCode 取出并删除授权码, 只能使用一次
+	TakeAuthorizationCode(ctx context.Context, code string) (*AuthorizationCode, error)
+	// SaveRefreshToken 保存刷新令牌
+	SaveRefreshToken(ctx context.Context, token *RefreshToken) error
+	// GetRefreshToken 获取刷新令牌
+	GetRefreshToken(ctx context.Context, token string) (*RefreshToken, error)
+	// TakeRefreshToken 取出并删除刷新令牌, 并发使用同一令牌时只有一个能取到
+	TakeRefreshToken(ctx context.Context, token string) (*RefreshToken, error)
+	// DeleteRefreshToken 删除刷新令牌
+	DeleteRefreshToken(ctx context.Context, token string) error
+	// RevokeAccessToken 吊销访问令牌, 记录保留到令牌过期
+	RevokeAccessToken(ctx context.Context, jti string, expireAt time.Time) error
+	// IsAccessTokenRevoked 访问令牌是否已吊销
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+var _ Storage = (*MemoryStorage)(nil)
+
+// MemoryStorage 内存存储, 用于单机或测试
+type MemoryStorage struct {
+	mutex         sync.Mutex
+	codes         map[string]*AuthorizationCode
+	refreshTokens map[string]*RefreshToken
+	revoked       map[string]time.Time
+}
+
+// NewMemoryStorage 创建内存存储
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		codes:         make(map[string]*AuthorizationCode),
+		refreshTokens: make(map[string]*RefreshToken),
+		revoked:       make(map[string]time.Time),
+	}
+}
+
+func (m *MemoryStorage) SaveAuthorizationCode(_ context.Context, code *AuthorizationCode) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	cp := *code
+	m.codes[code.Code] = &cp
+	return nil
+}
+
+func (m *MemoryStorage) TakeAuthorizationCode(_ context.Context, code string) (*AuthorizationCode, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	c, ok := m.codes[code]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	delete(m.codes, code)
+	if time.Now().After(c.ExpireAt) {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+func (m *MemoryStorage) SaveRefreshToken(_ context.Context, token *RefreshToken) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	cp := *token
+	m.refreshTokens[token.Token] = &cp
+	return nil
+}
+
+func (m *MemoryStorage) GetRefreshToken(_ context.Context, token string) (*RefreshToken, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	t, ok := m.refreshTokens[token]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if time.Now().After(t.ExpireAt) {
+		delete(m.refreshTokens, token)
+		return nil, ErrNotFound
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (m *MemoryStorage) TakeRefreshToken(_ context.Context, token string) (*RefreshToken, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	t, ok := m.refreshTokens[token]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	delete(m.refreshTokens, token)
+	if time.Now().After(t.ExpireAt) {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+func (m *MemoryStorage) DeleteRefreshToken(_ context.Context, token string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.refreshTokens, token)
+	return nil
+}
+
+func (m *MemoryStorage) RevokeAccessToken(_ context.Context, jti string, expireAt time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now()
+	// 顺便清理已过期的记录
+	for k, v := range m.revoked {
+		if now.After(v) {
+			delete(m.revoked, k)
+		}
+	}
+	m.revoked[jti] = expireAt
+	return nil
+}
+
+func (m *MemoryStorage) IsAccessTokenRevoked(_ context.Context, jti string) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, ok := m.revoked[jti]
+	return ok, nil
+}
@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (c Condition) eval(req *Request) (bool, string) {
+	left, ok := resolve(req, c.Left)
+	if !ok {
+		return false, fmt.Sprintf("attribute %q not found", c.Left)
+	}
+	right, ok := resolve(req, c.Right)
+	if !ok {
+		return false, fmt.Sprintf("attribute %q not found", c.Right)
+	}
+	var result bool
+	switch c.Op {
+	case OpEq:
+		result = toString(left) == toString(right)
+	case OpNe:
+		result = toString(left) != toString(right)
+	case OpIn:
+		result = contains(right, left)
+	case OpNotIn:
+		result = !contains(right, left)
+	}
+	if !result {
+		return false, fmt.Sprintf("condition %s(%v) %s %s(%v) is false", c.Left, left, c.Op, c.Right, right)
+	}
+	return true, ""
+}
+
+// resolve 读取操作数, principal. / resource. / env. 开头为属性, 支持用 . 访问嵌套的 map
+func resolve(req *Request, operand string) (any, bool) {
+	scope, path, found := strings.Cut(operand, ".")
+	if !found {
+		return operand, true
+	}
+	var root map[string]any
+	switch scope {
+	case "principal":
+		switch path {
+		case "id":
+			return req.Principal.Id, true
+		case "roles":
+			return req.Principal.Roles, true
+		}
+		root = req.Principal.Attrs
+	case "resource":
+		root = req.Resource
+	case "env":
+		root = req.Env
+	default:
+		return operand, true
+	}
+
+	var v any = root
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func toString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// contains 判断 list 是否包含 v, list 为字符串时按逗号分隔
+func contains(list, v any) bool {
+	want := toString(v)
+	switch l := list.(type) {
+	case string:
+		for _, s := range strings.Split(l, ",") {
+			if strings.TrimSpace(s) == want {
+				return true
+			}
+		}
+	case []string:
+		for _, s := range l {
+			if s == want {
+				return true
+			}
+		}
+	case []any:
+		for _, s := range l {
+			if toString(s) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,341 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Principal 已认证的主体
+type Principal struct {
+	Id    string
+	Roles []string
+	Attrs map[string]any
+}
+
+// Request 鉴权请求
+type Request struct {
+	Principal  *Principal
+	Permission string         // 如 order:write
+	Resource   map[string]any // 资源属性, 如 owner
+	Env        map[string]any // 环境属性, 如 ip
+}
+
+// Decision 鉴权结果
+type Decision struct {
+	Allowed bool
+	Reason  string   // 结果原因
+	Role    string   // 生效规则所属角色
+	Rule    int      // 生效规则在角色中的下标, 没有生效规则时为 -1
+	Cached  bool     // 是否命中缓存
+	Trace   []string // Explain 时记录的匹配过程
+}
+
+// rule 编译后的规则
+type rule struct {
+	role  string // 定义规则的角色
+	index int
+	*Rule
+}
+
+// Engine 策略引擎
+type Engine struct {
+	mutex     sync.RWMutex
+	roles     map[string][]*rule // 角色 -> 包含继承的所有规则
+	cache     map[string]*cacheEntry
+	cacheSize int
+	version   uint64 // 每次 Reload 加 1, 避免把旧策略的结果写入新缓存
+}
+
+type cacheEntry struct {
+	rules    []*rule
+	decision *Decision // 所有规则都没有条件时, 结果与属性无关, 可直接缓存
+}
+
+// Option 引擎配置
+type Option func(*Engine)
+
+// WithCacheSize 缓存的 (角色, 权限) 组合数量, 默认 1024, 0 表示不缓存
+func WithCacheSize(n int) Option {
+	return func(e *Engine) {
+		if n >= 0 {
+			e.cacheSize = n
+		}
+	}
+}
+
+// NewEngine 创建策略引擎, 校验策略并展开角色继承
+func NewEngine(p *Policy, opts ...Option) (*Engine, error) {
+	e := &Engine{cacheSize: 1024}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if err := e.Reload(p); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload 重新加载策略并清空缓存
+func (e *Engine) Reload(p *Policy) error {
+	roles, err := compile(p)
+	if err != nil {
+		return err
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.roles = roles
+	e.cache = make(map[string]*cacheEntry)
+	e.version++
+	return nil
+}
+
+func compile(p *Policy) (map[string][]*rule, error) {
+	defs := make(map[string]*Role, len(p.Roles))
+	for i := range p.Roles {
+		r := &p.Roles[i]
+		if r.Name == "" {
+			return nil, fmt.Errorf("policy: role %d has no name", i)
+		}
+		if _, ok := defs[r.Name]; ok {
+			return nil, fmt.Errorf("policy: duplicate role %q", r.Name)
+		}
+		for j := range r.Rules {
+			if err := validateRule(&r.Rules[j]); err != nil {
+				return nil, fmt.Errorf("policy: role %q rule %d: %w", r.Name, j, err)
+			}
+		}
+		defs[r.Name] = r
+	}
+
+	roles := make(map[string][]*rule, len(defs))
+	var resolve func(name string, visiting map[string]bool) ([]*rule, error)
+	resolve = func(name string, visiting map[string]bool) ([]*rule, error) {
+		if rules, ok := roles[name]; ok {
+			return rules, nil
+		}
+		def, ok := defs[name]
+		if !ok {
+			return nil, fmt.Errorf("policy: unknown role %q", name)
+		}
+		if visiting[name] {
+			return nil, fmt.Errorf("policy: role inheritance cycle at %q", name)
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+
+		var rules []*rule
+		for i := range def.Rules {
+			rules = append(rules, &rule{role: name, index: i, Rule: &def.Rules[i]})
+		}
+		for _, parent := range def.Inherits {
+			inherited, err := resolve(parent, visiting)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, inherited...)
+		}
+		roles[name] = rules
+		return rules, nil
+	}
+	for name := range defs {
+		if _, err := resolve(name, make(map[string]bool)); err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+func validateRule(r *Rule) error {
+	switch r.Effect {
+	case "":
+		r.Effect = Allow
+	case Allow, Deny:
+	default:
+		return fmt.Errorf("unknown effect %q", r.Effect)
+	}
+	if len(r.Permissions) == 0 {
+		return fmt.Errorf("no permissions")
+	}
+	for _, c := range r.Conditions {
+		switch c.Op {
+		case OpEq, OpNe, OpIn, OpNotIn:
+		default:
+			return fmt.Errorf("unknown condition op %q", c.Op)
+		}
+	}
+	return nil
+}
+
+// Check 是否允许
+func (e *Engine) Check(ctx context.Context, req *Request) bool {
+	return e.Decide(ctx, req).Allowed
+}
+
+// Decide 鉴权, deny 优先, 没有匹配的规则时拒绝
+func (e *Engine) Decide(_ context.Context, req *Request) *Decision {
+	return e.decide(req, false)
+}
+
+// Explain 鉴权并记录匹配过程, 用于排查拒绝原因, 不使用结果缓存
+func (e *Engine) Explain(_ context.Context, req *Request) *Decision {
+	return e.decide(req, true)
+}
+
+func (e *Engine) decide(req *Request, explain bool) *Decision {
+	if req.Principal == nil {
+		return &Decision{Rule: -1, Reason: "no principal"}
+	}
+	entry, cached := e.lookup(req.Principal.Roles, req.Permission)
+	if entry.decision != nil && !explain {
+		d := *entry.decision
+		d.Cached = cached
+		return &d
+	}
+
+	d := &Decision{Rule: -1, Cached: cached}
+	tracef := func(format string, args ...any) {
+		if explain {
+			d.Trace = append(d.Trace, fmt.Sprintf(format, args...))
+		}
+	}
+	if explain {
+		e.mutex.RLock()
+		for _, role := range req.Principal.Roles {
+			if _, ok := e.roles[role]; !ok {
+				tracef("role %q is not defined", role)
+			}
+		}
+		e.mutex.RUnlock()
+	}
+	if len(entry.rules) == 0 {
+		tracef("no rule of roles %v matches permission %q", req.Principal.Roles, req.Permission)
+	}
+
+	var allow *rule
+	for _, r := range entry.rules {
+		ok, reason := r.match(req)
+		if !ok {
+			tracef("%s rule %s#%d skipped: %s", r.Effect, r.role, r.index, reason)
+			continue
+		}
+		tracef("%s rule %s#%d matched", r.Effect, r.role, r.index)
+		if r.Effect == Deny {
+			d.Role, d.Rule = r.role, r.index
+			d.Reason = fmt.Sprintf("denied by rule %s#%d", r.role, r.index)
+			return d
+		}
+		if allow == nil {
+			allow = r
+		}
+	}
+	if allow == nil {
+		d.Reason = fmt.Sprintf("no rule allows %q", req.Permission)
+		return d
+	}
+	d.Allowed = true
+	d.Role, d.Rule = allow.role, allow.index
+	d.Reason = fmt.Sprintf("allowed by rule %s#%d", allow.role, allow.index)
+	return d
+}
+
+// lookup 查找角色中与权限匹配的规则
+func (e *Engine) lookup(roles []string, permission string) (*cacheEntry, bool) {
+	sorted := append([]string(nil), roles...)
+	sort.Strings(sorted)
+	key := cacheKey(sorted, permission)
+
+	e.mutex.RLock()
+	if entry, ok := e.cache[key]; ok {
+		e.mutex.RUnlock()
+		return entry, true
+	}
+	version := e.version
+	entry := &cacheEntry{}
+	seen := make(map[*Rule]bool)
+	for _, role := range sorted {
+		for _, r := range e.roles[role] {
+			if seen[r.Rule] || !r.matchPermission(permission) {
+				continue
+			}
+			seen[r.Rule] = true
+			entry.rules = append(entry.rules, r)
+		}
+	}
+	e.mutex.RUnlock()
+
+	unconditional := true
+	for _, r := range entry.rules {
+		if len(r.Conditions) > 0 {
+			unconditional = false
+			break
+		}
+	}
+	if unconditional {
+		entry.decision = e.decideUnconditional(entry.rules, permission)
+	}
+
+	if e.cacheSize > 0 {
+		e.mutex.Lock()
+		// 期间已重新加载, 结果基于旧策略, 不缓存
+		if e.version == version {
+			if len(e.cache) >= e.cacheSize {
+				e.cache = make(map[string]*cacheEntry)
+			}
+			e.cache[key] = entry
+		}
+		e.mutex.Unlock()
+	}
+	return entry, false
+}
+
+// cacheKey 角色名加上长度前缀, 角色名中含有分隔符时也不会冲突
+func cacheKey(roles []string, permission string) string {
+	var b strings.Builder
+	for _, role := range roles {
+		b.WriteString(strconv.Itoa(len(role)))
+		b.WriteByte(':')
+		b.WriteString(role)
+	}
+	b.WriteByte('|')
+	b.WriteString(permission)
+	return b.String()
+}
+
+func (e *Engine) decideUnconditional(rules []*rule, permission string) *Decision {
+	var allow *rule
+	for _, r := range rules {
+		if r.Effect == Deny {
+			return &Decision{Role: r.role, Rule: r.index, Reason: fmt.Sprintf("denied by rule %s#%d", r.role, r.index)}
+		}
+		if allow == nil {
+			allow = r
+		}
+	}
+	if allow == nil {
+		return &Decision{Rule: -1, Reason: fmt.Sprintf("no rule allows %q", permission)}
+	}
+	return &Decision{Allowed: true, Role: allow.role, Rule: allow.index, Reason: fmt.Sprintf("allowed by rule %s#%d", allow.role, allow.index)}
+}
+
+func (r *rule) matchPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if MatchPermission(p, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// match 判断条件是否全部满足, 不满足时返回原因
+func (r *rule) match(req *Request) (bool, string) {
+	for _, c := range r.Conditions {
+		if ok, reason := c.eval(req); !ok {
+			return false, reason
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,222 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicyYAML = `
+roles:
+  - name: user
+    rules:
+      - permissions: ["order:read"]
+      - permissions: ["order:write", "order:delete"]
+        conditions:
+          - { left: resource.owner, op: eq, right: principal.id }
+          - { left: resource.status, op: in, right: "draft,pending" }
+  - name: auditor
+    rules:
+      - permissions: ["order:read", "report:*"]
+  - name: admin
+    inherits: [user]
+    rules:
+      - permissions: ["order:*"]
+      - effect: deny
+        permissions: ["order:delete"]
+        conditions:
+          - { left: resource.status, op: eq, right: paid }
+`
+
+func newTestEngine(t *testing.T, opts ...Option) *Engine {
+	p, err := ParseYAML([]byte(testPolicyYAML))
+	assert.NoError(t, err)
+	e, err := NewEngine(p, opts...)
+	assert.NoError(t, err)
+	return e
+}
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		permission string
+		want       bool
+	}{
+		{"*", "order:read", true},
+		{"order:*", "order:read", true},
+		{"order:*", "order:item:read", true},
+		{"order:*", "order", false},
+		{"order:read", "order:read", true},
+		{"order:read", "order:write", false},
+		{"order:*:read", "order:item:read", true},
+		{"order:*:read", "order:item:write", false},
+		{"order:read", "order:read:all", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchPermission(tt.pattern, tt.permission), "%s %s", tt.pattern, tt.permission)
+	}
+}
+
+func TestEngine_Decide(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := context.Background()
+	user := &Principal{Id: "1001", Roles: []string{"user"}}
+	admin := &Principal{Id: "1", Roles: []string{"admin"}}
+
+	tests := []struct {
+		name     string
+		req      *Request
+		want     bool
+		wantRole string
+	}{
+		{"user read", &Request{Principal: user, Permission: "order:read"}, true, "user"},
+		{"user write own draft", &Request{Principal: user, Permission: "order:write", Resource: map[string]any{"owner": "1001", "status": "draft"}}, true, "user"},
+		{"user write own paid", &Request{Principal: user, Permission: "order:write", Resource: map[string]any{"owner": "1001", "status": "paid"}}, false, ""},
+		{"user write other", &Request{Principal: user, Permission: "order:write", Resource: map[string]any{"owner": "1002", "status": "draft"}}, false, ""},
+		{"user write numeric owner", &Request{Principal: user, Permission: "order:write", Resource: map[string]any{"owner": 1001, "status": "draft"}}, true, "user"},
+		{"user report", &Request{Principal: user, Permission: "report:read"}, false, ""},
+		{"admin write other", &Request{Principal: admin, Permission: "order:write", Resource: map[string]any{"owner": "1002"}}, true, "admin"},
+		{"admin delete paid", &Request{Principal: admin, Permission: "order:delete", Resource: map[string]any{"status": "paid"}}, false, "admin"},
+		{"admin delete draft", &Request{Principal: admin, Permission: "order:delete", Resource: map[string]any{"status": "draft"}}, true, "admin"},
+		{"multiple roles", &Request{Principal: &Principal{Id: "2", Roles: []string{"user", "auditor"}}, Permission: "report:daily"}, true, "auditor"},
+		{"unknown role", &Request{Principal: &Principal{Id: "3", Roles: []string{"guest"}}, Permission: "order:read"}, false, ""},
+		{"no principal", &Request{Permission: "order:read"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := e.Decide(ctx, tt.req)
+			assert.Equal(t, tt.want, d.Allowed, d.Reason)
+			assert.Equal(t, tt.wantRole, d.Role)
+			assert.Equal(t, tt.want, e.Check(ctx, tt.req))
+		})
+	}
+}
+
+func TestEngine_Cache(t *testing.T) {
+	e := newTestEngine(t)
+	ctx := context.Background()
+	req := &Request{Principal: &Principal{Id: "1", Roles: []string{"auditor"}}, Permission: "order:read"}
+
+	d := e.Decide(ctx, req)
+	assert.True(t, d.Allowed)
+	assert.False(t, d.Cached)
+	d = e.Decide(ctx, req)
+	assert.True(t, d.Allowed)
+	assert.True(t, d.Cached)
+
+	// 角色顺序不影响缓存
+	req.Principal.Roles = []string{"user", "auditor"}
+	e.Decide(ctx, req)
+	req.Principal.Roles = []string{"auditor", "user"}
+	assert.True(t, e.Decide(ctx, req).Cached)
+
+	// 重新加载后清空缓存
+	p, err := ParseYAML([]byte(`roles: [{name: auditor, rules: [{permissions: ["report:*"]}]}]`))
+	assert.NoError(t, err)
+	assert.NoError(t, e.Reload(p))
+	req.Principal.Roles = []string{"auditor"}
+	d = e.Decide(ctx, req)
+	assert.False(t, d.Allowed)
+	assert.False(t, d.Cached)
+
+	e = newTestEngine(t, WithCacheSize(0))
+	e.Decide(ctx, req)
+	assert.False(t, e.Decide(ctx, req).Cached)
+}
+
+func TestEngine_CacheKey(t *testing.T) {
+	p, err := ParseYAML([]byte(`roles:
+  - {name: "a,b", rules: [{permissions: ["order:read"]}]}
+  - {name: a}
+  - {name: b}
+`))
+	assert.NoError(t, err)
+	e, err := NewEngine(p)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	// 角色名含逗号时不能与拆开的角色共用缓存
+	assert.True(t, e.Check(ctx, &Request{Principal: &Principal{Roles: []string{"a,b"}}, Permission: "order:read"}))
+	d := e.Decide(ctx, &Request{Principal: &Principal{Roles: []string{"a", "b"}}, Permission: "order:read"})
+	assert.False(t, d.Allowed)
+	assert.False(t, d.Cached)
+	assert.NotEqual(t, cacheKey([]string{"a|x"}, "y"), cacheKey([]string{"a"}, "x|y"))
+}
+
+func TestEngine_CacheReload(t *testing.T) {
+	allow, err := ParseYAML([]byte(`roles: [{name: auditor, rules: [{permissions: ["order:read"]}]}]`))
+	assert.NoError(t, err)
+	deny, err := ParseYAML([]byte(`roles: [{name: auditor}]`))
+	assert.NoError(t, err)
+	e, err := NewEngine(allow)
+	assert.NoError(t, err)
+	ctx := context.Background()
+	req := &Request{Principal: &Principal{Roles: []string{"auditor"}}, Permission: "order:read"}
+
+	// 并发重新加载, 最后一次加载后的结果不能来自旧策略
+	for i := 0; i < 200; i++ {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			e.Check(ctx, req)
+		}()
+		assert.NoError(t, e.Reload(deny))
+		<-done
+		assert.False(t, e.Check(ctx, req))
+		assert.NoError(t, e.Reload(allow))
+	}
+}
+
+func TestEngine_Explain(t *testing.T) {
+	e := newTestEngine(t)
+	d := e.Explain(context.Background(), &Request{
+		Principal:  &Principal{Id: "1001", Roles: []string{"user", "guest"}},
+		Permission: "order:write",
+		Resource:   map[string]any{"owner": "1002", "status": "draft"},
+	})
+	assert.False(t, d.Allowed)
+	assert.Equal(t, -1, d.Rule)
+	if assert.Len(t, d.Trace, 2) {
+		assert.Contains(t, d.Trace[0], `role "guest" is not defined`)
+		assert.Contains(t, d.Trace[1], "user#1 skipped")
+		assert.Contains(t, d.Trace[1], "resource.owner(1002) eq principal.id(1001)")
+	}
+}
+
+func TestNewEngine_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown role":  `roles: [{name: a, inherits: [b], rules: []}]`,
+		"cycle":         `roles: [{name: a, inherits: [b]}, {name: b, inherits: [a]}]`,
+		"duplicate":     `roles: [{name: a}, {name: a}]`,
+		"unknown op":    `roles: [{name: a, rules: [{permissions: ["x"], conditions: [{left: a, op: gt, right: b}]}]}]`,
+		"unknown eff":   `roles: [{name: a, rules: [{effect: maybe, permissions: ["x"]}]}]`,
+		"no permission": `roles: [{name: a, rules: [{effect: allow}]}]`,
+	}
+	for name, y := range tests {
+		p, err := ParseYAML([]byte(y))
+		assert.NoError(t, err, name)
+		_, err = NewEngine(p)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "policy.json")
+	assert.NoError(t, os.WriteFile(jsonPath, []byte(`{"roles":[{"name":"user","rules":[{"permissions":["order:read"]}]}]}`), 0o600))
+	p, err := LoadFile(jsonPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "user", p.Roles[0].Name)
+
+	yamlPath := filepath.Join(dir, "policy.yaml")
+	assert.NoError(t, os.WriteFile(yamlPath, []byte(testPolicyYAML), 0o600))
+	p, err = LoadFile(yamlPath)
+	assert.NoError(t, err)
+	assert.Len(t, p.Roles, 3)
+
+	_, err = LoadFile(filepath.Join(dir, "policy.toml"))
+	assert.Error(t, err)
+}
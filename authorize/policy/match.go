@@ -0,0 +1,28 @@
+package policy
+
+import "strings"
+
+// MatchPermission 权限匹配, 以 : 分段
+// * 匹配一段, 位于末尾时匹配剩余所有段, 如 order:* 匹配 order:read 和 order:item:read
+func MatchPermission(pattern, permission string) bool {
+	if pattern == "*" {
+		return true
+	}
+	ps := strings.Split(pattern, ":")
+	vs := strings.Split(permission, ":")
+	for i, p := range ps {
+		if i >= len(vs) {
+			return false
+		}
+		if p == "*" {
+			if i == len(ps)-1 {
+				return true
+			}
+			continue
+		}
+		if p != vs[i] {
+			return false
+		}
+	}
+	return len(ps) == len(vs)
+}
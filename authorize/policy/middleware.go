@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ctxPrincipalKey struct{}
+
+// WithPrincipal 将主体写入 context, 一般在认证中间件中调用
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxPrincipalKey{}, p)
+}
+
+// FromPrincipal 获取 context 中的主体
+func FromPrincipal(ctx context.Context) (p *Principal, ok bool) {
+	p, ok = ctx.Value(ctxPrincipalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Authorizer is the gin authorizer middleware.
+type Authorizer struct {
+	Engine *Engine
+	// Principal 获取已认证的主体, 默认从 request context 中读取 (WithPrincipal)
+	Principal func(c *gin.Context) (*Principal, bool)
+	// Explain 拒绝时记录匹配过程, 用于调试
+	Explain bool
+	// ErrFallback 未认证 (401) 或无权限 (403) 时的处理
+	ErrFallback func(c *gin.Context, statusCode int, decision *Decision)
+}
+
+// RequirePermission returns a gin middleware which permits given permission.
+// resource 用于读取资源属性, 供条件判断使用
+func (a *Authorizer) RequirePermission(permission string, resource ...func(c *gin.Context) map[string]any) gin.HandlerFunc {
+	if a.Engine == nil {
+		panic("policy engine must be initialized.")
+	}
+	if a.Principal == nil {
+		a.Principal = func(c *gin.Context) (*Principal, bool) {
+			return FromPrincipal(c.Request.Context())
+		}
+	}
+	if a.ErrFallback == nil {
+		a.ErrFallback = func(c *gin.Context, statusCode int, decision *Decision) {
+			c.JSON(statusCode, gin.H{"error": http.StatusText(statusCode), "reason": decision.Reason})
+		}
+	}
+	return func(c *gin.Context) {
+		principal, ok := a.Principal(c)
+		if !ok {
+			c.Abort()
+			a.ErrFallback(c, http.StatusUnauthorized, &Decision{Rule: -1, Reason: "no principal"})
+			return
+		}
+		req := &Request{Principal: principal, Permission: permission}
+		for _, fn := range resource {
+			if req.Resource == nil {
+				req.Resource = make(map[string]any)
+			}
+			for k, v := range fn(c) {
+				req.Resource[k] = v
+			}
+		}
+		var decision *Decision
+		if a.Explain {
+			decision = a.Engine.Explain(c.Request.Context(), req)
+		} else {
+			decision = a.Engine.Decide(c.Request.Context(), req)
+		}
+		if !decision.Allowed {
+			c.Abort()
+			a.ErrFallback(c, http.StatusForbidden, decision)
+			return
+		}
+		c.Next()
+	}
+}
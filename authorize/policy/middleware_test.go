@@ -0,0 +1,51 @@
+package policy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizer_RequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := &Authorizer{Engine: newTestEngine(t), Explain: true}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-User"); id != "" {
+			p := &Principal{Id: id, Roles: []string{"user"}}
+			c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), p))
+		}
+	})
+	owner := func(c *gin.Context) map[string]any {
+		return map[string]any{"owner": c.Param("owner"), "status": "draft"}
+	}
+	r.POST("/orders/:owner", a.RequirePermission("order:write", owner), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		name string
+		user string
+		path string
+		want int
+	}{
+		{"unauthenticated", "", "/orders/1001", http.StatusUnauthorized},
+		{"owner", "1001", "/orders/1001", http.StatusOK},
+		{"other", "1002", "/orders/1001", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.user != "" {
+				req.Header.Set("X-User", tt.user)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
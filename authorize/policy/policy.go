@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Effect 规则效果
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Policy 策略定义, 可从 yaml/json 加载
+//
+//	roles:
+//	  - name: user
+//	    rules:
+//	      - permissions: ["order:read", "order:write"]
+//	        conditions:
+//	          - { left: resource.owner, op: eq, right: principal.id }
+//	  - name: admin
+//	    inherits: [user]
+//	    rules:
+//	      - permissions: ["order:*"]
+type Policy struct {
+	Roles []Role `json:"roles" yaml:"roles"`
+}
+
+// Role 角色, 可继承其他角色的规则
+type Role struct {
+	Name     string   `json:"name" yaml:"name"`
+	Inherits []string `json:"inherits,omitempty" yaml:"inherits,omitempty"`
+	Rules    []Rule   `json:"rules" yaml:"rules"`
+}
+
+// Rule 规则, 权限匹配且所有条件满足时生效
+type Rule struct {
+	Effect      Effect      `json:"effect,omitempty" yaml:"effect,omitempty"` // 默认 allow
+	Permissions []string    `json:"permissions" yaml:"permissions"`           // 如 order:read, order:*, *
+	Conditions  []Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+}
+
+// Condition 属性条件
+// 操作数以 principal. / resource. / env. 开头时读取对应的属性, 否则为字面量
+type Condition struct {
+	Left  string `json:"left" yaml:"left"`
+	Op    Op     `json:"op" yaml:"op"`
+	Right string `json:"right" yaml:"right"`
+}
+
+// Op 条件操作符
+type Op string
+
+const (
+	OpEq    Op = "eq"     // 相等
+	OpNe    Op = "ne"     // 不相等
+	OpIn    Op = "in"     // 左值在右值中, 右值为列表属性或逗号分隔的字面量
+	OpNotIn Op = "not_in" // 左值不在右值中
+)
+
+// ParseJSON 解析 json 策略
+func ParseJSON(b []byte) (*Policy, error) {
+	p := new(Policy)
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ParseYAML 解析 yaml 策略
+func ParseYAML(b []byte) (*Policy, error) {
+	p := new(Policy)
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// LoadFile 根据扩展名加载 yaml/json 策略文件
+func LoadFile(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		return ParseYAML(b)
+	case ".json":
+		return ParseJSON(b)
+	default:
+		return nil, fmt.Errorf("policy: unsupported file extension %q", ext)
+	}
+}
@@ -0,0 +1,132 @@
+package authorize
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+var _ IAuthorizeOther = (*Principal[struct{}])(nil)
+
+// Principal 泛型的 token 数据, Data 为任意可 json 序列化的类型
+// 默认使用 JWE 加密, WithPlainClaims 时只签名不加密
+type Principal[T any] struct {
+	Id   string `json:"id"`
+	Ban  bool   `json:"ban,omitempty"`
+	Data T      `json:"data"`
+
+	plain bool // 明文 claims, 不加密
+}
+
+// PrincipalOption Principal 配置
+type PrincipalOption func(*principalOptions)
+
+type principalOptions struct {
+	plain bool
+}
+
+// WithPlainClaims 只签名不加密, token 中的数据对持有者可见
+func WithPlainClaims() PrincipalOption {
+	return func(o *principalOptions) {
+		o.plain = true
+	}
+}
+
+// NewPrincipal 创建 Principal
+func NewPrincipal[T any](id string, data T, opts ...PrincipalOption) *Principal[T] {
+	o := &principalOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Principal[T]{Id: id, Data: data, plain: o.plain}
+}
+
+func (p *Principal[T]) Encrypt(ctx context.Context, algorithm jwa.KeyEncryptionAlgorithm, jwkRSAPublicKey jwk.Key) (string, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	if p.plain {
+		return string(payload), nil
+	}
+	encrypted, err := jwe.Encrypt(payload, jwe.WithKey(algorithm, jwkRSAPublicKey))
+	if err != nil {
+		return "", err
+	}
+	return string(encrypted), nil
+}
+
+// Decrypt 解密 token 中的数据, 根据内容自动识别是否为明文 claims
+func (p *Principal[T]) Decrypt(ctx context.Context, encrypted string, algorithm jwa.KeyEncryptionAlgorithm, jwkRSAPrivateKey jwk.Key) error {
+	if strings.HasPrefix(encrypted, "{") {
+		p.plain = true
+		return json.Unmarshal([]byte(encrypted), p)
+	}
+	decrypted, err := jwe.Decrypt([]byte(encrypted), jwe.WithKey(algorithm, jwkRSAPrivateKey))
+	if err != nil {
+		return err
+	}
+	p.plain = false
+	return json.Unmarshal(decrypted, p)
+}
+
+func (p *Principal[T]) GetId(context.Context) string {
+	return p.Id
+}
+
+func (p *Principal[T]) GetBan(context.Context) bool {
+	return p.Ban
+}
+
+func (p *Principal[T]) SetBan(_ context.Context, b bool) {
+	p.Ban = b
+}
+
+// Int64Id 将 Id 解析为 int64
+func (p *Principal[T]) Int64Id() (int64, error) {
+	return strconv.ParseInt(p.Id, 10, 64)
+}
+
+type principalKey[T any] struct{}
+
+// NewContext 将 Principal 写入 context
+func NewContext[T any](ctx context.Context, p *Principal[T]) context.Context {
+	return context.WithValue(ctx, principalKey[T]{}, p)
+}
+
+// PrincipalFromContext 获取 context 中的 Principal
+func PrincipalFromContext[T any](ctx context.Context) (*Principal[T], bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	p, ok := ctx.Value(principalKey[T]{}).(*Principal[T])
+	return p, ok && p != nil
+}
+
+// FromContext 获取 context 中 Principal 的数据
+func FromContext[T any](ctx context.Context) (T, bool) {
+	p, ok := PrincipalFromContext[T](ctx)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return p.Data, true
+}
+
+// LookupUserIdFromContext 获取 context 中 UserAuthorizeOther 的数字 id, 不会 panic
+func LookupUserIdFromContext(ctx context.Context) (int64, bool) {
+	ua := UserAuthorizeFromContext(ctx)
+	if ua == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(ua.Id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
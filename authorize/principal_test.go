@@ -0,0 +1,107 @@
+package authorize
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+type testProfile struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+func TestPrincipal_Encrypted(t *testing.T) {
+	cfg := testDefaultConfig
+	cfg.Expire = time.Hour
+	userAuthorize := NewUserAuthorize(&cfg)
+
+	p := NewPrincipal("1001", testProfile{Name: "John Doe", Roles: []string{"admin"}})
+	token, err := userAuthorize.GenerateToken(context.Background(), p)
+	assert.NoError(t, err)
+	assert.NotContains(t, token, "John")
+
+	got := new(Principal[testProfile])
+	_, err = userAuthorize.VerifyToken(context.Background(), token, got)
+	assert.NoError(t, err)
+	assert.Equal(t, "1001", got.Id)
+	assert.Equal(t, p.Data, got.Data)
+	assert.False(t, got.plain)
+}
+
+func TestPrincipal_PlainClaims(t *testing.T) {
+	cfg := testDefaultConfig
+	cfg.Expire = time.Hour
+	userAuthorize := NewUserAuthorize(&cfg)
+
+	p := NewPrincipal("1001", testProfile{Name: "John Doe"}, WithPlainClaims())
+	token, err := userAuthorize.GenerateToken(context.Background(), p)
+	assert.NoError(t, err)
+
+	jwtToken, err := jwt.ParseInsecure([]byte(token))
+	assert.NoError(t, err)
+	claim, _ := jwtToken.PrivateClaims()[UserAuthorizeInfo].(string)
+	assert.True(t, strings.Contains(claim, "John Doe"))
+
+	got := new(Principal[testProfile])
+	_, err = userAuthorize.VerifyToken(context.Background(), token, got)
+	assert.NoError(t, err)
+	assert.Equal(t, p.Data, got.Data)
+	assert.True(t, got.plain)
+}
+
+func TestPrincipal_Ban(t *testing.T) {
+	cfg := testDefaultConfig
+	cfg.Expire = time.Hour
+	userAuthorize := NewUserAuthorize(&cfg)
+	userAuthorize.SetBanAccount(func(ctx context.Context, user IAuthorizeOther) bool {
+		return user.GetId(ctx) == "1001"
+	})
+
+	token, err := userAuthorize.GenerateToken(context.Background(), NewPrincipal("1001", 0))
+	assert.NoError(t, err)
+	got := new(Principal[int])
+	_, err = userAuthorize.VerifyToken(context.Background(), token, got)
+	assert.NoError(t, err)
+	assert.True(t, got.GetBan(context.Background()))
+}
+
+func TestPrincipal_Context(t *testing.T) {
+	ctx := context.Background()
+	_, ok := FromContext[testProfile](ctx)
+	assert.False(t, ok)
+
+	p := NewPrincipal("1001", testProfile{Name: "John Doe"})
+	ctx = NewContext(ctx, p)
+	data, ok := FromContext[testProfile](ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "John Doe", data.Name)
+
+	// 不同类型互不影响
+	_, ok = FromContext[string](ctx)
+	assert.False(t, ok)
+
+	got, ok := PrincipalFromContext[testProfile](ctx)
+	assert.True(t, ok)
+	id, err := got.Int64Id()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1001), id)
+}
+
+func TestLookupUserIdFromContext(t *testing.T) {
+	_, ok := LookupUserIdFromContext(context.Background())
+	assert.False(t, ok)
+
+	ua := &UserAuthorizeOther{Id: "abc"}
+	_, ok = LookupUserIdFromContext(ua.WithContextValue(context.Background()))
+	assert.False(t, ok)
+
+	ua = &UserAuthorizeOther{Id: "123"}
+	id, ok := LookupUserIdFromContext(ua.WithContextValue(context.Background()))
+	assert.True(t, ok)
+	assert.Equal(t, int64(123), id)
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore 内存会话存储, 用于单机或测试
+type MemoryStore struct {
+	mutex    sync.RWMutex
+	sessions map[string]map[string]*Session // userId -> sessionId -> session
+}
+
+// NewMemoryStore 创建内存会话存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]map[string]*Session)}
+}
+
+func (m *MemoryStore) Save(_ context.Context, s *Session, limit Limit) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	sessions, ok := m.sessions[s.UserId]
+	if !ok {
+		sessions = make(map[string]*Session)
+		m.sessions[s.UserId] = sessions
+	}
+
+	now := time.Now()
+	var remove []string
+	active := make([]*Session, 0, len(sessions))
+	for id, old := range sessions {
+		switch {
+		case id == s.Id:
+		case old.Expired(now):
+			remove = append(remove, id)
+		case limit.ReplaceDevice && s.Device.DeviceId != "" && old.Device.DeviceId == s.Device.DeviceId:
+			remove = append(remove, id)
+		default:
+			active = append(active, old)
+		}
+	}
+	if limit.MaxSessions > 0 && len(active) >= limit.MaxSessions {
+		if limit.RejectWhenFull {
+			return ErrTooManySessions
+		}
+		// 踢掉最早创建的
+		SortSessions(active)
+		for _, old := range active[:len(active)-limit.MaxSessions+1] {
+			remove = append(remove, old.Id)
+		}
+	}
+	for _, id := range remove {
+		delete(sessions, id)
+	}
+	cp := *s
+	sessions[s.Id] = &cp
+	return nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, userId, sessionId string) (*Session, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	s, ok := m.sessions[userId][sessionId]
+	if !ok || s.Expired(time.Now()) {
+		return nil, ErrSessionNotFound
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (m *MemoryStore) List(_ context.Context, userId string) ([]*Session, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now()
+	sessions := m.sessions[userId]
+	list := make([]*Session, 0, len(sessions))
+	for id, s := range sessions {
+		if s.Expired(now) {
+			delete(sessions, id)
+			continue
+		}
+		cp := *s
+		list = append(list, &cp)
+	}
+	if len(sessions) == 0 {
+		delete(m.sessions, userId)
+	}
+	SortSessions(list)
+	return list, nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, userId string, sessionIds ...string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(sessionIds) == 0 {
+		delete(m.sessions, userId)
+		return nil
+	}
+	sessions := m.sessions[userId]
+	for _, id := range sessionIds {
+		delete(sessions, id)
+	}
+	if len(sessions) == 0 {
+		delete(m.sessions, userId)
+	}
+	return nil
+}
+
+// SortSessions 按创建时间升序排序
+func SortSessions(list []*Session) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].Id < list[j].Id
+		}
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+}
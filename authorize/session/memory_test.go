@@ -0,0 +1,12 @@
+package session_test
+
+import (
+	"testing"
+
+	"github.com/zmicro-team/ztlib/authorize/session"
+	"github.com/zmicro-team/ztlib/authorize/session/tests"
+)
+
+func TestMemoryStore(t *testing.T) {
+	tests.TestStore(t, session.NewMemoryStore())
+}
@@ -0,0 +1,27 @@
+package session
+
+// Option 会话管理配置
+type Option func(*Manager)
+
+// WithMaxSessions 每个用户最多的活跃会话数, 0 表示不限制
+func WithMaxSessions(n int) Option {
+	return func(m *Manager) {
+		if n >= 0 {
+			m.maxSessions = n
+		}
+	}
+}
+
+// WithRejectWhenFull 超过最大会话数时拒绝登录, 默认踢掉最早登录的会话
+func WithRejectWhenFull() Option {
+	return func(m *Manager) {
+		m.rejectWhenFull = true
+	}
+}
+
+// WithReplaceSameDevice 同一设备 (DeviceId 相同) 重新登录时终止旧会话, 默认开启
+func WithReplaceSameDevice(b bool) Option {
+	return func(m *Manager) {
+		m.replaceSameDevice = b
+	}
+}
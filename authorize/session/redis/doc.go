@@ -0,0 +1,11 @@
+/*
+session redis 存储:
+
+  > redis 存储格式:
+  > `keyPrefix{userId}` -----> hash { sessionId -- session json }
+  >   key 的过期时间为最晚过期的会话, 已过期的会话在 List 与 Save 时清理
+  >   session json 附带 created_ms、expire_ms 毫秒时间戳, 供 save.lua 判断过期并踢掉最早创建的会话
+
+*/
+
+package redis
@@ -0,0 +1,60 @@
+local key = KEYS[1]                 -- key
+local field = ARGV[1]               -- 会话 id
+local value = ARGV[2]               -- 会话数据
+local expires = tonumber(ARGV[3])   -- 会话剩余有效期(毫秒), 0 表示不过期
+local now = tonumber(ARGV[4])       -- 当前时间(毫秒)
+local max = tonumber(ARGV[5])       -- 最多的未过期会话数, 0 表示不限制
+local reject = ARGV[6] == "1"       -- 已满时拒绝, 否则删除最早创建的会话
+local device = ARGV[7]              -- 不为空时删除同一设备的其他会话
+
+if max > 0 or device ~= "" then
+    local all = redis.call("HGETALL", key)
+    local active = {}
+    local remove = {}
+    for i = 1, #all, 2 do
+        local id = all[i]
+        if id ~= field then
+            local ok, s = pcall(cjson.decode, all[i + 1])
+            if not ok or type(s) ~= "table" then
+                table.insert(remove, id)
+            elseif (tonumber(s.expire_ms) or 0) > 0 and tonumber(s.expire_ms) <= now then
+                table.insert(remove, id)
+            elseif device ~= "" and type(s.device) == "table" and s.device.device_id == device then
+                table.insert(remove, id)
+            else
+                table.insert(active, { id = id, created = tonumber(s.created_ms) or 0 })
+            end
+        end
+    end
+    if max > 0 and #active >= max then
+        if reject then
+            return 1
+        end
+        table.sort(active, function(a, b)
+            if a.created == b.created then
+                return a.id < b.id
+            end
+            return a.created < b.created
+        end)
+        for i = 1, #active - max + 1 do
+            table.insert(remove, active[i].id)
+        end
+    end
+    if #remove > 0 then
+        redis.call("HDEL", key, unpack(remove))
+    end
+end
+
+redis.call("HSET", key, field, value)
+local ttl = redis.call("PTTL", key)
+if expires > 0 then
+    -- 只延长 key 的过期时间, 不缩短
+    if ttl >= 0 and ttl < expires then
+        redis.call("PEXPIRE", key, expires)
+    elseif ttl == -1 and redis.call("HLEN", key) == 1 then
+        redis.call("PEXPIRE", key, expires)
+    end
+elseif ttl >= 0 then
+    redis.call("PERSIST", key)
+end
+return 0
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/zmicro-team/ztlib/authorize/session"
+)
+
+//go:embed save.lua
+var SaveScript string
+
+// SaveRejected SaveScript 已满拒绝保存时的返回值
+const SaveRejected = 1
+
+// record 保存到 redis 的会话, 附带毫秒时间戳供 save.lua 判断过期与排序
+type record struct {
+	*session.Session
+	CreatedMs int64 `json:"created_ms"`
+	ExpireMs  int64 `json:"expire_ms,omitempty"`
+}
+
+// SaveArgs SaveScript 的参数, expires 为会话剩余有效期(毫秒), 0 表示不过期
+func SaveArgs(s *session.Session, expires int64, limit session.Limit) ([]any, error) {
+	r := record{Session: s, CreatedMs: s.CreatedAt.UnixMilli()}
+	if !s.ExpireAt.IsZero() {
+		r.ExpireMs = s.ExpireAt.UnixMilli()
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	reject := "0"
+	if limit.RejectWhenFull {
+		reject = "1"
+	}
+	var device string
+	if limit.ReplaceDevice {
+		device = s.Device.DeviceId
+	}
+	return []any{
+		s.Id, b, strconv.FormatInt(expires, 10),
+		strconv.FormatInt(time.Now().UnixMilli(), 10),
+		strconv.Itoa(limit.MaxSessions), reject, device,
+	}, nil
+}
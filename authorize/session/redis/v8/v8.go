@@ -0,0 +1,109 @@
+package v8
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/zmicro-team/ztlib/authorize/session"
+	redisScript "github.com/zmicro-team/ztlib/authorize/session/redis"
+)
+
+var _ session.Store = (*RedisStore)(nil)
+
+const defaultKeyPrefix = "authorize:session:"
+
+// RedisStore redis 会话存储, 每个用户一个 hash, field 为会话 id
+type RedisStore struct {
+	store     *redis.Client
+	keyPrefix string
+}
+
+// NewRedisStore new redis store instance.
+func NewRedisStore(store *redis.Client, keyPrefix ...string) *RedisStore {
+	prefix := defaultKeyPrefix
+	if len(keyPrefix) > 0 && keyPrefix[0] != "" {
+		prefix = keyPrefix[0]
+	}
+	return &RedisStore{store: store, keyPrefix: prefix}
+}
+
+func (r *RedisStore) key(userId string) string {
+	return r.keyPrefix + userId
+}
+
+// Save 保存会话并在脚本中按 limit 删除其他会话, key 的过期时间延长到最晚过期的会话
+func (r *RedisStore) Save(ctx context.Context, s *session.Session, limit session.Limit) error {
+	var expires int64
+	if !s.ExpireAt.IsZero() {
+		expires = time.Until(s.ExpireAt).Milliseconds()
+		if expires <= 0 {
+			return r.store.HDel(ctx, r.key(s.UserId), s.Id).Err()
+		}
+	}
+	args, err := redisScript.SaveArgs(s, expires, limit)
+	if err != nil {
+		return err
+	}
+	n, err := r.store.Eval(ctx, redisScript.SaveScript, []string{r.key(s.UserId)}, args...).Int()
+	if err != nil {
+		return err
+	}
+	if n == redisScript.SaveRejected {
+		return session.ErrTooManySessions
+	}
+	return nil
+}
+
+func (r *RedisStore) Get(ctx context.Context, userId, sessionId string) (*session.Session, error) {
+	b, err := r.store.HGet(ctx, r.key(userId), sessionId).Bytes()
+	if err == redis.Nil {
+		return nil, session.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := new(session.Session)
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if s.Expired(time.Now()) {
+		return nil, session.ErrSessionNotFound
+	}
+	return s, nil
+}
+
+// List 获取未过期的会话, 同时清理已过期的会话
+func (r *RedisStore) List(ctx context.Context, userId string) ([]*session.Session, error) {
+	values, err := r.store.HGetAll(ctx, r.key(userId)).Result()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	list := make([]*session.Session, 0, len(values))
+	var expired []string
+	for id, v := range values {
+		s := new(session.Session)
+		if err := json.Unmarshal([]byte(v), s); err != nil || s.Expired(now) {
+			expired = append(expired, id)
+			continue
+		}
+		list = append(list, s)
+	}
+	if len(expired) > 0 {
+		if err := r.store.HDel(ctx, r.key(userId), expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	session.SortSessions(list)
+	return list, nil
+}
+
+func (r *RedisStore) Delete(ctx context.Context, userId string, sessionIds ...string) error {
+	if len(sessionIds) == 0 {
+		return r.store.Del(ctx, r.key(userId)).Err()
+	}
+	return r.store.HDel(ctx, r.key(userId), sessionIds...).Err()
+}
@@ -0,0 +1,127 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+
+	"github.com/zmicro-team/ztlib/authorize"
+	"github.com/zmicro-team/ztlib/random"
+)
+
+// ClaimSessionId token 中会话 id 的 claim
+const ClaimSessionId = "sid"
+
+var (
+	// ErrTooManySessions 超过最大会话数
+	ErrTooManySessions = errors.New("session: too many active sessions")
+	// ErrSessionTerminated token 对应的会话已终止
+	ErrSessionTerminated = errors.New("session: session terminated")
+)
+
+// Manager 基于 UserAuthorize 的会话管理
+type Manager struct {
+	auth              *authorize.UserAuthorize
+	store             Store
+	maxSessions       int  // 每个用户最多的活跃会话数, 0 表示不限制
+	rejectWhenFull    bool // 超过最大会话数时拒绝登录
+	replaceSameDevice bool // 同一设备重新登录时终止旧会话
+}
+
+// NewManager 创建会话管理
+func NewManager(auth *authorize.UserAuthorize, store Store, opts ...Option) *Manager {
+	m := &Manager{
+		auth:              auth,
+		store:             store,
+		replaceSameDevice: true,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Login 创建会话并签发绑定会话 id 的 token
+func (m *Manager) Login(ctx context.Context, user authorize.IAuthorizeOther, device Device) (string, *Session, error) {
+	userId := user.GetId(ctx)
+	s := &Session{
+		Id:        random.RandId(),
+		UserId:    userId,
+		Device:    device,
+		CreatedAt: time.Now(),
+	}
+	token, err := m.auth.GenerateTokenWithClaims(ctx, user, map[string]any{ClaimSessionId: s.Id})
+	if err != nil {
+		return "", nil, err
+	}
+	jwtToken, err := jwt.ParseInsecure([]byte(token))
+	if err != nil {
+		return "", nil, err
+	}
+	s.ExpireAt = jwtToken.Expiration()
+
+	// 由存储在保存时原子地踢掉同设备或最早登录的会话
+	if err := m.store.Save(ctx, s, Limit{
+		MaxSessions:    m.maxSessions,
+		RejectWhenFull: m.rejectWhenFull,
+		ReplaceDevice:  m.replaceSameDevice,
+	}); err != nil {
+		return "", nil, err
+	}
+	return token, s, nil
+}
+
+// Verify 校验 token 及其会话是否仍然有效
+func (m *Manager) Verify(ctx context.Context, token string, user authorize.IAuthorizeOther) (jwt.Token, *Session, error) {
+	jwtToken, err := m.auth.VerifyToken(ctx, token, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	sessionId, ok := jwtToken.PrivateClaims()[ClaimSessionId].(string)
+	if !ok || sessionId == "" {
+		return nil, nil, ErrSessionTerminated
+	}
+	s, err := m.store.Get(ctx, user.GetId(ctx), sessionId)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, nil, ErrSessionTerminated
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return jwtToken, s, nil
+}
+
+// List 获取用户的活跃会话 (已登录设备), 按登录时间升序
+func (m *Manager) List(ctx context.Context, userId string) ([]*Session, error) {
+	return m.store.List(ctx, userId)
+}
+
+// Terminate 终止指定会话
+func (m *Manager) Terminate(ctx context.Context, userId string, sessionIds ...string) error {
+	if len(sessionIds) == 0 {
+		return nil
+	}
+	return m.store.Delete(ctx, userId, sessionIds...)
+}
+
+// TerminateAll 终止用户所有会话
+func (m *Manager) TerminateAll(ctx context.Context, userId string) error {
+	return m.store.Delete(ctx, userId)
+}
+
+// TerminateOthers 终止除 keepSessionId 以外的会话
+func (m *Manager) TerminateOthers(ctx context.Context, userId, keepSessionId string) error {
+	sessions, err := m.store.List(ctx, userId)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, s := range sessions {
+		if s.Id != keepSessionId {
+			ids = append(ids, s.Id)
+		}
+	}
+	return m.Terminate(ctx, userId, ids...)
+}
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zmicro-team/ztlib/authorize"
+)
+
+func newTestAuthorize() *authorize.UserAuthorize {
+	return authorize.NewUserAuthorize(&authorize.AuthorizeConfig{
+		Expire:                time.Hour,
+		Issuer:                "example.com",
+		KeySignatureAlgorithm: jwa.RSA_OAEP,
+		PrivateKeyPath:        "../tools/rsa-private.key",
+		PublicKeyPath:         "../tools/rsa-public.key",
+		SecretKey:             "secret",
+		SignatureAlgorithm:    jwa.HS256,
+	})
+}
+
+func TestManager_LoginVerify(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newTestAuthorize(), NewMemoryStore())
+	user := &authorize.UserAuthorizeOther{Id: "1001", Name: "John Doe"}
+
+	token, s, err := m.Login(ctx, user, Device{DeviceId: "d1", UserAgent: "ua", IP: "127.0.0.1"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, s.Id)
+	assert.False(t, s.ExpireAt.IsZero())
+
+	got := new(authorize.UserAuthorizeOther)
+	jwtToken, vs, err := m.Verify(ctx, token, got)
+	assert.NoError(t, err)
+	assert.NotNil(t, jwtToken)
+	assert.Equal(t, s.Id, vs.Id)
+	assert.Equal(t, "127.0.0.1", vs.Device.IP)
+	assert.Equal(t, "1001", got.Id)
+
+	assert.NoError(t, m.Terminate(ctx, "1001", s.Id))
+	_, _, err = m.Verify(ctx, token, new(authorize.UserAuthorizeOther))
+	assert.ErrorIs(t, err, ErrSessionTerminated)
+}
+
+func TestManager_TokenWithoutSession(t *testing.T) {
+	auth := newTestAuthorize()
+	m := NewManager(auth, NewMemoryStore())
+	token, err := auth.GenerateToken(context.Background(), &authorize.UserAuthorizeOther{Id: "1001"})
+	assert.NoError(t, err)
+	_, _, err = m.Verify(context.Background(), token, new(authorize.UserAuthorizeOther))
+	assert.ErrorIs(t, err, ErrSessionTerminated)
+}
+
+func TestManager_MaxSessions(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newTestAuthorize(), NewMemoryStore(), WithMaxSessions(2))
+	user := &authorize.UserAuthorizeOther{Id: "1001"}
+
+	var tokens []string
+	for _, d := range []string{"d1", "d2", "d3"} {
+		token, _, err := m.Login(ctx, user, Device{DeviceId: d})
+		assert.NoError(t, err)
+		tokens = append(tokens, token)
+		time.Sleep(time.Millisecond)
+	}
+
+	list, err := m.List(ctx, "1001")
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, "d2", list[0].Device.DeviceId)
+		assert.Equal(t, "d3", list[1].Device.DeviceId)
+	}
+	// 最早登录的设备被踢下线
+	_, _, err = m.Verify(ctx, tokens[0], new(authorize.UserAuthorizeOther))
+	assert.ErrorIs(t, err, ErrSessionTerminated)
+	_, _, err = m.Verify(ctx, tokens[2], new(authorize.UserAuthorizeOther))
+	assert.NoError(t, err)
+}
+
+func TestManager_RejectWhenFull(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newTestAuthorize(), NewMemoryStore(), WithMaxSessions(1), WithRejectWhenFull())
+	user := &authorize.UserAuthorizeOther{Id: "1001"}
+
+	_, _, err := m.Login(ctx, user, Device{DeviceId: "d1"})
+	assert.NoError(t, err)
+	_, _, err = m.Login(ctx, user, Device{DeviceId: "d2"})
+	assert.ErrorIs(t, err, ErrTooManySessions)
+	// 同一设备重新登录替换旧会话
+	_, _, err = m.Login(ctx, user, Device{DeviceId: "d1"})
+	assert.NoError(t, err)
+}
+
+func TestManager_TerminateOthers(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newTestAuthorize(), NewMemoryStore())
+	user := &authorize.UserAuthorizeOther{Id: "1001"}
+
+	_, keep, err := m.Login(ctx, user, Device{DeviceId: "d1"})
+	assert.NoError(t, err)
+	_, _, err = m.Login(ctx, user, Device{DeviceId: "d2"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.TerminateOthers(ctx, "1001", keep.Id))
+	list, err := m.List(ctx, "1001")
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, keep.Id, list[0].Id)
+	}
+
+	assert.NoError(t, m.TerminateAll(ctx, "1001"))
+	list, err = m.List(ctx, "1001")
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,53 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSessionNotFound 会话不存在或已被终止
+var ErrSessionNotFound = errors.New("session: session not found")
+
+// Device 登录设备信息
+type Device struct {
+	DeviceId  string `json:"device_id,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	IP        string `json:"ip,omitempty"`
+}
+
+// Session 会话, 每个 token 对应一个会话
+type Session struct {
+	Id        string    `json:"id"`
+	UserId    string    `json:"user_id"`
+	Device    Device    `json:"device"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpireAt  time.Time `json:"expire_at"`
+}
+
+// Expired 会话是否已过期
+func (s *Session) Expired(now time.Time) bool {
+	return !s.ExpireAt.IsZero() && !now.Before(s.ExpireAt)
+}
+
+// Limit 保存会话时的数量限制, 由存储在保存的同时原子地执行, 并发登录也不会超出
+type Limit struct {
+	// MaxSessions 每个用户最多的未过期会话数, 0 表示不限制
+	MaxSessions int
+	// RejectWhenFull 已满时返回 ErrTooManySessions 且不做任何修改, 否则删除最早创建的会话
+	RejectWhenFull bool
+	// ReplaceDevice 删除同一设备 (DeviceId 相同) 的其他会话, 这些会话不计入数量
+	ReplaceDevice bool
+}
+
+// Store 会话存储
+type Store interface {
+	// Save 保存会话, 并按 limit 删除同设备或最早创建的会话
+	Save(ctx context.Context, s *Session, limit Limit) error
+	// Get 获取会话, 不存在或已过期返回 ErrSessionNotFound
+	Get(ctx context.Context, userId, sessionId string) (*Session, error)
+	// List 获取用户未过期的会话, 按创建时间升序
+	List(ctx context.Context, userId string) ([]*Session, error)
+	// Delete 删除会话, sessionIds 为空时删除用户所有会话
+	Delete(ctx context.Context, userId string, sessionIds ...string) error
+}
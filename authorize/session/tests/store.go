@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zmicro-team/ztlib/authorize/session"
+)
+
+// TestStore 会话存储的通用测试
+func TestStore(t *testing.T, store session.Store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	s1 := &session.Session{Id: "s1", UserId: "u1", Device: session.Device{DeviceId: "d1"}, CreatedAt: now, ExpireAt: now.Add(time.Hour)}
+	s2 := &session.Session{Id: "s2", UserId: "u1", Device: session.Device{DeviceId: "d2", IP: "127.0.0.1"}, CreatedAt: now.Add(time.Second), ExpireAt: now.Add(time.Hour)}
+	s3 := &session.Session{Id: "s3", UserId: "u1", CreatedAt: now.Add(-2 * time.Hour), ExpireAt: now.Add(-time.Hour)}
+	for _, s := range []*session.Session{s2, s1, s3} {
+		assert.NoError(t, store.Save(ctx, s, session.Limit{}))
+	}
+
+	got, err := store.Get(ctx, "u1", "s2")
+	assert.NoError(t, err)
+	assert.Equal(t, s2.Device, got.Device)
+	assert.True(t, s2.CreatedAt.Equal(got.CreatedAt))
+
+	// 已过期
+	_, err = store.Get(ctx, "u1", "s3")
+	assert.ErrorIs(t, err, session.ErrSessionNotFound)
+	_, err = store.Get(ctx, "u2", "s1")
+	assert.ErrorIs(t, err, session.ErrSessionNotFound)
+
+	list, err := store.List(ctx, "u1")
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, "s1", list[0].Id)
+		assert.Equal(t, "s2", list[1].Id)
+	}
+
+	assert.NoError(t, store.Delete(ctx, "u1", "s1"))
+	_, err = store.Get(ctx, "u1", "s1")
+	assert.ErrorIs(t, err, session.ErrSessionNotFound)
+
+	assert.NoError(t, store.Delete(ctx, "u1"))
+	list, err = store.List(ctx, "u1")
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+
+	testLimit(t, store)
+}
+
+func testLimit(t *testing.T, store session.Store) {
+	ctx := context.Background()
+	now := time.Now()
+	newSession := func(id, device string, created time.Duration) *session.Session {
+		return &session.Session{
+			Id: id, UserId: "u3", Device: session.Device{DeviceId: device},
+			CreatedAt: now.Add(created), ExpireAt: now.Add(time.Hour),
+		}
+	}
+	ids := func() []string {
+		list, err := store.List(ctx, "u3")
+		assert.NoError(t, err)
+		var ids []string
+		for _, s := range list {
+			ids = append(ids, s.Id)
+		}
+		return ids
+	}
+	limit := session.Limit{MaxSessions: 2, ReplaceDevice: true}
+
+	// 超过数量时删除最早创建的, 已过期的不计入
+	expired := newSession("expired", "", -3*time.Hour)
+	expired.ExpireAt = now.Add(-time.Hour)
+	assert.NoError(t, store.Save(ctx, expired, session.Limit{}))
+	assert.NoError(t, store.Save(ctx, newSession("a", "d1", 0), limit))
+	assert.NoError(t, store.Save(ctx, newSession("b", "d2", time.Second), limit))
+	assert.Equal(t, []string{"a", "b"}, ids())
+	assert.NoError(t, store.Save(ctx, newSession("c", "d3", 2*time.Second), limit))
+	assert.Equal(t, []string{"b", "c"}, ids())
+
+	// 同一设备的旧会话被替换
+	assert.NoError(t, store.Save(ctx, newSession("d", "d2", 3*time.Second), limit))
+	assert.Equal(t, []string{"c", "d"}, ids())
+
+	// 已满时拒绝, 不做任何修改
+	limit.RejectWhenFull = true
+	assert.ErrorIs(t, store.Save(ctx, newSession("e", "d4", 4*time.Second), limit), session.ErrTooManySessions)
+	assert.Equal(t, []string{"c", "d"}, ids())
+	assert.NoError(t, store.Save(ctx, newSession("f", "d3", 5*time.Second), limit))
+	assert.Equal(t, []string{"d", "f"}, ids())
+
+	// 并发保存也不超过数量
+	assert.NoError(t, store.Delete(ctx, "u3"))
+	limit = session.Limit{MaxSessions: 3}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, store.Save(ctx, newSession(fmt.Sprintf("s%02d", i), "", time.Duration(i)*time.Second), limit))
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, ids(), 3)
+	assert.NoError(t, store.Delete(ctx, "u3"))
+}
@@ -3,6 +3,7 @@ package authorize
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"strconv"
 	"time"
@@ -16,6 +17,12 @@ import (
 
 const UserAuthorizeInfo = "userAuthorizeInfo"
 
+// ClaimTokenUse token 用途, 未设置的为普通用户 token; 设置了用途的 token 只能由 VerifyTokenUse 校验
+const ClaimTokenUse = "token_use"
+
+// ErrTokenUse token 用途不符, 如把 OAuth2 access token 当作普通用户 token 使用
+var ErrTokenUse = errors.New("authorize: token use mismatch")
+
 type UserAuthorize struct {
 	publicKey  jwk.Key
 	privateKey jwk.Key
@@ -79,11 +86,20 @@ func (userAuthorize *UserAuthorize) SetBanAccount(callback func(context.Context,
 }
 
 func (userAuthorize *UserAuthorize) GenerateToken(ctx context.Context, user IAuthorizeOther) (str string, err error) {
+	return userAuthorize.GenerateTokenWithClaims(ctx, user, nil)
+}
+
+// GenerateTokenWithClaims 生成 token, 并附加额外的 claims (如 sid, amr)
+func (userAuthorize *UserAuthorize) GenerateTokenWithClaims(ctx context.Context, user IAuthorizeOther, claims map[string]any) (str string, err error) {
 	userEncrypt, err := user.Encrypt(ctx, userAuthorize.options.KeySignatureAlgorithm, userAuthorize.privateKey)
 	if err != nil {
 		return "", err
 	}
-	jwtToken, err := jwt.NewBuilder().
+	builder := jwt.NewBuilder()
+	for k, v := range claims {
+		builder = builder.Claim(k, v)
+	}
+	jwtToken, err := builder.
 		Issuer(userAuthorize.options.Issuer).
 		Expiration(time.Now().Add(userAuthorize.options.Expire)).
 		IssuedAt(time.Now()).
@@ -100,17 +116,26 @@ func (userAuthorize *UserAuthorize) GenerateToken(ctx context.Context, user IAut
 	return string(signed), nil
 }
 
+// VerifyToken 校验普通用户 token, 带有 ClaimTokenUse 的 token 返回 ErrTokenUse
 func (userAuthorize *UserAuthorize) VerifyToken(ctx context.Context, token string, user IAuthorizeOther) (jwt.Token, error) {
+	return userAuthorize.VerifyTokenUse(ctx, token, "", user)
+}
+
+// VerifyTokenUse 校验 token, 要求 ClaimTokenUse 与 use 一致
+func (userAuthorize *UserAuthorize) VerifyTokenUse(ctx context.Context, token, use string, user IAuthorizeOther) (jwt.Token, error) {
 	jwtToken, err := jwt.ParseString(token, jwt.WithKey(userAuthorize.options.SignatureAlgorithm, userAuthorize.secretKey))
 	if err != nil {
 		return nil, err
 	}
 	claims := jwtToken.PrivateClaims()
-	userEncrypt, ok := claims[UserAuthorizeInfo]
+	if got, _ := claims[ClaimTokenUse].(string); got != use {
+		return nil, ErrTokenUse
+	}
+	userEncrypt, ok := claims[UserAuthorizeInfo].(string)
 	if !ok {
-		return nil, err
+		return nil, ErrTokenClaimNotFound
 	}
-	err = user.Decrypt(ctx, userEncrypt.(string), userAuthorize.options.KeySignatureAlgorithm, userAuthorize.privateKey)
+	err = user.Decrypt(ctx, userEncrypt, userAuthorize.options.KeySignatureAlgorithm, userAuthorize.privateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -193,6 +218,7 @@ func UserAuthorizeFromContext(ctx context.Context) *UserAuthorizeOther {
 	return nil
 }
 
+// GetUserIdFromContext id 不是数字时会 panic, 推荐使用 LookupUserIdFromContext
 func GetUserIdFromContext(ctx context.Context) int64 {
 	if ctx == nil {
 		panic("ctx is nil")
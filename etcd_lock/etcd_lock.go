@@ -17,6 +17,7 @@ type EtcdLock struct {
 	cancel  context.CancelFunc
 	key     string
 	ctx     context.Context
+	done    chan struct{}
 }
 
 func NewEtcdLock(client *clientv3.Client, key string) *EtcdLock {
@@ -45,7 +46,10 @@ func (l *EtcdLock) Lock(ttl int64) error {
 	if err != nil {
 		return err
 	}
+	done := make(chan struct{})
+	l.done = done
 	go func() {
+		defer close(done)
 		for {
 			select {
 			// 自动续约
@@ -78,6 +82,11 @@ func (l *EtcdLock) Lock(ttl int64) error {
 	return nil
 }
 
+// Done 续约停止 (租约失效或已 Unlock) 时关闭, 未调用 Lock 时返回 nil
+func (l *EtcdLock) Done() <-chan struct{} {
+	return l.done
+}
+
 // Unlock 释放锁
 func (l *EtcdLock) Unlock() error {
 	if l.cancel != nil {
@@ -8,6 +8,8 @@ import (
 
 var _ Extractor = (*AuthorizationExtractor)(nil)
 
+const authorizationKey = keyExtractor("Authorization")
+
 type AuthorizationExtractor struct{}
 
 func NewAuthorizationExtractor() AuthorizationExtractor {
@@ -15,37 +17,17 @@ func NewAuthorizationExtractor() AuthorizationExtractor {
 }
 
 func (e AuthorizationExtractor) Extract(ctx context.Context) (string, error) {
-	token := ctx.Value("Authorization")
-	tokenHeader, ok := token.(string)
-	if !ok {
-		return "", ErrInvalidTokenType
-	}
-	if tokenHeader == "" {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader, nil
+	return authorizationKey.Extract(ctx)
 }
 
 func (e AuthorizationExtractor) ExtractRequest(r *http.Request) (string, error) {
-	tokenHeader := r.Header.Get("Authorization")
-	if tokenHeader == "" {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader, nil
+	return authorizationKey.ExtractRequest(r)
 }
 
 func (e AuthorizationExtractor) ExtractHeader(header http.Header) (string, error) {
-	tokenHeader := header.Get("Authorization")
-	if tokenHeader == "" {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader, nil
+	return authorizationKey.ExtractHeader(header)
 }
 
 func (e AuthorizationExtractor) ExtractQuery(query url.Values) (string, error) {
-	tokenHeader := query.Get("Authorization")
-	if tokenHeader == "" {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader, nil
+	return authorizationKey.ExtractQuery(query)
 }
@@ -4,11 +4,12 @@ import (
 	"context"
 	"net/http"
 	"net/url"
-	"strings"
 )
 
 var _ Extractor = (*BearerExtractor)(nil)
 
+var bearer = NewPrefixExtractor(SchemeBearer, AuthorizationExtractor{})
+
 type BearerExtractor struct{}
 
 func NewBearerExtractor() BearerExtractor {
@@ -16,37 +17,17 @@ func NewBearerExtractor() BearerExtractor {
 }
 
 func (e BearerExtractor) Extract(ctx context.Context) (string, error) {
-	token := ctx.Value("Authorization")
-	tokenHeader, ok := token.(string)
-	if !ok {
-		return "", ErrInvalidTokenType
-	}
-	if tokenHeader == "" || !strings.HasPrefix(strings.ToLower(tokenHeader), "bearer ") {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader[7:], nil
+	return bearer.Extract(ctx)
 }
 
 func (e BearerExtractor) ExtractRequest(r *http.Request) (string, error) {
-	tokenHeader := r.Header.Get("Authorization")
-	if tokenHeader == "" || !strings.HasPrefix(strings.ToLower(tokenHeader), "bearer ") {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader[7:], nil
+	return bearer.ExtractRequest(r)
 }
 
 func (e BearerExtractor) ExtractHeader(header http.Header) (string, error) {
-	tokenHeader := header.Get("Authorization")
-	if tokenHeader == "" || !strings.HasPrefix(strings.ToLower(tokenHeader), "bearer ") {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader[7:], nil
+	return bearer.ExtractHeader(header)
 }
 
 func (e BearerExtractor) ExtractQuery(query url.Values) (string, error) {
-	tokenHeader := query.Get("Authorization")
-	if tokenHeader == "" || !strings.HasPrefix(strings.ToLower(tokenHeader), "bearer ") {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader[7:], nil
+	return bearer.ExtractQuery(query)
 }
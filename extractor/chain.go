@@ -0,0 +1,66 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+var _ Extractor = (*Chain)(nil)
+
+// Chain 按顺序尝试每个 Extractor, 返回第一个成功的结果
+type Chain []Extractor
+
+// ChainExtractor 如 ChainExtractor(BearerExtractor{}, NewCookieExtractor("token"))
+func ChainExtractor(extractors ...Extractor) Chain {
+	return extractors
+}
+
+func (c Chain) Extract(ctx context.Context) (string, error) {
+	token, _, err := c.ExtractSource(ctx)
+	return token, err
+}
+
+func (c Chain) ExtractRequest(r *http.Request) (string, error) {
+	token, _, err := c.ExtractRequestSource(r)
+	return token, err
+}
+
+func (c Chain) ExtractHeader(header http.Header) (string, error) {
+	token, _, err := c.ExtractHeaderSource(header)
+	return token, err
+}
+
+func (c Chain) ExtractQuery(query url.Values) (string, error) {
+	token, _, err := c.ExtractQuerySource(query)
+	return token, err
+}
+
+// ExtractSource 同 Extract, 额外返回命中的 Extractor 在链中的下标, 未命中时为 -1
+func (c Chain) ExtractSource(ctx context.Context) (string, int, error) {
+	return c.first(func(e Extractor) (string, error) { return e.Extract(ctx) })
+}
+
+// ExtractRequestSource 同 ExtractRequest, 额外返回命中的下标
+func (c Chain) ExtractRequestSource(r *http.Request) (string, int, error) {
+	return c.first(func(e Extractor) (string, error) { return e.ExtractRequest(r) })
+}
+
+// ExtractHeaderSource 同 ExtractHeader, 额外返回命中的下标
+func (c Chain) ExtractHeaderSource(header http.Header) (string, int, error) {
+	return c.first(func(e Extractor) (string, error) { return e.ExtractHeader(header) })
+}
+
+// ExtractQuerySource 同 ExtractQuery, 额外返回命中的下标
+func (c Chain) ExtractQuerySource(query url.Values) (string, int, error) {
+	return c.first(func(e Extractor) (string, error) { return e.ExtractQuery(query) })
+}
+
+func (c Chain) first(extract func(Extractor) (string, error)) (string, int, error) {
+	for i, e := range c {
+		if token, err := extract(e); err == nil && token != "" {
+			return token, i, nil
+		}
+	}
+	return "", -1, ErrNoTokenInContext
+}
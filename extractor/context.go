@@ -0,0 +1,52 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+)
+
+// ctxCredentialKey 按名称区分的 context key, 名称按 http header 规范化
+type ctxCredentialKey struct{ name string }
+
+func credentialKey(name string) ctxCredentialKey {
+	return ctxCredentialKey{name: http.CanonicalHeaderKey(name)}
+}
+
+// WithCredential 将原始凭证以 name 存入 context, 如 WithCredential(ctx, "Authorization", "Bearer xxx")
+func WithCredential(ctx context.Context, name, credential string) context.Context {
+	return context.WithValue(ctx, credentialKey(name), credential)
+}
+
+// FromCredential 读取 WithCredential 存入的原始凭证
+func FromCredential(ctx context.Context, name string) (v string, ok bool) {
+	v, ok = ctx.Value(credentialKey(name)).(string)
+	return
+}
+
+// WithAuthorization 存入原始的 Authorization 值
+func WithAuthorization(ctx context.Context, authorization string) context.Context {
+	return WithCredential(ctx, "Authorization", authorization)
+}
+
+// FromAuthorization 读取 WithAuthorization 存入的值
+func FromAuthorization(ctx context.Context) (string, bool) {
+	return FromCredential(ctx, "Authorization")
+}
+
+// WithToken 存入原始的 Token 值
+func WithToken(ctx context.Context, token string) context.Context {
+	return WithCredential(ctx, "Token", token)
+}
+
+// FromToken 读取 WithToken 存入的值
+func FromToken(ctx context.Context) (string, bool) {
+	return FromCredential(ctx, "Token")
+}
+
+// valueFromContext 优先读取类型化的 key, 兼容以字符串为 key 存入的值
+func valueFromContext(ctx context.Context, name string) any {
+	if v, ok := FromCredential(ctx, name); ok {
+		return v
+	}
+	return ctx.Value(name)
+}
@@ -0,0 +1,40 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+var _ Extractor = (*CookieExtractor)(nil)
+
+// CookieExtractor 从 cookie 中读取 token, 依次尝试每个 cookie 名
+type CookieExtractor []string
+
+func NewCookieExtractor(name ...string) CookieExtractor {
+	return name
+}
+
+// Extract 从 context 中读取同名的值
+func (e CookieExtractor) Extract(ctx context.Context) (string, error) {
+	return HeaderExtractor(e).Extract(ctx)
+}
+
+func (e CookieExtractor) ExtractRequest(r *http.Request) (string, error) {
+	for _, name := range e {
+		if cookie, err := r.Cookie(name); err == nil && cookie.Value != "" {
+			return cookie.Value, nil
+		}
+	}
+	return "", ErrNoTokenInContext
+}
+
+// ExtractHeader 解析 header 中的 Cookie
+func (e CookieExtractor) ExtractHeader(header http.Header) (string, error) {
+	return e.ExtractRequest(&http.Request{Header: header})
+}
+
+// ExtractQuery cookie 不会出现在 query 中, 总是返回 ErrNoTokenInContext
+func (e CookieExtractor) ExtractQuery(url.Values) (string, error) {
+	return "", ErrNoTokenInContext
+}
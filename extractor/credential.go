@@ -0,0 +1,182 @@
+package extractor
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidCredential = errors.New("invalid credential")
+
+// Credential 结构化的凭证
+type Credential struct {
+	// Scheme 如 Basic, Digest
+	Scheme string
+	// User 用户名
+	User string
+	// Secret Basic 为密码, Digest 为 response
+	Secret string
+	// Params Digest 的全部参数, Basic 为 nil
+	Params map[string]string
+}
+
+// CredentialExtractor 与 Extractor 对应, 返回结构化的凭证
+type CredentialExtractor interface {
+	ExtractCredential(ctx context.Context) (*Credential, error)
+	ExtractCredentialRequest(r *http.Request) (*Credential, error)
+	ExtractCredentialHeader(header http.Header) (*Credential, error)
+	ExtractCredentialQuery(query url.Values) (*Credential, error)
+}
+
+var _ CredentialExtractor = (*BasicExtractor)(nil)
+var _ CredentialExtractor = (*DigestExtractor)(nil)
+
+// BasicExtractor 解析 HTTP Basic 认证 (RFC 7617)
+type BasicExtractor struct {
+	prefix PrefixExtractor
+}
+
+// NewBasicExtractor 未指定 e 时从 Authorization 读取
+func NewBasicExtractor(e ...Extractor) BasicExtractor {
+	return BasicExtractor{prefix: NewPrefixExtractor(SchemeBasic, sourceExtractor(e))}
+}
+
+func (e BasicExtractor) parse(token string, err error) (*Credential, error) {
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCredential
+	}
+	user, password, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return nil, ErrInvalidCredential
+	}
+	return &Credential{Scheme: SchemeBasic, User: user, Secret: password}, nil
+}
+
+func (e BasicExtractor) ExtractCredential(ctx context.Context) (*Credential, error) {
+	return e.parse(e.prefix.Extract(ctx))
+}
+
+func (e BasicExtractor) ExtractCredentialRequest(r *http.Request) (*Credential, error) {
+	return e.parse(e.prefix.ExtractRequest(r))
+}
+
+func (e BasicExtractor) ExtractCredentialHeader(header http.Header) (*Credential, error) {
+	return e.parse(e.prefix.ExtractHeader(header))
+}
+
+func (e BasicExtractor) ExtractCredentialQuery(query url.Values) (*Credential, error) {
+	return e.parse(e.prefix.ExtractQuery(query))
+}
+
+// DigestExtractor 解析 HTTP Digest 认证 (RFC 7616), 校验见 DigestVerifier
+type DigestExtractor struct {
+	prefix PrefixExtractor
+}
+
+// NewDigestExtractor 未指定 e 时从 Authorization 读取
+func NewDigestExtractor(e ...Extractor) DigestExtractor {
+	return DigestExtractor{prefix: NewPrefixExtractor(SchemeDigest, sourceExtractor(e))}
+}
+
+func (e DigestExtractor) parse(token string, err error) (*Credential, error) {
+	if err != nil {
+		return nil, err
+	}
+	params, err := ParseAuthParams(token)
+	if err != nil {
+		return nil, err
+	}
+	user, ok := params["username"]
+	if !ok {
+		// RFC 7616 3.4.4, username* 使用 RFC 5987 编码
+		v, ok := params["username*"]
+		if !ok {
+			return nil, ErrInvalidCredential
+		}
+		charset, encoded, ok := strings.Cut(v, "''")
+		if !ok || !strings.EqualFold(charset, "UTF-8") {
+			return nil, ErrInvalidCredential
+		}
+		if user, err = url.PathUnescape(encoded); err != nil {
+			return nil, ErrInvalidCredential
+		}
+	}
+	if params["response"] == "" {
+		return nil, ErrInvalidCredential
+	}
+	return &Credential{Scheme: SchemeDigest, User: user, Secret: params["response"], Params: params}, nil
+}
+
+func (e DigestExtractor) ExtractCredential(ctx context.Context) (*Credential, error) {
+	return e.parse(e.prefix.Extract(ctx))
+}
+
+func (e DigestExtractor) ExtractCredentialRequest(r *http.Request) (*Credential, error) {
+	return e.parse(e.prefix.ExtractRequest(r))
+}
+
+func (e DigestExtractor) ExtractCredentialHeader(header http.Header) (*Credential, error) {
+	return e.parse(e.prefix.ExtractHeader(header))
+}
+
+func (e DigestExtractor) ExtractCredentialQuery(query url.Values) (*Credential, error) {
+	return e.parse(e.prefix.ExtractQuery(query))
+}
+
+func sourceExtractor(e []Extractor) Extractor {
+	if len(e) == 0 {
+		return AuthorizationExtractor{}
+	}
+	return ChainExtractor(e...)
+}
+
+// ParseAuthParams 解析 auth-param 列表, 如 `realm="a", qop=auth`, 参数名转为小写
+func ParseAuthParams(s string) (map[string]string, error) {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return params, nil
+		}
+		i := strings.IndexByte(s, '=')
+		if i <= 0 {
+			return nil, ErrInvalidCredential
+		}
+		name := strings.ToLower(strings.TrimSpace(s[:i]))
+		s = strings.TrimLeft(s[i+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i = 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return nil, ErrInvalidCredential
+			}
+			value, s = b.String(), s[i+1:]
+		} else {
+			i = strings.IndexByte(s, ',')
+			if i < 0 {
+				i = len(s)
+			}
+			value, s = strings.TrimSpace(s[:i]), s[i:]
+		}
+		s = strings.TrimLeft(s, " \t")
+		if s != "" && s[0] != ',' {
+			return nil, ErrInvalidCredential
+		}
+		params[name] = value
+	}
+}
@@ -0,0 +1,256 @@
+package extractor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zmicro-team/ztlib/limiter/limit"
+)
+
+// Digest 算法
+const (
+	DigestMD5        = "MD5"
+	DigestMD5Sess    = "MD5-sess"
+	DigestSHA256     = "SHA-256"
+	DigestSHA256Sess = "SHA-256-sess"
+)
+
+var (
+	// ErrDigestMismatch 用户名、密码或请求不匹配
+	ErrDigestMismatch = errors.New("digest: response mismatch")
+	// ErrDigestStale nonce 已过期, 应重新质询并带上 stale=true
+	ErrDigestStale = errors.New("digest: stale nonce")
+	// ErrDigestReplay nonce 与 nc 已被使用过
+	ErrDigestReplay = errors.New("digest: nonce replayed")
+)
+
+// DigestSecretFunc 返回用户的明文密码
+type DigestSecretFunc func(ctx context.Context, username, realm string) (string, error)
+
+// DigestOption digest verifier 选项
+type DigestOption func(*DigestVerifier)
+
+// WithDigestAlgorithms 质询时提供的算法, 按优先级排列, 默认 SHA-256, MD5
+func WithDigestAlgorithms(algorithms ...string) DigestOption {
+	return func(v *DigestVerifier) {
+		v.algorithms = algorithms
+	}
+}
+
+// WithDigestNonceExpires nonce 有效期, 默认 5 分钟
+func WithDigestNonceExpires(d time.Duration) DigestOption {
+	return func(v *DigestVerifier) {
+		v.nonceExpires = d
+	}
+}
+
+// WithDigestKeyPrefix nonce 计数的 key 前缀, 默认 "digest:nonce:"
+func WithDigestKeyPrefix(prefix string) DigestOption {
+	return func(v *DigestVerifier) {
+		v.keyPrefix = prefix
+	}
+}
+
+// WithDigestExtractor 读取 Authorization 的来源
+func WithDigestExtractor(e ...Extractor) DigestOption {
+	return func(v *DigestVerifier) {
+		v.extractor = NewDigestExtractor(e...)
+	}
+}
+
+// DigestVerifier RFC 7616 Digest 质询与校验, 只支持 qop=auth 以及不带 qop 的旧设备.
+// nonce 带时间戳并以 key 签名, 无需存储即可校验有效期;
+// 每个 nonce+nc 的使用记录通过 limit.PeriodStorage (如 limiter 的 redis PeriodStore) 保存, 用于防重放.
+type DigestVerifier struct {
+	realm        string
+	key          []byte
+	store        limit.PeriodStorage
+	secret       DigestSecretFunc
+	algorithms   []string
+	nonceExpires time.Duration
+	keyPrefix    string
+	extractor    DigestExtractor
+	opaque       string
+}
+
+// NewDigestVerifier key 用于签名 nonce, 多实例部署时需一致
+func NewDigestVerifier(realm string, key []byte, store limit.PeriodStorage, secret DigestSecretFunc, opts ...DigestOption) *DigestVerifier {
+	v := &DigestVerifier{
+		realm:        realm,
+		key:          key,
+		store:        store,
+		secret:       secret,
+		algorithms:   []string{DigestSHA256, DigestMD5},
+		nonceExpires: 5 * time.Minute,
+		keyPrefix:    "digest:nonce:",
+		extractor:    NewDigestExtractor(),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	v.opaque = hex.EncodeToString(v.sign([]byte("opaque:" + realm)))
+	return v
+}
+
+func (v *DigestVerifier) sign(b []byte) []byte {
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write(b)
+	return mac.Sum(nil)[:16]
+}
+
+// nonce = base64url(unix毫秒(8) | 随机(8) | 签名(16))
+func (v *DigestVerifier) newNonce() (string, error) {
+	b := make([]byte, 16, 32)
+	binary.BigEndian.PutUint64(b, uint64(time.Now().UnixMilli()))
+	if _, err := rand.Read(b[8:16]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(b, v.sign(b)...)), nil
+}
+
+func (v *DigestVerifier) checkNonce(nonce string) error {
+	b, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(b) != 32 || !hmac.Equal(b[16:], v.sign(b[:16])) {
+		return ErrDigestMismatch
+	}
+	issued := time.UnixMilli(int64(binary.BigEndian.Uint64(b)))
+	if time.Since(issued) > v.nonceExpires {
+		return ErrDigestStale
+	}
+	return nil
+}
+
+// Challenge 返回 WWW-Authenticate 的值, 每个算法一个
+func (v *DigestVerifier) Challenge(stale bool) ([]string, error) {
+	nonce, err := v.newNonce()
+	if err != nil {
+		return nil, err
+	}
+	challenges := make([]string, 0, len(v.algorithms))
+	for _, algorithm := range v.algorithms {
+		c := fmt.Sprintf(`%s realm=%q, qop="auth", algorithm=%s, nonce=%q, opaque=%q`,
+			SchemeDigest, v.realm, algorithm, nonce, v.opaque)
+		if stale {
+			c += ", stale=true"
+		}
+		challenges = append(challenges, c)
+	}
+	return challenges, nil
+}
+
+// WriteChallenge 写入质询并返回 401
+func (v *DigestVerifier) WriteChallenge(w http.ResponseWriter, stale bool) error {
+	challenges, err := v.Challenge(stale)
+	if err != nil {
+		return err
+	}
+	for _, c := range challenges {
+		w.Header().Add("WWW-Authenticate", c)
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+	return nil
+}
+
+// Verify 校验请求中的 Digest 凭证, 成功时返回凭证.
+// 返回 ErrDigestStale 时应以 stale=true 重新质询.
+func (v *DigestVerifier) Verify(ctx context.Context, r *http.Request) (*Credential, error) {
+	c, err := v.extractor.ExtractCredentialRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	p := c.Params
+	algorithm := p["algorithm"]
+	if algorithm == "" {
+		algorithm = DigestMD5
+	}
+	if !v.supported(algorithm) {
+		return nil, ErrInvalidCredential
+	}
+	if p["realm"] != v.realm || p["opaque"] != v.opaque || p["uri"] != r.URL.RequestURI() {
+		return nil, ErrDigestMismatch
+	}
+	qop := p["qop"]
+	if qop != "" && (qop != "auth" || p["nc"] == "" || p["cnonce"] == "") {
+		return nil, ErrInvalidCredential
+	}
+	if err = v.checkNonce(p["nonce"]); err != nil {
+		return nil, err
+	}
+
+	password, err := v.secret(ctx, c.User, v.realm)
+	if err != nil {
+		return nil, err
+	}
+	want := digestResponse(algorithm, c.User, v.realm, password, r.Method, p)
+	if subtle.ConstantTimeCompare([]byte(want), []byte(strings.ToLower(c.Secret))) != 1 {
+		return nil, ErrDigestMismatch
+	}
+
+	// 不带 qop 时 nonce 只能使用一次, 否则每个 nc 只能使用一次
+	key := v.keyPrefix + p["nonce"]
+	if qop != "" {
+		nc, err := strconv.ParseUint(p["nc"], 16, 32)
+		if err != nil {
+			return nil, ErrInvalidCredential
+		}
+		key += ":" + strconv.FormatUint(nc, 16)
+	}
+	code, err := v.store.Take(ctx, key, 1, int(v.nonceExpires/time.Second)+1)
+	if err != nil {
+		return nil, err
+	}
+	if code > 1 {
+		return nil, ErrDigestReplay
+	}
+	return c, nil
+}
+
+func (v *DigestVerifier) supported(algorithm string) bool {
+	for _, a := range v.algorithms {
+		if strings.EqualFold(a, algorithm) {
+			return true
+		}
+	}
+	return false
+}
+
+func digestResponse(algorithm, username, realm, password, method string, p map[string]string) string {
+	var newHash func() hash.Hash
+	switch strings.ToUpper(strings.TrimSuffix(strings.ToLower(algorithm), "-sess")) {
+	case DigestMD5:
+		newHash = md5.New
+	case DigestSHA256:
+		newHash = sha256.New
+	default:
+		return ""
+	}
+	h := func(s ...string) string {
+		hh := newHash()
+		hh.Write([]byte(strings.Join(s, ":")))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	ha1 := h(username, realm, password)
+	if strings.HasSuffix(strings.ToLower(algorithm), "-sess") {
+		ha1 = h(ha1, p["nonce"], p["cnonce"])
+	}
+	ha2 := h(method, p["uri"])
+	if p["qop"] == "" {
+		return h(ha1, p["nonce"], ha2)
+	}
+	return h(ha1, p["nonce"], p["nc"], p["cnonce"], p["qop"], ha2)
+}
@@ -0,0 +1,105 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v9 "github.com/zmicro-team/ztlib/limiter/limit/redis/v9"
+)
+
+// RFC 7616 3.9.1
+func TestDigestResponse(t *testing.T) {
+	p := map[string]string{
+		"uri":    "/dir/index.html",
+		"nonce":  "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+		"nc":     "00000001",
+		"cnonce": "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+		"qop":    "auth",
+	}
+	assert.Equal(t, "8ca523f5e9506fed4657c9700eebdbec",
+		digestResponse(DigestMD5, "Mufasa", "http-auth@example.org", "Circle of Life", http.MethodGet, p))
+	assert.Equal(t, "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+		digestResponse(DigestSHA256, "Mufasa", "http-auth@example.org", "Circle of Life", http.MethodGet, p))
+}
+
+func TestDigestVerifier(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	const realm = "device@example.org"
+	store := v9.NewPeriodStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	v := NewDigestVerifier(realm, []byte("secret"), store, func(_ context.Context, username, _ string) (string, error) {
+		if username != "Mufasa" {
+			return "", ErrDigestMismatch
+		}
+		return "Circle of Life", nil
+	}, WithDigestNonceExpires(time.Second))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, v.WriteChallenge(w, false))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	challenges := w.Header().Values("WWW-Authenticate")
+	require.Len(t, challenges, 2)
+	params, err := ParseAuthParams(challenges[1][len(SchemeDigest)+1:])
+	require.NoError(t, err)
+	assert.Equal(t, DigestMD5, params["algorithm"])
+
+	request := func(user, password, nc string) *http.Request {
+		p := map[string]string{
+			"uri":    "/api/report?id=1",
+			"nonce":  params["nonce"],
+			"nc":     nc,
+			"cnonce": "0a4f113b",
+			"qop":    "auth",
+		}
+		r := httptest.NewRequest(http.MethodPost, p["uri"], nil)
+		r.Header.Set("Authorization", fmt.Sprintf(
+			`Digest username=%q, realm=%q, nonce=%q, uri=%q, qop=auth, nc=%s, cnonce=%q, response=%q, opaque=%q, algorithm=MD5`,
+			user, realm, p["nonce"], p["uri"], nc, p["cnonce"],
+			digestResponse(DigestMD5, user, realm, password, http.MethodPost, p), params["opaque"]))
+		return r
+	}
+
+	c, err := v.Verify(context.Background(), request("Mufasa", "Circle of Life", "00000001"))
+	require.NoError(t, err)
+	assert.Equal(t, "Mufasa", c.User)
+
+	_, err = v.Verify(context.Background(), request("Mufasa", "Circle of Life", "00000001"))
+	assert.Equal(t, ErrDigestReplay, err)
+
+	_, err = v.Verify(context.Background(), request("Mufasa", "Circle of Life", "00000002"))
+	assert.NoError(t, err)
+
+	_, err = v.Verify(context.Background(), request("Mufasa", "wrong", "00000003"))
+	assert.Equal(t, ErrDigestMismatch, err)
+
+	// 篡改 uri
+	r := request("Mufasa", "Circle of Life", "00000004")
+	r.URL.RawQuery = "id=2"
+	_, err = v.Verify(context.Background(), r)
+	assert.Equal(t, ErrDigestMismatch, err)
+
+	// 伪造 nonce
+	params["nonce"] = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	_, err = v.Verify(context.Background(), request("Mufasa", "Circle of Life", "00000001"))
+	assert.Equal(t, ErrDigestMismatch, err)
+
+	// 过期 nonce
+	challenges, err = v.Challenge(false)
+	require.NoError(t, err)
+	params, err = ParseAuthParams(challenges[1][len(SchemeDigest)+1:])
+	require.NoError(t, err)
+	time.Sleep(1100 * time.Millisecond)
+	_, err = v.Verify(context.Background(), request("Mufasa", "Circle of Life", "00000001"))
+	assert.Equal(t, ErrDigestStale, err)
+}
@@ -3,10 +3,14 @@ package extractor
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
 )
 
 type testHeaderExtractorType string
@@ -366,3 +370,270 @@ func TestTokenExtractor_ExtractQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestPrefixExtractor(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  string
+		header  string
+		want    string
+		wantErr error
+	}{
+		{"bearer", SchemeBearer, "Bearer xyz456", "xyz456", nil},
+		{"case insensitive", SchemeDPoP, "dpop xyz456", "xyz456", nil},
+		{"token", SchemeToken, "Token abc", "abc", nil},
+		{"basic", SchemeBasic, "Basic dXNlcjpwYXNz", "dXNlcjpwYXNz", nil},
+		{"scheme mismatch", SchemeBasic, "Bearer xyz456", "", ErrNoTokenInContext},
+		{"no separator", SchemeBearer, "Bearerxyz456", "", ErrNoTokenInContext},
+		{"empty credential", SchemeBearer, "Bearer ", "", ErrNoTokenInContext},
+		{"missing", SchemeBearer, "", "", ErrNoTokenInContext},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewPrefixExtractor(tt.scheme, AuthorizationExtractor{})
+			got, err := e.ExtractHeader(http.Header{"Authorization": []string{tt.header}})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := NewPrefixExtractor(SchemeBearer, AuthorizationExtractor{}).Extract(context.Background())
+	assert.Equal(t, ErrInvalidTokenType, err)
+}
+
+func TestCookieExtractor(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: "abc123"})
+	e := NewCookieExtractor("token", "session")
+
+	token, err := e.ExtractRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", token)
+
+	token, err = e.ExtractHeader(r.Header)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", token)
+
+	_, err = e.ExtractQuery(url.Values{"session": []string{"abc123"}})
+	assert.Equal(t, ErrNoTokenInContext, err)
+
+	_, err = NewCookieExtractor("token").ExtractRequest(r)
+	assert.Equal(t, ErrNoTokenInContext, err)
+}
+
+func TestFormExtractor(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("access_token=abc123"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	e := NewFormExtractor("access_token")
+
+	token, err := e.ExtractRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", token)
+
+	token, err = e.ExtractQuery(url.Values{"access_token": []string{"xyz456"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz456", token)
+
+	_, err = e.ExtractHeader(http.Header{"Access_token": []string{"abc123"}})
+	assert.Equal(t, ErrNoTokenInContext, err)
+}
+
+func TestParamExtractor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := NewParamExtractor("token")
+
+	var got string
+	router := gin.New()
+	router.GET("/share/:token", func(c *gin.Context) {
+		got, _ = e.Extract(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/share/abc123", nil))
+	assert.Equal(t, "abc123", got)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/share/{token}", func(_ http.ResponseWriter, r *http.Request) {
+		got, _ = e.ExtractRequest(r)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/share/xyz456", nil))
+	assert.Equal(t, "xyz456", got)
+
+	_, err := e.ExtractRequest(httptest.NewRequest(http.MethodGet, "/share/xyz456", nil))
+	assert.Equal(t, ErrNoTokenInContext, err)
+}
+
+func TestChainExtractor(t *testing.T) {
+	e := ChainExtractor(BearerExtractor{}, NewCookieExtractor("token"), NewFormExtractor("access_token"))
+
+	r := httptest.NewRequest(http.MethodGet, "/?access_token=q", nil)
+	r.AddCookie(&http.Cookie{Name: "token", Value: "c"})
+	token, source, err := e.ExtractRequestSource(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "c", token)
+	assert.Equal(t, 1, source)
+
+	r.Header.Set("Authorization", "Bearer b")
+	token, source, err = e.ExtractRequestSource(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", token)
+	assert.Equal(t, 0, source)
+
+	token, source, err = e.ExtractQuerySource(url.Values{"access_token": []string{"q"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "q", token)
+	assert.Equal(t, 2, source)
+
+	token, err = e.ExtractRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, ErrNoTokenInContext, err)
+	assert.Empty(t, token)
+
+	// context 中类型不符的值不应中断链
+	_, source, err = ChainExtractor(BearerExtractor{}, NewHeaderExtractor("Token")).
+		ExtractSource(context.WithValue(context.Background(), "Token", "abc123")) // nolint
+	assert.NoError(t, err)
+	assert.Equal(t, 1, source)
+}
+
+func TestTypedContextKey(t *testing.T) {
+	ctx := WithAuthorization(context.Background(), "Bearer abc123")
+	ctx = WithToken(ctx, "xyz456")
+
+	v, ok := FromAuthorization(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "Bearer abc123", v)
+
+	token, err := BearerExtractor{}.Extract(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", token)
+
+	token, err = TokenExtractor{}.Extract(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz456", token)
+
+	// 名称按 header 规范化
+	token, err = NewHeaderExtractor("token").Extract(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz456", token)
+
+	// 类型化的 key 优先于字符串 key
+	ctx = context.WithValue(context.Background(), "Token", "legacy") // nolint
+	token, err = TokenExtractor{}.Extract(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy", token)
+	token, err = TokenExtractor{}.Extract(WithToken(ctx, "typed"))
+	assert.NoError(t, err)
+	assert.Equal(t, "typed", token)
+}
+
+func TestMetadataExtractor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-token", "abc123", "authorization", "Bearer xyz456"))
+
+	token, err := NewMetadataExtractor().Extract(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer xyz456", token)
+
+	token, err = NewMetadataExtractor("x-token").Extract(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", token)
+
+	token, err = NewMetadataBearerExtractor().Extract(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz456", token)
+
+	token, err = NewMetadataExtractor().Extract(WithToken(context.Background(), "abc123"))
+	assert.Equal(t, ErrNoTokenInContext, err)
+	assert.Empty(t, token)
+
+	token, err = NewMetadataExtractor("x-token").Extract(WithCredential(context.Background(), "x-token", "abc123"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", token)
+}
+
+func TestWebSocketExtractor(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		want    string
+		wantErr error
+	}{
+		{"pair", []string{"chat, access_token, abc.def.ghi"}, "abc.def.ghi", nil},
+		{"multiple headers", []string{"access_token", "abc123"}, "abc123", nil},
+		{"dotted", []string{"chat, access_token.abc.def.ghi"}, "abc.def.ghi", nil},
+		{"marker without token", []string{"chat, access_token"}, "", ErrNoTokenInContext},
+		{"missing", []string{"chat"}, "", ErrNoTokenInContext},
+	}
+	e := NewWebSocketExtractor("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for _, v := range tt.header {
+				header.Add(HeaderWebSocketProtocol, v)
+			}
+			got, err := e.ExtractHeader(header)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	token, err := e.Extract(WithCredential(context.Background(), HeaderWebSocketProtocol, "access_token, abc123"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", token)
+	assert.Equal(t, "access_token", e.ResponseHeader().Get(HeaderWebSocketProtocol))
+}
+
+func TestBasicExtractor(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("partner", "p:ss")
+
+	c, err := NewBasicExtractor().ExtractCredentialRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, &Credential{Scheme: SchemeBasic, User: "partner", Secret: "p:ss"}, c)
+
+	_, err = NewBasicExtractor().ExtractCredentialHeader(http.Header{"Authorization": []string{"Basic !!"}})
+	assert.Equal(t, ErrInvalidCredential, err)
+
+	_, err = NewBasicExtractor().ExtractCredentialHeader(http.Header{"Authorization": []string{"Bearer abc"}})
+	assert.Equal(t, ErrNoTokenInContext, err)
+
+	c, err = NewBasicExtractor(NewHeaderExtractor("Proxy-Authorization")).
+		ExtractCredentialHeader(http.Header{"Proxy-Authorization": []string{"Basic dXNlcjo="}})
+	assert.NoError(t, err)
+	assert.Equal(t, "user", c.User)
+	assert.Empty(t, c.Secret)
+}
+
+func TestDigestExtractor(t *testing.T) {
+	header := http.Header{"Authorization": []string{`Digest username="Mufasa", realm="http-auth@example.org", ` +
+		`uri="/dir/index.html", algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", ` +
+		`nc=00000001, cnonce="f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", qop=auth, ` +
+		`response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1", ` +
+		`opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`}}
+	c, err := NewDigestExtractor().ExtractCredentialHeader(header)
+	assert.NoError(t, err)
+	assert.Equal(t, SchemeDigest, c.Scheme)
+	assert.Equal(t, "Mufasa", c.User)
+	assert.Equal(t, "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1", c.Secret)
+	assert.Equal(t, "SHA-256", c.Params["algorithm"])
+	assert.Equal(t, "00000001", c.Params["nc"])
+
+	header.Set("Authorization", `Digest username*=UTF-8''J%C3%A4s%C3%B8n%20Doe, response="abc"`)
+	c, err = NewDigestExtractor().ExtractCredentialHeader(header)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jäsøn Doe", c.User)
+
+	for _, v := range []string{
+		`Digest realm="a", response="abc"`,
+		`Digest username="a"`,
+		`Digest username="a, response="abc"`,
+		`Digest username="a" response="abc"`,
+	} {
+		header.Set("Authorization", v)
+		_, err = NewDigestExtractor().ExtractCredentialHeader(header)
+		assert.Equal(t, ErrInvalidCredential, err, v)
+	}
+}
+
+func TestParseAuthParams(t *testing.T) {
+	params, err := ParseAuthParams(`realm="a \"b\", c", qop=auth ,Nonce="x=="`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"realm": `a "b", c`, "qop": "auth", "nonce": "x=="}, params)
+}
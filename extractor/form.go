@@ -0,0 +1,40 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+var _ Extractor = (*FormExtractor)(nil)
+
+// FormExtractor 从表单字段中读取 token, 依次尝试每个字段名.
+// ExtractRequest 读取 r.FormValue, 即包含 body 表单和 query.
+type FormExtractor []string
+
+func NewFormExtractor(field ...string) FormExtractor {
+	return field
+}
+
+// Extract 从 context 中读取同名的值
+func (e FormExtractor) Extract(ctx context.Context) (string, error) {
+	return HeaderExtractor(e).Extract(ctx)
+}
+
+func (e FormExtractor) ExtractRequest(r *http.Request) (string, error) {
+	for _, field := range e {
+		if token := r.FormValue(field); token != "" {
+			return token, nil
+		}
+	}
+	return "", ErrNoTokenInContext
+}
+
+// ExtractHeader 表单字段不会出现在 header 中, 总是返回 ErrNoTokenInContext
+func (e FormExtractor) ExtractHeader(http.Header) (string, error) {
+	return "", ErrNoTokenInContext
+}
+
+func (e FormExtractor) ExtractQuery(query url.Values) (string, error) {
+	return HeaderExtractor(e).ExtractQuery(query)
+}
@@ -16,7 +16,7 @@ func NewHeaderExtractor(header ...string) HeaderExtractor {
 
 func (h HeaderExtractor) Extract(ctx context.Context) (string, error) {
 	for _, header := range h {
-		if token := ctx.Value(header); token != nil {
+		if token := valueFromContext(ctx, header); token != nil {
 			if tokenStr, ok := token.(string); ok {
 				return tokenStr, nil
 			}
@@ -0,0 +1,42 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+var _ Extractor = keyExtractor("")
+
+// keyExtractor 从同名的 context value、header、query 中读取 token
+type keyExtractor string
+
+func (k keyExtractor) Extract(ctx context.Context) (string, error) {
+	token, ok := valueFromContext(ctx, string(k)).(string)
+	if !ok {
+		return "", ErrInvalidTokenType
+	}
+	if token == "" {
+		return "", ErrNoTokenInContext
+	}
+	return token, nil
+}
+
+func (k keyExtractor) ExtractRequest(r *http.Request) (string, error) {
+	return k.ExtractHeader(r.Header)
+}
+
+func (k keyExtractor) ExtractHeader(header http.Header) (string, error) {
+	return nonEmpty(header.Get(string(k)))
+}
+
+func (k keyExtractor) ExtractQuery(query url.Values) (string, error) {
+	return nonEmpty(query.Get(string(k)))
+}
+
+func nonEmpty(token string) (string, error) {
+	if token == "" {
+		return "", ErrNoTokenInContext
+	}
+	return token, nil
+}
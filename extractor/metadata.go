@@ -0,0 +1,53 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/grpc/metadata"
+)
+
+var _ Extractor = (*MetadataExtractor)(nil)
+
+// MetadataExtractor 从 gRPC incoming metadata 中读取 token, 依次尝试每个 key.
+// metadata 中没有时回退到 context 中存入的凭证.
+type MetadataExtractor []string
+
+// NewMetadataExtractor 未指定 key 时默认为 authorization, x-token
+func NewMetadataExtractor(key ...string) MetadataExtractor {
+	if len(key) == 0 {
+		return MetadataExtractor{"authorization", "x-token"}
+	}
+	return key
+}
+
+// NewMetadataBearerExtractor 读取 metadata authorization 中的 Bearer token
+func NewMetadataBearerExtractor() PrefixExtractor {
+	return NewPrefixExtractor(SchemeBearer, NewMetadataExtractor("authorization"))
+}
+
+func (e MetadataExtractor) Extract(ctx context.Context) (string, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range e {
+			for _, token := range md.Get(key) {
+				if token != "" {
+					return token, nil
+				}
+			}
+		}
+	}
+	return HeaderExtractor(e).Extract(ctx)
+}
+
+func (e MetadataExtractor) ExtractRequest(r *http.Request) (string, error) {
+	return HeaderExtractor(e).ExtractRequest(r)
+}
+
+func (e MetadataExtractor) ExtractHeader(header http.Header) (string, error) {
+	return HeaderExtractor(e).ExtractHeader(header)
+}
+
+func (e MetadataExtractor) ExtractQuery(query url.Values) (string, error) {
+	return HeaderExtractor(e).ExtractQuery(query)
+}
@@ -0,0 +1,51 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+var _ Extractor = (*ParamExtractor)(nil)
+
+// ParamExtractor 从路由路径参数中读取 token, 依次尝试每个参数名.
+// Extract 传入 *gin.Context 时读取 gin 的路径参数,
+// ExtractRequest 读取标准库 ServeMux 的 r.PathValue.
+type ParamExtractor []string
+
+func NewParamExtractor(param ...string) ParamExtractor {
+	return param
+}
+
+func (e ParamExtractor) Extract(ctx context.Context) (string, error) {
+	if c, ok := ctx.(*gin.Context); ok {
+		for _, param := range e {
+			if token := c.Param(param); token != "" {
+				return token, nil
+			}
+		}
+		return "", ErrNoTokenInContext
+	}
+	return HeaderExtractor(e).Extract(ctx)
+}
+
+func (e ParamExtractor) ExtractRequest(r *http.Request) (string, error) {
+	for _, param := range e {
+		if token := r.PathValue(param); token != "" {
+			return token, nil
+		}
+	}
+	return "", ErrNoTokenInContext
+}
+
+// ExtractHeader 路径参数不会出现在 header 中, 总是返回 ErrNoTokenInContext
+func (e ParamExtractor) ExtractHeader(http.Header) (string, error) {
+	return "", ErrNoTokenInContext
+}
+
+// ExtractQuery 路径参数不会出现在 query 中, 总是返回 ErrNoTokenInContext
+func (e ParamExtractor) ExtractQuery(url.Values) (string, error) {
+	return "", ErrNoTokenInContext
+}
@@ -0,0 +1,57 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// 常用的认证 scheme
+const (
+	SchemeBearer = "Bearer"
+	SchemeToken  = "Token"
+	SchemeBasic  = "Basic"
+	SchemeDigest = "Digest"
+	SchemeDPoP   = "DPoP"
+)
+
+var _ Extractor = (*PrefixExtractor)(nil)
+
+// PrefixExtractor 去掉 "Scheme " 前缀 (不区分大小写), 不匹配时返回 ErrNoTokenInContext
+type PrefixExtractor struct {
+	Scheme    string
+	Extractor Extractor
+}
+
+// NewPrefixExtractor 如 NewPrefixExtractor("DPoP", AuthorizationExtractor{})
+func NewPrefixExtractor(scheme string, e Extractor) PrefixExtractor {
+	return PrefixExtractor{Scheme: scheme, Extractor: e}
+}
+
+func (p PrefixExtractor) strip(token string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	n := len(p.Scheme) + 1
+	if len(token) <= n || token[n-1] != ' ' || !strings.EqualFold(token[:n-1], p.Scheme) {
+		return "", ErrNoTokenInContext
+	}
+	return token[n:], nil
+}
+
+func (p PrefixExtractor) Extract(ctx context.Context) (string, error) {
+	return p.strip(p.Extractor.Extract(ctx))
+}
+
+func (p PrefixExtractor) ExtractRequest(r *http.Request) (string, error) {
+	return p.strip(p.Extractor.ExtractRequest(r))
+}
+
+func (p PrefixExtractor) ExtractHeader(header http.Header) (string, error) {
+	return p.strip(p.Extractor.ExtractHeader(header))
+}
+
+func (p PrefixExtractor) ExtractQuery(query url.Values) (string, error) {
+	return p.strip(p.Extractor.ExtractQuery(query))
+}
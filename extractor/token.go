@@ -6,6 +6,8 @@ import (
 	"net/url"
 )
 
+const tokenKey = keyExtractor("Token")
+
 type TokenExtractor struct{}
 
 var _ Extractor = (*TokenExtractor)(nil)
@@ -15,37 +17,17 @@ func NewTokenExtractor() TokenExtractor {
 }
 
 func (e TokenExtractor) Extract(ctx context.Context) (string, error) {
-	token := ctx.Value("Token")
-	tokenHeader, ok := token.(string)
-	if !ok {
-		return "", ErrInvalidTokenType
-	}
-	if tokenHeader == "" {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader, nil
+	return tokenKey.Extract(ctx)
 }
 
 func (e TokenExtractor) ExtractRequest(r *http.Request) (string, error) {
-	tokenHeader := r.Header.Get("Token")
-	if tokenHeader == "" {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader, nil
+	return tokenKey.ExtractRequest(r)
 }
 
 func (e TokenExtractor) ExtractHeader(header http.Header) (string, error) {
-	tokenHeader := header.Get("Token")
-	if tokenHeader == "" {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader, nil
+	return tokenKey.ExtractHeader(header)
 }
 
 func (e TokenExtractor) ExtractQuery(query url.Values) (string, error) {
-	tokenHeader := query.Get("Token")
-	if tokenHeader == "" {
-		return "", ErrNoTokenInContext
-	}
-	return tokenHeader, nil
+	return tokenKey.ExtractQuery(query)
 }
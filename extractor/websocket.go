@@ -0,0 +1,84 @@
+package extractor
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	HeaderWebSocketProtocol = "Sec-WebSocket-Protocol"
+	// DefaultWebSocketProtocol 默认的 token 子协议名
+	DefaultWebSocketProtocol = "access_token"
+)
+
+var _ Extractor = (*WebSocketExtractor)(nil)
+
+// WebSocketExtractor 从 Sec-WebSocket-Protocol 中读取 token.
+// 浏览器的 WebSocket API 不能设置自定义 header, 通常借用子协议传递 token, 支持两种写法:
+//
+//	new WebSocket(url, ["access_token", token])
+//	new WebSocket(url, ["access_token." + token])
+//
+// 浏览器要求服务端回应其中一个子协议, 否则会断开连接, 见 ResponseHeader.
+type WebSocketExtractor struct {
+	Protocol string
+}
+
+// NewWebSocketExtractor protocol 为空时使用 DefaultWebSocketProtocol
+func NewWebSocketExtractor(protocol string) WebSocketExtractor {
+	if protocol == "" {
+		protocol = DefaultWebSocketProtocol
+	}
+	return WebSocketExtractor{Protocol: protocol}
+}
+
+// ResponseHeader 握手响应需携带的 header, 可传给 websocket upgrader
+func (e WebSocketExtractor) ResponseHeader() http.Header {
+	header := http.Header{}
+	header.Set(HeaderWebSocketProtocol, e.Protocol)
+	return header
+}
+
+func (e WebSocketExtractor) parse(values []string) (string, error) {
+	var protocols []string
+	for _, v := range values {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				protocols = append(protocols, p)
+			}
+		}
+	}
+	for i, p := range protocols {
+		if p == e.Protocol && i+1 < len(protocols) {
+			return protocols[i+1], nil
+		}
+		if token, ok := strings.CutPrefix(p, e.Protocol+"."); ok && token != "" {
+			return token, nil
+		}
+	}
+	return "", ErrNoTokenInContext
+}
+
+// Extract 读取 context 中存入的 Sec-WebSocket-Protocol 原始值
+func (e WebSocketExtractor) Extract(ctx context.Context) (string, error) {
+	v, ok := valueFromContext(ctx, HeaderWebSocketProtocol).(string)
+	if !ok {
+		return "", ErrInvalidTokenType
+	}
+	return e.parse([]string{v})
+}
+
+func (e WebSocketExtractor) ExtractRequest(r *http.Request) (string, error) {
+	return e.ExtractHeader(r.Header)
+}
+
+func (e WebSocketExtractor) ExtractHeader(header http.Header) (string, error) {
+	return e.parse(header.Values(HeaderWebSocketProtocol))
+}
+
+// ExtractQuery 子协议不会出现在 query 中, 总是返回 ErrNoTokenInContext
+func (e WebSocketExtractor) ExtractQuery(url.Values) (string, error) {
+	return "", ErrNoTokenInContext
+}
@@ -0,0 +1,149 @@
+// Package codec 将整数 ID 编码为短字符串, 支持 base62 与 Crockford base32, 可选校验位
+package codec
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidCharacter = errors.New("codec: invalid character")
+	ErrInvalidChecksum  = errors.New("codec: invalid checksum")
+	ErrOverflow         = errors.New("codec: value overflows uint64")
+)
+
+const (
+	base62Alphabet    = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	// crockfordChecksum Crockford 校验位的 37 个符号
+	crockfordChecksum = crockfordAlphabet + "*~$=U"
+)
+
+var (
+	// Base62 0-9A-Za-z, 区分大小写, 校验位使用 Luhn mod 62
+	Base62 = newEncoding(base62Alphabet, false)
+	// Crockford Crockford base32, 不区分大小写, I L 视为 1, O 视为 0, 忽略 '-', 校验位使用 mod 37
+	Crockford = newEncoding(crockfordAlphabet, true)
+)
+
+// Encoding 整数编码
+type Encoding struct {
+	alphabet  string
+	index     [256]int
+	crockford bool
+	checksum  bool
+}
+
+func newEncoding(alphabet string, crockford bool) *Encoding {
+	e := &Encoding{alphabet: alphabet, crockford: crockford}
+	for i := range e.index {
+		e.index[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		e.index[alphabet[i]] = i
+	}
+	if crockford {
+		for i := 0; i < len(alphabet); i++ {
+			e.index[strings.ToLower(alphabet[i : i+1])[0]] = i
+		}
+		for _, c := range "iIlL" {
+			e.index[c] = 1
+		}
+		for _, c := range "oO" {
+			e.index[c] = 0
+		}
+	}
+	return e
+}
+
+// WithChecksum 返回末尾追加一位校验位的编码
+func (e *Encoding) WithChecksum() *Encoding {
+	c := *e
+	c.checksum = true
+	return &c
+}
+
+// Encode 编码
+func (e *Encoding) Encode(n uint64) string {
+	base := uint64(len(e.alphabet))
+	var buf [66]byte
+	i := len(buf)
+	for {
+		i--
+		buf[i] = e.alphabet[n%base]
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+	s := string(buf[i:])
+	if e.checksum {
+		s += string(e.checkSymbol(s))
+	}
+	return s
+}
+
+// Decode 解码
+func (e *Encoding) Decode(s string) (uint64, error) {
+	if e.crockford {
+		s = strings.ReplaceAll(s, "-", "")
+	}
+	if s == "" {
+		return 0, ErrInvalidCharacter
+	}
+	if e.checksum {
+		if len(s) < 2 {
+			return 0, ErrInvalidChecksum
+		}
+		var sum byte
+		s, sum = s[:len(s)-1], s[len(s)-1]
+		want := e.checkSymbol(s)
+		if e.crockford {
+			sum = strings.ToUpper(string(sum))[0]
+		}
+		if sum != want {
+			return 0, ErrInvalidChecksum
+		}
+	}
+
+	base := uint64(len(e.alphabet))
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		d := e.index[s[i]]
+		if d < 0 {
+			return 0, ErrInvalidCharacter
+		}
+		if n > (1<<64-1-uint64(d))/base {
+			return 0, ErrOverflow
+		}
+		n = n*base + uint64(d)
+	}
+	return n, nil
+}
+
+func (e *Encoding) checkSymbol(s string) byte {
+	if e.crockford {
+		// 校验位为数值 mod 37, 按位累加避免溢出
+		var mod uint64
+		for i := 0; i < len(s); i++ {
+			mod = (mod*32 + uint64(e.index[s[i]])) % 37
+		}
+		return crockfordChecksum[mod]
+	}
+	return e.alphabet[luhn(s, e.index[:], len(e.alphabet))]
+}
+
+// luhn Luhn mod N 算法, 可检测单个字符错误和大部分相邻字符交换
+func luhn(s string, index []int, n int) int {
+	factor, sum := 2, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		addend := factor * index[s[i]]
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+		sum += addend/n + addend%n
+	}
+	return (n - sum%n) % n
+}
@@ -0,0 +1,69 @@
+package codec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoding(t *testing.T) {
+	for _, e := range []*Encoding{Base62, Crockford, Base62.WithChecksum(), Crockford.WithChecksum()} {
+		for _, n := range []uint64{0, 1, 61, 62, 1234567890, 1<<63 - 1, math.MaxUint64} {
+			s := e.Encode(n)
+			got, err := e.Decode(s)
+			assert.NoError(t, err, s)
+			assert.Equal(t, n, got, s)
+		}
+	}
+}
+
+func TestBase62(t *testing.T) {
+	assert.Equal(t, "0", Base62.Encode(0))
+	assert.Equal(t, "z", Base62.Encode(61))
+	assert.Equal(t, "10", Base62.Encode(62))
+	assert.Equal(t, "LygHa16AHYF", Base62.Encode(math.MaxUint64))
+
+	_, err := Base62.Decode("ab-c")
+	assert.Equal(t, ErrInvalidCharacter, err)
+	_, err = Base62.Decode("LygHa16AHYG")
+	assert.Equal(t, ErrOverflow, err)
+}
+
+func TestCrockford(t *testing.T) {
+	assert.Equal(t, "Z", Crockford.Encode(31))
+	assert.Equal(t, "10", Crockford.Encode(32))
+
+	n, err := Crockford.Decode("1o-iL")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1<<15|1<<5|1), n)
+
+	// 1234 mod 37 = 13
+	assert.Equal(t, "16JD", Crockford.WithChecksum().Encode(1234))
+	n, err = Crockford.WithChecksum().Decode("16jd")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1234), n)
+}
+
+func TestChecksum(t *testing.T) {
+	for _, e := range []*Encoding{Base62.WithChecksum(), Crockford.WithChecksum()} {
+		s := e.Encode(987654321)
+		// 单个字符错误
+		for i := 0; i < len(s)-1; i++ {
+			b := []byte(s)
+			b[i] = e.alphabet[(e.index[b[i]]+1)%len(e.alphabet)]
+			_, err := e.Decode(string(b))
+			assert.Equal(t, ErrInvalidChecksum, err, string(b))
+		}
+		// 相邻字符交换
+		b := []byte(s)
+		for i := 0; i+2 < len(b); i++ {
+			if b[i] != b[i+1] {
+				b[i], b[i+1] = b[i+1], b[i]
+				_, err := e.Decode(string(b))
+				assert.Equal(t, ErrInvalidChecksum, err, string(b))
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package idgen
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zmicro-team/ztlib/idgen/codec"
+	"github.com/zmicro-team/ztlib/idgen/ksuid"
+	"github.com/zmicro-team/ztlib/idgen/ulid"
+	"github.com/zmicro-team/ztlib/idgen/uuidv7"
+)
+
+// ID 方案
+const (
+	SchemeSnowflake = "snowflake"
+	SchemeULID      = "ulid"
+	SchemeUUIDv7    = "uuidv7"
+	SchemeKSUID     = "ksuid"
+)
+
+// snowflake 的字符串编码
+const (
+	EncodingDecimal   = "decimal"
+	EncodingBase62    = "base62"
+	EncodingCrockford = "crockford"
+)
+
+// Generator 字符串 ID 生成器
+type Generator interface {
+	Next() (string, error)
+}
+
+// GeneratorFunc 函数形式的 Generator
+type GeneratorFunc func() (string, error)
+
+func (f GeneratorFunc) Next() (string, error) {
+	return f()
+}
+
+type Config struct {
+	// Scheme snowflake(默认), ulid, uuidv7, ksuid
+	Scheme string `json:"scheme" yaml:"scheme"`
+	// Encoding snowflake 的编码: decimal(默认), base62, crockford
+	Encoding string `json:"encoding" yaml:"encoding"`
+	// Checksum base62 与 crockford 编码末尾追加校验位
+	Checksum bool `json:"checksum" yaml:"checksum"`
+	// Monotonic ulid 与 uuidv7 同一毫秒内严格递增
+	Monotonic bool `json:"monotonic" yaml:"monotonic"`
+}
+
+// NewGenerator 按配置创建生成器, snowflake 使用默认生成器, 见 Setup
+func NewGenerator(c Config) (Generator, error) {
+	switch c.Scheme {
+	case "", SchemeSnowflake:
+		encode, err := snowflakeEncoder(c)
+		if err != nil {
+			return nil, err
+		}
+		return GeneratorFunc(func() (string, error) {
+			id, err := sf.Load().Next()
+			if err != nil {
+				return "", err
+			}
+			return encode(id), nil
+		}), nil
+	case SchemeULID:
+		var opts []ulid.Option
+		if c.Monotonic {
+			opts = append(opts, ulid.WithMonotonic())
+		}
+		g := ulid.NewGenerator(opts...)
+		return GeneratorFunc(func() (string, error) {
+			u, err := g.Next()
+			if err != nil {
+				return "", err
+			}
+			return u.String(), nil
+		}), nil
+	case SchemeUUIDv7:
+		var opts []uuidv7.Option
+		if c.Monotonic {
+			opts = append(opts, uuidv7.WithMonotonic())
+		}
+		g := uuidv7.NewGenerator(opts...)
+		return GeneratorFunc(func() (string, error) {
+			u, err := g.Next()
+			if err != nil {
+				return "", err
+			}
+			return u.String(), nil
+		}), nil
+	case SchemeKSUID:
+		return GeneratorFunc(func() (string, error) {
+			k, err := ksuid.New()
+			if err != nil {
+				return "", err
+			}
+			return k.String(), nil
+		}), nil
+	default:
+		return nil, fmt.Errorf("idgen: unknown scheme %q", c.Scheme)
+	}
+}
+
+func snowflakeEncoder(c Config) (func(int64) string, error) {
+	var enc *codec.Encoding
+	switch c.Encoding {
+	case "", EncodingDecimal:
+		return func(id int64) string { return strconv.FormatInt(id, 10) }, nil
+	case EncodingBase62:
+		enc = codec.Base62
+	case EncodingCrockford:
+		enc = codec.Crockford
+	default:
+		return nil, fmt.Errorf("idgen: unknown encoding %q", c.Encoding)
+	}
+	if c.Checksum {
+		enc = enc.WithChecksum()
+	}
+	return func(id int64) string { return enc.Encode(uint64(id)) }, nil
+}
@@ -0,0 +1,85 @@
+package idgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/idgen/codec"
+	"github.com/zmicro-team/ztlib/idgen/ksuid"
+	"github.com/zmicro-team/ztlib/idgen/ulid"
+	"github.com/zmicro-team/ztlib/idgen/uuidv7"
+)
+
+func TestNewGenerator(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		parse  func(string) error
+	}{
+		{"snowflake", Config{}, nil},
+		{"snowflake base62", Config{Encoding: EncodingBase62}, func(s string) error {
+			_, err := codec.Base62.Decode(s)
+			return err
+		}},
+		{"snowflake crockford checksum", Config{Encoding: EncodingCrockford, Checksum: true}, func(s string) error {
+			_, err := codec.Crockford.WithChecksum().Decode(s)
+			return err
+		}},
+		{"ulid", Config{Scheme: SchemeULID, Monotonic: true}, func(s string) error {
+			_, err := ulid.Parse(s)
+			return err
+		}},
+		{"uuidv7", Config{Scheme: SchemeUUIDv7, Monotonic: true}, func(s string) error {
+			_, err := uuidv7.Parse(s)
+			return err
+		}},
+		{"ksuid", Config{Scheme: SchemeKSUID}, func(s string) error {
+			_, err := ksuid.Parse(s)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGenerator(tt.config)
+			require.NoError(t, err)
+			seen := make(map[string]struct{})
+			for i := 0; i < 100; i++ {
+				id, err := g.Next()
+				require.NoError(t, err)
+				if tt.parse != nil {
+					assert.NoError(t, tt.parse(id))
+				}
+				seen[id] = struct{}{}
+			}
+			assert.Len(t, seen, 100)
+		})
+	}
+
+	_, err := NewGenerator(Config{Scheme: "uuidv4"})
+	assert.Error(t, err)
+	_, err = NewGenerator(Config{Encoding: "hex"})
+	assert.Error(t, err)
+}
+
+func TestGetMulti(t *testing.T) {
+	ids := GetMulti(1000)
+	assert.Len(t, ids, 1000)
+	for i := 1; i < len(ids); i++ {
+		assert.Greater(t, ids[i], ids[i-1])
+	}
+	assert.Empty(t, GetMulti(0))
+}
+
+func TestNewDefault(t *testing.T) {
+	t.Setenv(EnvMachineID, "invalid")
+	s, err := newDefault()
+	assert.Error(t, err)
+	require.NotNil(t, s)
+	assert.NotZero(t, s.NextId())
+
+	t.Setenv(EnvMachineID, "7")
+	_, err = newDefault()
+	assert.NoError(t, err)
+}
@@ -1,31 +1,93 @@
 package idgen
 
-import "github.com/zmicro-team/ztlib/idgen/snowflake"
+import (
+	"errors"
+	"sync/atomic"
 
-var sf *snowflake.Snowflake
+	"github.com/zmicro-team/ztlib/idgen/machineid"
+	"github.com/zmicro-team/ztlib/idgen/snowflake"
+)
+
+// EnvMachineID 默认生成器读取机器 ID 的环境变量
+const EnvMachineID = "IDGEN_MACHINE_ID"
+
+var (
+	sf atomic.Pointer[snowflake.Snowflake]
+	// initErr 默认生成器初始化时的错误, Setup 成功后清除
+	initErr atomic.Pointer[error]
+)
 
 func init() {
-	st := snowflake.Settings{
-		MachineID: getMachineId,
+	s, err := newDefault()
+	if err != nil {
+		initErr.Store(&err)
+	}
+	sf.Store(s)
+}
+
+// newDefault 创建默认生成器, 环境变量无效时回退为 1 并返回错误, 不在 init 中 panic
+func newDefault() (*snowflake.Snowflake, error) {
+	id, err := defaultMachineId()
+	if err != nil {
+		id = 1
+	}
+	s, serr := snowflake.NewSnowflake(snowflake.Settings{MachineID: func() (uint16, error) { return id, nil }})
+	if serr != nil {
+		// 机器 ID 为 1 时不会出错
+		panic(serr)
 	}
-	sf = snowflake.NewSnowflake(st)
+	return s, err
 }
 
-func getMachineId() (uint16, error) {
-	return 1, nil
+// defaultMachineId 未设置环境变量时回退为 1, 多副本部署时需设置或调用 Setup
+func defaultMachineId() (uint16, error) {
+	id, err := machineid.FromEnv(EnvMachineID)()
+	if errors.Is(err, machineid.ErrNotFound) {
+		return 1, nil
+	}
+	return id, err
+}
+
+// Err 返回默认生成器的初始化错误, 如 IDGEN_MACHINE_ID 无效时已回退为 1;
+// Setup 成功后返回 nil
+func Err() error {
+	if err := initErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// Setup 重新初始化默认生成器, 应在生成 ID 之前调用, 如
+//
+//	idgen.Setup(snowflake.Settings{MachineID: machineid.FromHostnameOrdinal()})
+func Setup(st snowflake.Settings) error {
+	s, err := snowflake.NewSnowflake(st)
+	if err != nil {
+		return err
+	}
+	sf.Store(s)
+	initErr.Store(nil)
+	return nil
 }
 
 func Next() int64 {
-	return sf.NextId()
+	return sf.Load().NextId()
 }
 
 func GetOne() int64 {
 	return Next()
 }
 
+// GetMulti 一次加锁生成 n 个 ID, 出错时 panic
 func GetMulti(n int) (ids []int64) {
-	for i := 0; i < n; i++ {
-		ids = append(ids, Next())
+	ids, err := NextN(n)
+	if err != nil {
+		panic(err)
 	}
-	return
+	return ids
+}
+
+// NextN 一次加锁生成 n 个 ID
+func NextN(n int) ([]int64, error) {
+	return sf.Load().NextN(n)
 }
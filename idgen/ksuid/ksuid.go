@@ -0,0 +1,101 @@
+// Package ksuid 生成 KSUID (https://github.com/segmentio/ksuid)
+package ksuid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"time"
+)
+
+var (
+	ErrInvalidLength    = errors.New("ksuid: invalid length")
+	ErrInvalidCharacter = errors.New("ksuid: invalid character")
+	ErrOverflow         = errors.New("ksuid: value overflows 160 bits")
+	ErrTimeOutOfRange   = errors.New("ksuid: time out of range")
+)
+
+const (
+	// Epoch KSUID 时间戳起点, 2014-05-13 16:53:20 UTC
+	Epoch = 1400000000
+
+	alphabet     = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	stringLength = 27
+)
+
+var maxKSUID = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+
+// KSUID 32 位秒级时间戳 + 128 位随机数
+type KSUID [20]byte
+
+// New 以当前时间生成
+func New() (KSUID, error) {
+	return NewWithTime(time.Now(), rand.Reader)
+}
+
+// NewWithTime 以指定时间和随机源生成
+func NewWithTime(t time.Time, entropy io.Reader) (k KSUID, err error) {
+	ts := t.Unix() - Epoch
+	if ts < 0 || ts > 1<<32-1 {
+		return k, ErrTimeOutOfRange
+	}
+	binary.BigEndian.PutUint32(k[:4], uint32(ts))
+	_, err = io.ReadFull(entropy, k[4:])
+	return k, err
+}
+
+// String 27 位 base62, 左侧补 0
+func (k KSUID) String() string {
+	var dst [stringLength]byte
+	n := new(big.Int).SetBytes(k[:])
+	base, mod := big.NewInt(62), new(big.Int)
+	for i := stringLength - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		dst[i] = alphabet[mod.Int64()]
+	}
+	return string(dst[:])
+}
+
+// Parse 解析 27 位 base62 字符串
+func Parse(s string) (k KSUID, err error) {
+	if len(s) != stringLength {
+		return k, ErrInvalidLength
+	}
+	n, base := new(big.Int), big.NewInt(62)
+	for i := 0; i < len(s); i++ {
+		d := indexOf(s[i])
+		if d < 0 {
+			return k, ErrInvalidCharacter
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(d)))
+	}
+	if n.Cmp(maxKSUID) > 0 {
+		return k, ErrOverflow
+	}
+	n.FillBytes(k[:])
+	return k, nil
+}
+
+func indexOf(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 36
+	}
+	return -1
+}
+
+// Time 时间戳部分
+func (k KSUID) Time() time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint32(k[:4]))+Epoch, 0)
+}
+
+// Payload 随机部分
+func (k KSUID) Payload() []byte {
+	return k[4:]
+}
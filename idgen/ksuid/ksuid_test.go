@@ -0,0 +1,43 @@
+package ksuid
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	k, err := Parse("0ujtsYcgvSTl8PAuAdqWYSMnLOv")
+	require.NoError(t, err)
+	assert.Equal(t, int64(107608047+Epoch), k.Time().Unix())
+	assert.Equal(t, "B5A1CD34B5F99D1154FB6853345C9735", strings.ToUpper(hex.EncodeToString(k.Payload())))
+	assert.Equal(t, "0ujtsYcgvSTl8PAuAdqWYSMnLOv", k.String())
+
+	var zero KSUID
+	assert.Equal(t, "000000000000000000000000000", zero.String())
+	max, err := Parse("aWgEPTl1tmebfsQzFP4bxwgy80V")
+	require.NoError(t, err)
+	assert.Equal(t, KSUID(bytes.Repeat([]byte{0xFF}, 20)), max)
+
+	_, err = Parse("aWgEPTl1tmebfsQzFP4bxwgy80W")
+	assert.Equal(t, ErrOverflow, err)
+	_, err = Parse("0ujtsYcgvSTl8PAuAdqWYSMnLO")
+	assert.Equal(t, ErrInvalidLength, err)
+	_, err = Parse("0ujtsYcgvSTl8PAuAdqWYSMnLO-")
+	assert.Equal(t, ErrInvalidCharacter, err)
+}
+
+func TestNew(t *testing.T) {
+	now := time.Now()
+	k, err := New()
+	require.NoError(t, err)
+	assert.WithinDuration(t, now, k.Time(), time.Second)
+
+	_, err = NewWithTime(time.Unix(Epoch-1, 0), nil)
+	assert.Equal(t, ErrTimeOutOfRange, err)
+}
@@ -0,0 +1,86 @@
+package machineid
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/zmicro-team/ztlib/etcd_lock"
+)
+
+var ErrExhausted = errors.New("machineid: all machine ids are in use")
+
+// EtcdAllocator 基于 etcd_lock 在 [0, max) 中抢占一个未被使用的 ID,
+// 进程存活期间通过租约续约持有, 进程退出或 Release 后释放.
+type EtcdAllocator struct {
+	client *clientv3.Client
+	prefix string
+	max    int
+	ttl    int64
+
+	mu   sync.Mutex
+	lock *etcd_lock.EtcdLock
+	id   uint16
+}
+
+// NewEtcdAllocator prefix 如 "/idgen/order/", ttl 为租约秒数
+func NewEtcdAllocator(client *clientv3.Client, prefix string, max int, ttl int64) *EtcdAllocator {
+	return &EtcdAllocator{
+		client: client,
+		prefix: prefix,
+		max:    max,
+		ttl:    ttl,
+	}
+}
+
+// Allocate 抢占一个 ID, 已持有时直接返回
+func (a *EtcdAllocator) Allocate() (uint16, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lock != nil {
+		return a.id, nil
+	}
+	for i := 0; i < a.max; i++ {
+		lock := etcd_lock.NewEtcdLock(a.client, a.prefix+strconv.Itoa(i))
+		err := lock.Lock(a.ttl)
+		if err == nil {
+			a.lock, a.id = lock, uint16(i)
+			return a.id, nil
+		}
+		// 抢占失败也需要撤销已创建的租约
+		_ = lock.Unlock()
+		if !errors.Is(err, etcd_lock.LockAcquiredError) {
+			return 0, err
+		}
+	}
+	return 0, ErrExhausted
+}
+
+// Provider 用作 snowflake.Settings.MachineID
+func (a *EtcdAllocator) Provider() Provider {
+	return a.Allocate
+}
+
+// Done 租约失效时关闭, 此时 ID 可能已被其它实例占用, 应停止生成 ID
+func (a *EtcdAllocator) Done() <-chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lock == nil {
+		return nil
+	}
+	return a.lock.Done()
+}
+
+// Release 释放持有的 ID
+func (a *EtcdAllocator) Release() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lock == nil {
+		return nil
+	}
+	err := a.lock.Unlock()
+	a.lock = nil
+	return err
+}
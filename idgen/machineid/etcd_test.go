@@ -0,0 +1,42 @@
+//go:build ignore
+
+package machineid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestEtcdAllocator(t *testing.T) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{"127.0.0.1:2379"},
+		DialTimeout: 2 * time.Second,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	a1 := NewEtcdAllocator(client, "/test/machineid/", 2, 5)
+	a2 := NewEtcdAllocator(client, "/test/machineid/", 2, 5)
+	a3 := NewEtcdAllocator(client, "/test/machineid/", 2, 5)
+
+	id1, err := a1.Allocate()
+	require.NoError(t, err)
+	id2, err := a2.Allocate()
+	require.NoError(t, err)
+	assert.NotEqual(t, id1, id2)
+
+	_, err = a3.Allocate()
+	assert.Equal(t, ErrExhausted, err)
+
+	require.NoError(t, a1.Release())
+	id3, err := a3.Allocate()
+	require.NoError(t, err)
+	assert.Equal(t, id1, id3)
+
+	_ = a2.Release()
+	_ = a3.Release()
+}
@@ -0,0 +1,107 @@
+// Package machineid 提供 snowflake 机器 ID 的来源
+package machineid
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotFound    = errors.New("machineid: machine id not found")
+	ErrInvalidBits = errors.New("machineid: bits must be between 1 and 16")
+)
+
+// Provider 返回当前实例的机器 ID, 可直接用作 snowflake.Settings.MachineID
+type Provider func() (uint16, error)
+
+// Fixed 固定的机器 ID
+func Fixed(id uint16) Provider {
+	return func() (uint16, error) {
+		return id, nil
+	}
+}
+
+// FromEnv 从环境变量读取, 未设置时返回 ErrNotFound
+func FromEnv(name string) Provider {
+	return func() (uint16, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return 0, ErrNotFound
+		}
+		id, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return 0, fmt.Errorf("machineid: invalid env %s=%q: %w", name, v, err)
+		}
+		return uint16(id), nil
+	}
+}
+
+// FromHostnameOrdinal 取 hostname 末尾的序号, 适用于 k8s StatefulSet (如 web-3 返回 3)
+func FromHostnameOrdinal() Provider {
+	return func() (uint16, error) {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return 0, err
+		}
+		return hostnameOrdinal(hostname)
+	}
+}
+
+func hostnameOrdinal(hostname string) (uint16, error) {
+	i := strings.LastIndexByte(hostname, '-')
+	if i < 0 {
+		return 0, ErrNotFound
+	}
+	id, err := strconv.ParseUint(hostname[i+1:], 10, 16)
+	if err != nil {
+		return 0, ErrNotFound
+	}
+	return uint16(id), nil
+}
+
+// FromPrivateIP 取第一个私有 IPv4 地址的低 bits 位.
+// 同一网段内实例数超过 1<<bits 时会冲突, 需确认网段划分; bits 不在 1 到 16 之间时返回 ErrInvalidBits.
+func FromPrivateIP(bits int) Provider {
+	return func() (uint16, error) {
+		if bits < 1 || bits > 16 {
+			return 0, ErrInvalidBits
+		}
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return 0, err
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ip := ipNet.IP.To4(); ip != nil && ip.IsPrivate() {
+				return lowBits(ip, bits), nil
+			}
+		}
+		return 0, ErrNotFound
+	}
+}
+
+func lowBits(ip net.IP, bits int) uint16 {
+	v := uint16(ip[2])<<8 | uint16(ip[3])
+	return v & uint16(1<<bits-1)
+}
+
+// First 依次尝试, 返回第一个成功的结果
+func First(providers ...Provider) Provider {
+	return func() (uint16, error) {
+		err := ErrNotFound
+		for _, p := range providers {
+			var id uint16
+			if id, err = p(); err == nil {
+				return id, nil
+			}
+		}
+		return 0, err
+	}
+}
@@ -0,0 +1,66 @@
+package machineid
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("TEST_MACHINE_ID", "12")
+	id, err := FromEnv("TEST_MACHINE_ID")()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(12), id)
+
+	_, err = FromEnv("TEST_MACHINE_ID_NOT_SET")()
+	assert.Equal(t, ErrNotFound, err)
+
+	t.Setenv("TEST_MACHINE_ID", "70000")
+	_, err = FromEnv("TEST_MACHINE_ID")()
+	assert.Error(t, err)
+}
+
+func TestHostnameOrdinal(t *testing.T) {
+	tests := []struct {
+		hostname string
+		want     uint16
+		wantErr  error
+	}{
+		{"web-0", 0, nil},
+		{"order-service-12", 12, nil},
+		{"web", 0, ErrNotFound},
+		{"web-abc", 0, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			got, err := hostnameOrdinal(tt.hostname)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLowBits(t *testing.T) {
+	ip := net.ParseIP("10.0.3.21").To4()
+	assert.Equal(t, uint16(21), lowBits(ip, 5))
+	assert.Equal(t, uint16(3<<8|21), lowBits(ip, 16))
+	for _, bits := range []int{-1, 0, 17} {
+		_, err := FromPrivateIP(bits)()
+		assert.ErrorIs(t, err, ErrInvalidBits, bits)
+	}
+}
+
+func TestFirst(t *testing.T) {
+	failed := errors.New("failed")
+	id, err := First(func() (uint16, error) { return 0, failed }, Fixed(3))()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(3), id)
+
+	_, err = First(func() (uint16, error) { return 0, failed })()
+	assert.Equal(t, failed, err)
+
+	_, err = First()()
+	assert.Equal(t, ErrNotFound, err)
+}
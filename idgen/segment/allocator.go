@@ -0,0 +1,139 @@
+package segment
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Allocator 号段分配器, 每个 tag 持有当前号段与预加载的下一号段 (双 buffer),
+// 热路径上不访问存储.
+type Allocator struct {
+	store        Store
+	step         int64
+	preloadRatio float64
+	loadTimeout  time.Duration
+	onLoadError  func(tag string, err error)
+
+	mu      sync.Mutex
+	buffers map[string]*buffer
+}
+
+type buffer struct {
+	mu      sync.Mutex
+	current Segment
+	// pos 当前号段中下一个 ID
+	pos  int64
+	next *Segment
+	// loading 非 nil 表示正在加载, 加载结束时关闭
+	loading chan struct{}
+}
+
+func NewAllocator(store Store, opts ...Option) *Allocator {
+	a := &Allocator{
+		store:        store,
+		step:         1000,
+		preloadRatio: 0.1,
+		loadTimeout:  3 * time.Second,
+		buffers:      make(map[string]*buffer),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+func (a *Allocator) buffer(tag string) *buffer {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b, ok := a.buffers[tag]
+	if !ok {
+		b = &buffer{}
+		a.buffers[tag] = b
+	}
+	return b
+}
+
+// Next 返回 tag 的下一个 ID
+func (a *Allocator) Next(ctx context.Context, tag string) (int64, error) {
+	b := a.buffer(tag)
+	b.mu.Lock()
+	for {
+		if b.pos < b.current.End {
+			id := b.pos
+			b.pos++
+			a.maybePreload(tag, b)
+			b.mu.Unlock()
+			return id, nil
+		}
+		if b.next != nil {
+			b.current, b.pos, b.next = *b.next, b.next.Start, nil
+			continue
+		}
+
+		// 号段用尽且没有预加载的号段
+		if loading := b.loading; loading != nil {
+			b.mu.Unlock()
+			select {
+			case <-loading:
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+			b.mu.Lock()
+			continue
+		}
+		loading := make(chan struct{})
+		b.loading = loading
+		b.mu.Unlock()
+		seg, err := a.allocate(ctx, tag)
+		b.mu.Lock()
+		b.loading = nil
+		close(loading)
+		if err != nil {
+			b.mu.Unlock()
+			return 0, err
+		}
+		b.next = &seg
+	}
+}
+
+// maybePreload 需持有 b.mu
+func (a *Allocator) maybePreload(tag string, b *buffer) {
+	if b.next != nil || b.loading != nil {
+		return
+	}
+	size := b.current.Len()
+	if float64(b.pos-b.current.Start) < float64(size)*a.preloadRatio {
+		return
+	}
+	loading := make(chan struct{})
+	b.loading = loading
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), a.loadTimeout)
+		defer cancel()
+		seg, err := a.allocate(ctx, tag)
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.loading = nil
+		close(loading)
+		if err != nil {
+			if a.onLoadError != nil {
+				a.onLoadError(tag, err)
+			}
+			return
+		}
+		b.next = &seg
+	}()
+}
+
+func (a *Allocator) allocate(ctx context.Context, tag string) (Segment, error) {
+	seg, err := a.store.Allocate(ctx, tag, a.step)
+	if err != nil {
+		return Segment{}, err
+	}
+	if seg.Len() <= 0 {
+		return Segment{}, ErrInvalidSegment
+	}
+	return seg, nil
+}
@@ -0,0 +1,119 @@
+package segment_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/idgen/segment"
+)
+
+type countingStore struct {
+	segment.Store
+	calls atomic.Int64
+	delay time.Duration
+	fail  atomic.Bool
+}
+
+var errUnavailable = errors.New("store unavailable")
+
+func (s *countingStore) Allocate(ctx context.Context, tag string, step int64) (segment.Segment, error) {
+	s.calls.Add(1)
+	time.Sleep(s.delay)
+	if s.fail.Load() {
+		return segment.Segment{}, errUnavailable
+	}
+	return s.Store.Allocate(ctx, tag, step)
+}
+
+func TestAllocator(t *testing.T) {
+	store := &countingStore{Store: segment.NewMemoryStore()}
+	a := segment.NewAllocator(store, segment.WithStep(10))
+	ctx := context.Background()
+
+	for i := int64(1); i <= 100; i++ {
+		id, err := a.Next(ctx, "order")
+		require.NoError(t, err)
+		assert.Equal(t, i, id)
+	}
+	id, err := a.Next(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	// 预加载下一号段, 不会多取
+	assert.LessOrEqual(t, store.calls.Load(), int64(12))
+}
+
+func TestAllocator_Concurrent(t *testing.T) {
+	store := &countingStore{Store: segment.NewMemoryStore(), delay: time.Millisecond}
+	a := segment.NewAllocator(store, segment.WithStep(50), segment.WithPreloadRatio(0.5))
+
+	const workers, n = 8, 500
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ids = make(map[int64]bool)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := int64(0)
+			for i := 0; i < n; i++ {
+				id, err := a.Next(context.Background(), "order")
+				assert.NoError(t, err)
+				assert.Greater(t, id, last)
+				last = id
+				mu.Lock()
+				ids[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, ids, workers*n)
+	// 号段稠密, 未使用的 ID 不超过两个号段
+	max := int64(0)
+	for id := range ids {
+		if id > max {
+			max = id
+		}
+	}
+	assert.LessOrEqual(t, max, int64(workers*n+100))
+}
+
+func TestAllocator_LoadError(t *testing.T) {
+	store := &countingStore{Store: segment.NewMemoryStore()}
+	var loadErr atomic.Value
+	a := segment.NewAllocator(store, segment.WithStep(2), segment.WithPreloadRatio(0.5),
+		segment.WithLoadErrorHandler(func(tag string, err error) { loadErr.Store(err) }))
+	ctx := context.Background()
+
+	store.fail.Store(true)
+	_, err := a.Next(ctx, "order")
+	assert.Equal(t, errUnavailable, err)
+
+	store.fail.Store(false)
+	id, err := a.Next(ctx, "order")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	// 等待后台预加载完成后让存储失败, 已加载的号段仍可使用
+	time.Sleep(10 * time.Millisecond)
+	store.fail.Store(true)
+	for i := int64(2); i <= 4; i++ {
+		id, err = a.Next(ctx, "order")
+		require.NoError(t, err)
+		assert.Equal(t, i, id)
+	}
+	_, err = a.Next(ctx, "order")
+	assert.Equal(t, errUnavailable, err)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, errUnavailable, loadErr.Load())
+}
@@ -0,0 +1,12 @@
+package segment_test
+
+import (
+	"testing"
+
+	"github.com/zmicro-team/ztlib/idgen/segment"
+	"github.com/zmicro-team/ztlib/idgen/segment/tests"
+)
+
+func TestMemoryStore(t *testing.T) {
+	tests.TestStore(t, segment.NewMemoryStore())
+}
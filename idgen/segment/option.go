@@ -0,0 +1,40 @@
+package segment
+
+import "time"
+
+// Option 分配器配置
+type Option func(*Allocator)
+
+// WithStep 每次从存储分配的号段长度, 默认 1000
+func WithStep(step int64) Option {
+	return func(a *Allocator) {
+		if step > 0 {
+			a.step = step
+		}
+	}
+}
+
+// WithPreloadRatio 当前号段已使用的比例达到 ratio 时在后台预加载下一号段, 默认 0.1
+func WithPreloadRatio(ratio float64) Option {
+	return func(a *Allocator) {
+		if ratio >= 0 && ratio <= 1 {
+			a.preloadRatio = ratio
+		}
+	}
+}
+
+// WithLoadTimeout 后台预加载的超时时间, 默认 3 秒
+func WithLoadTimeout(d time.Duration) Option {
+	return func(a *Allocator) {
+		if d > 0 {
+			a.loadTimeout = d
+		}
+	}
+}
+
+// WithLoadErrorHandler 后台预加载失败时回调, 号段用尽时会再同步加载一次
+func WithLoadErrorHandler(f func(tag string, err error)) Option {
+	return func(a *Allocator) {
+		a.onLoadError = f
+	}
+}
@@ -0,0 +1,21 @@
+package v8
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zmicro-team/ztlib/idgen/segment/tests"
+)
+
+func TestRedisStore(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.Nil(t, err)
+
+	defer mr.Close()
+	tests.TestStore(t, NewRedisStore(
+		redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+	))
+}
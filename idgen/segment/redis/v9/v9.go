@@ -0,0 +1,37 @@
+package v9
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/zmicro-team/ztlib/idgen/segment"
+)
+
+var _ segment.Store = (*RedisStore)(nil)
+
+const defaultKeyPrefix = "idgen:segment:"
+
+// RedisStore redis 号段存储, 每个 tag 一个计数器, 通过 INCRBY 分配
+type RedisStore struct {
+	store     *redis.Client
+	keyPrefix string
+}
+
+// NewRedisStore new redis store instance.
+func NewRedisStore(store *redis.Client, keyPrefix ...string) *RedisStore {
+	prefix := defaultKeyPrefix
+	if len(keyPrefix) > 0 && keyPrefix[0] != "" {
+		prefix = keyPrefix[0]
+	}
+	return &RedisStore{store: store, keyPrefix: prefix}
+}
+
+// Allocate 为 tag 分配 step 个连续 ID
+func (r *RedisStore) Allocate(ctx context.Context, tag string, step int64) (segment.Segment, error) {
+	max, err := r.store.IncrBy(ctx, r.keyPrefix+tag, step).Result()
+	if err != nil {
+		return segment.Segment{}, err
+	}
+	return segment.Segment{Start: max - step + 1, End: max + 1}, nil
+}
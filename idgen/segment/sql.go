@@ -0,0 +1,104 @@
+package segment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+var _ Store = (*SQLStore)(nil)
+
+// SQLStore 基于 database/sql 的号段存储, 表结构如 (MySQL):
+//
+//	CREATE TABLE id_segment (
+//	  biz_tag VARCHAR(128) NOT NULL PRIMARY KEY,
+//	  max_id  BIGINT       NOT NULL DEFAULT 0
+//	);
+//
+// 不存在的 tag 会自动插入.
+type SQLStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+}
+
+// SQLOption SQLStore 配置
+type SQLOption func(*SQLStore)
+
+// WithTable 表名, 默认 id_segment
+func WithTable(table string) SQLOption {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// WithDollarPlaceholder 使用 $1, $2 形式的占位符, 如 PostgreSQL
+func WithDollarPlaceholder() SQLOption {
+	return func(s *SQLStore) {
+		s.placeholder = func(n int) string { return "$" + strconv.Itoa(n) }
+	}
+}
+
+func NewSQLStore(db *sql.DB, opts ...SQLOption) *SQLStore {
+	s := &SQLStore{
+		db:          db,
+		table:       "id_segment",
+		placeholder: func(int) string { return "?" },
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *SQLStore) Allocate(ctx context.Context, tag string, step int64) (Segment, error) {
+	seg, err := s.allocate(ctx, tag, step)
+	if err != nil {
+		// 并发插入同一个新 tag 时主键冲突, 重试一次
+		seg, err = s.allocate(ctx, tag, step)
+	}
+	return seg, err
+}
+
+func (s *SQLStore) allocate(ctx context.Context, tag string, step int64) (seg Segment, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return seg, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	p1, p2 := s.placeholder(1), s.placeholder(2)
+	res, err := tx.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s SET max_id = max_id + %s WHERE biz_tag = %s", s.table, p1, p2),
+		step, tag)
+	if err != nil {
+		return seg, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return seg, err
+	}
+	if n == 0 {
+		if _, err = tx.ExecContext(ctx,
+			fmt.Sprintf("INSERT INTO %s (biz_tag, max_id) VALUES (%s, %s)", s.table, p1, p2),
+			tag, step); err != nil {
+			return seg, err
+		}
+	}
+
+	var max int64
+	if err = tx.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT max_id FROM %s WHERE biz_tag = %s", s.table, p1),
+		tag).Scan(&max); err != nil {
+		return seg, err
+	}
+	if err = tx.Commit(); err != nil {
+		return seg, err
+	}
+	return Segment{Start: max - step + 1, End: max + 1}, nil
+}
@@ -0,0 +1,107 @@
+package segment_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/idgen/segment"
+	"github.com/zmicro-team/ztlib/idgen/segment/tests"
+)
+
+// fakeDriver 只支持 SQLStore 用到的三条语句, 事务之间串行执行
+type fakeDriver struct {
+	mu     sync.Mutex
+	txLock sync.Mutex
+	rows   map[string]int64
+	sqls   []string
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return d.Open("") }
+func (d *fakeDriver) Driver() driver.Driver                        { return d }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.d.txLock.Lock()
+	return c, nil
+}
+func (c *fakeConn) Commit() error   { c.d.txLock.Unlock(); return nil }
+func (c *fakeConn) Rollback() error { c.d.txLock.Unlock(); return nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	d := c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sqls = append(d.sqls, query)
+	switch {
+	case strings.HasPrefix(query, "UPDATE"):
+		tag := args[1].Value.(string)
+		if _, ok := d.rows[tag]; !ok {
+			return driver.RowsAffected(0), nil
+		}
+		d.rows[tag] += args[0].Value.(int64)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, "INSERT"):
+		tag := args[0].Value.(string)
+		if _, ok := d.rows[tag]; ok {
+			return nil, errors.New("duplicate key")
+		}
+		d.rows[tag] = args[1].Value.(int64)
+		return driver.RowsAffected(1), nil
+	}
+	return nil, errors.New("unexpected query: " + query)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	d := c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sqls = append(d.sqls, query)
+	max, ok := d.rows[args[0].Value.(string)]
+	if !ok {
+		return &fakeRows{}, nil
+	}
+	return &fakeRows{values: []int64{max}}, nil
+}
+
+type fakeRows struct{ values []int64 }
+
+func (r *fakeRows) Columns() []string { return []string{"max_id"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func TestSQLStore(t *testing.T) {
+	d := &fakeDriver{rows: make(map[string]int64)}
+	db := sql.OpenDB(d)
+	defer db.Close()
+
+	tests.TestStore(t, segment.NewSQLStore(db))
+
+	d.sqls = nil
+	_, err := segment.NewSQLStore(db, segment.WithTable("seq"), segment.WithDollarPlaceholder()).
+		Allocate(context.Background(), "order", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"UPDATE seq SET max_id = max_id + $1 WHERE biz_tag = $2",
+		"SELECT max_id FROM seq WHERE biz_tag = $1",
+	}, d.sqls)
+}
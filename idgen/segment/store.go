@@ -0,0 +1,46 @@
+// Package segment 号段模式 (leaf-segment) 的 ID 分配器, 按业务 tag 生成稠密且严格递增的 ID
+package segment
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrInvalidSegment = errors.New("segment: invalid segment")
+
+// Segment 号段 [Start, End)
+type Segment struct {
+	Start int64
+	End   int64
+}
+
+// Len 号段内的 ID 数量
+func (s Segment) Len() int64 {
+	return s.End - s.Start
+}
+
+// Store 号段存储
+type Store interface {
+	// Allocate 为 tag 分配 step 个连续 ID, 同一 tag 的号段严格递增, ID 从 1 开始
+	Allocate(ctx context.Context, tag string, step int64) (Segment, error)
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore 内存号段存储, 仅用于单实例或测试
+type MemoryStore struct {
+	mu  sync.Mutex
+	max map[string]int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{max: make(map[string]int64)}
+}
+
+func (m *MemoryStore) Allocate(_ context.Context, tag string, step int64) (Segment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.max[tag] += step
+	return Segment{Start: m.max[tag] - step + 1, End: m.max[tag] + 1}, nil
+}
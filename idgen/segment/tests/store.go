@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/idgen/segment"
+)
+
+// TestStore 号段存储的通用测试
+func TestStore(t *testing.T, store segment.Store) {
+	ctx := context.Background()
+
+	seg, err := store.Allocate(ctx, "order", 10)
+	require.NoError(t, err)
+	assert.Equal(t, segment.Segment{Start: 1, End: 11}, seg)
+
+	seg, err = store.Allocate(ctx, "order", 5)
+	require.NoError(t, err)
+	assert.Equal(t, segment.Segment{Start: 11, End: 16}, seg)
+
+	// tag 之间互不影响
+	seg, err = store.Allocate(ctx, "user", 10)
+	require.NoError(t, err)
+	assert.Equal(t, segment.Segment{Start: 1, End: 11}, seg)
+
+	// 并发分配的号段不重叠
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		segs []segment.Segment
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			seg, err := store.Allocate(ctx, "concurrent", 100)
+			assert.NoError(t, err)
+			mu.Lock()
+			segs = append(segs, seg)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	seen := make(map[int64]bool)
+	for _, seg := range segs {
+		assert.Equal(t, int64(100), seg.Len())
+		assert.False(t, seen[seg.Start])
+		seen[seg.Start] = true
+		assert.Equal(t, int64(0), (seg.Start-1)%100)
+	}
+	assert.Len(t, seen, 10)
+}
@@ -0,0 +1,68 @@
+package snowflake
+
+import "testing"
+
+func newBenchSnowflake(b *testing.B) *Snowflake {
+	sf, err := NewSnowflake(Settings{MachineID: fixedMachineID(1), Layout: TwitterLayout})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return sf
+}
+
+func BenchmarkNext(b *testing.B) {
+	sf := newBenchSnowflake(b)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = sf.Next()
+		}
+	})
+}
+
+func BenchmarkSharded(b *testing.B) {
+	s, err := NewSharded(Settings{MachineID: fixedMachineID(1), Layout: TwitterLayout}, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = s.Next()
+		}
+	})
+}
+
+// 批量 100 个: 逐个调用 Next 与 NextN 对比
+func BenchmarkBatch100_Next(b *testing.B) {
+	sf := newBenchSnowflake(b)
+	b.RunParallel(func(pb *testing.PB) {
+		ids := make([]int64, 0, 100)
+		for pb.Next() {
+			ids = ids[:0]
+			for i := 0; i < 100; i++ {
+				id, _ := sf.Next()
+				ids = append(ids, id)
+			}
+		}
+	})
+}
+
+func BenchmarkBatch100_NextN(b *testing.B) {
+	sf := newBenchSnowflake(b)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = sf.NextN(100)
+		}
+	})
+}
+
+func BenchmarkBatch100_ShardedNextN(b *testing.B) {
+	s, err := NewSharded(Settings{MachineID: fixedMachineID(1), Layout: TwitterLayout}, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = s.NextN(100)
+		}
+	})
+}
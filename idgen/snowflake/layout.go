@@ -0,0 +1,98 @@
+package snowflake
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidLayout = errors.New("snowflake: invalid layout")
+
+// Layout ID 的位布局, 从高到低依次为 时间 | 机器 ID | 序号, SequenceFirst 时为 时间 | 序号 | 机器 ID
+type Layout struct {
+	// TimeUnit 时间精度, 如 time.Millisecond, 10 * time.Millisecond, time.Second
+	TimeUnit time.Duration
+	// TimeBits 时间位数
+	TimeBits int
+	// MachineBits 机器 ID 位数, 最多 16 位
+	MachineBits int
+	// SequenceBits 序号位数, 最多 16 位
+	SequenceBits int
+	// SequenceFirst 序号放在机器 ID 之前
+	SequenceFirst bool
+	// Epoch 布局默认的 epoch, Settings.StartTime 为空时使用
+	Epoch time.Time
+}
+
+var (
+	// DefaultLayout 默认布局, 秒级, 32 位时间, 5 位机器, 16 位序号
+	DefaultLayout = Layout{TimeUnit: time.Second, TimeBits: BitLenTime, MachineBits: BitLenMachineID, SequenceBits: BitLenSequence}
+	// SonyflakeLayout 与 sonyflake 一致, 10ms, 39 位时间 | 8 位序号 | 16 位机器, epoch 为 2014-09-01 UTC
+	SonyflakeLayout = Layout{
+		TimeUnit: 10 * time.Millisecond, TimeBits: 39, MachineBits: 16, SequenceBits: 8,
+		SequenceFirst: true, Epoch: time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC),
+	}
+	// TwitterLayout 经典的 twitter snowflake, 毫秒, 41 位时间, 10 位机器, 12 位序号
+	TwitterLayout = Layout{TimeUnit: time.Millisecond, TimeBits: 41, MachineBits: 10, SequenceBits: 12}
+)
+
+// Validate 校验布局, 总位数不能超过 63 位
+func (l Layout) Validate() error {
+	if l.TimeUnit < time.Millisecond || l.TimeUnit%time.Millisecond != 0 ||
+		l.TimeBits <= 0 ||
+		l.MachineBits <= 0 || l.MachineBits > 16 ||
+		l.SequenceBits <= 0 || l.SequenceBits > 16 ||
+		l.TimeBits+l.MachineBits+l.SequenceBits > 63 {
+		return ErrInvalidLayout
+	}
+	return nil
+}
+
+// Lifetime 从 epoch 起可用的时长
+func (l Layout) Lifetime() time.Duration {
+	if l.TimeBits >= 63 || int64(1)<<l.TimeBits > int64(1<<63-1)/int64(l.TimeUnit) {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(int64(1)<<l.TimeBits) * l.TimeUnit
+}
+
+func (l Layout) maxMachine() uint16  { return uint16(1<<l.MachineBits - 1) }
+func (l Layout) maxSequence() uint16 { return uint16(1<<l.SequenceBits - 1) }
+func (l Layout) maxElapsed() int64   { return int64(1)<<l.TimeBits - 1 }
+
+// shifts 机器 ID 与序号的偏移
+func (l Layout) shifts() (machine, sequence int) {
+	if l.SequenceFirst {
+		return 0, l.MachineBits
+	}
+	return l.SequenceBits, 0
+}
+
+func (l Layout) compose(elapsed int64, machine, sequence uint16) int64 {
+	ms, ss := l.shifts()
+	return elapsed<<(l.MachineBits+l.SequenceBits) |
+		int64(machine)<<ms |
+		int64(sequence)<<ss
+}
+
+// sequenceStep 序号加 1 时 ID 的增量
+func (l Layout) sequenceStep() int64 {
+	_, ss := l.shifts()
+	return int64(1) << ss
+}
+
+func (l Layout) decompose(id int64) (elapsed int64, machine, sequence uint16) {
+	ms, ss := l.shifts()
+	return id >> (l.MachineBits + l.SequenceBits),
+		uint16(id>>ms) & l.maxMachine(),
+		uint16(id>>ss) & l.maxSequence()
+}
+
+// Parts 分解后的 ID
+type Parts struct {
+	Time     time.Time
+	Elapsed  int64
+	Machine  uint16
+	Sequence uint16
+	// Generation 时钟回拨代数, 见 RollbackBorrow
+	Generation uint16
+}
@@ -0,0 +1,99 @@
+package snowflake
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var ErrClockRollback = errors.New("snowflake: clock moved backwards")
+
+// Clock 时钟, 测试时可替换
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// SystemClock 系统时钟
+var SystemClock Clock = systemClock{}
+
+// RollbackStrategy 时钟回拨的处理策略
+type RollbackStrategy int
+
+const (
+	// RollbackWait 等待时钟追上, 回拨超过 MaxWait 时返回 ErrClockRollback
+	RollbackWait RollbackStrategy = iota
+	// RollbackBorrow 使用序号高 ReservedBits 位作为回拨代数, 每次回拨选用在回拨后的时间
+	// 从未使用过的最小代数, 以回拨后的时间继续生成; 时钟超过历史最大时间后代数归零,
+	// 没有可用代数时返回 ErrClockRollback.
+	// 此策略下 ID 不再保证单调递增.
+	RollbackBorrow
+	// RollbackError 直接返回 ErrClockRollback
+	RollbackError
+)
+
+func (s RollbackStrategy) String() string {
+	switch s {
+	case RollbackWait:
+		return "wait"
+	case RollbackBorrow:
+		return "borrow"
+	case RollbackError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// RollbackEvent 一次时钟回拨
+type RollbackEvent struct {
+	// Offset 回拨的时长
+	Offset   time.Duration
+	Strategy RollbackStrategy
+	// Err 为 nil 表示已处理
+	Err error
+}
+
+// RollbackPolicy 时钟回拨策略
+type RollbackPolicy struct {
+	Strategy RollbackStrategy
+	// MaxWait RollbackWait 最长等待时间, 默认 1 秒
+	MaxWait time.Duration
+	// ReservedBits RollbackBorrow 从序号中预留的位数
+	ReservedBits int
+	// OnRollback 发生回拨时回调, 在生成 ID 的锁内调用, 不应阻塞
+	OnRollback func(RollbackEvent)
+}
+
+// Stats 时钟回拨统计
+type Stats struct {
+	// Rollbacks 检测到回拨的次数
+	Rollbacks uint64
+	// Waited 因回拨累计等待的时长
+	Waited time.Duration
+	// Borrowed 使用预留序号的次数
+	Borrowed uint64
+	// Failed 返回 ErrClockRollback 的次数
+	Failed uint64
+}
+
+type stats struct {
+	rollbacks atomic.Uint64
+	waited    atomic.Int64
+	borrowed  atomic.Uint64
+	failed    atomic.Uint64
+}
+
+func (s *stats) load() Stats {
+	return Stats{
+		Rollbacks: s.rollbacks.Load(),
+		Waited:    time.Duration(s.waited.Load()),
+		Borrowed:  s.borrowed.Load(),
+		Failed:    s.failed.Load(),
+	}
+}
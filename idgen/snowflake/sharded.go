@@ -0,0 +1,87 @@
+package snowflake
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Sharded 分片生成器, 每个分片是独立的 Snowflake, 机器 ID 为 machineID<<shardBits | 分片序号.
+// 通过 sync.Pool 的 per-P 缓存让 goroutine 尽量使用所在 P 的分片, 减少锁竞争, 适合批量写入.
+// 不同分片之间的 ID 不保证单调递增.
+type Sharded struct {
+	shards []*Snowflake
+	pool   sync.Pool
+	rr     atomic.Uint32
+}
+
+// NewSharded shardBits 为子机器位数, 占用 Layout.MachineBits 中的低位; 为 0 时按 GOMAXPROCS 取值,
+// 小于 0 或超过 16 时返回 ErrInvalidLayout
+func NewSharded(st Settings, shardBits int) (*Sharded, error) {
+	if shardBits < 0 || shardBits > 16 {
+		return nil, ErrInvalidLayout
+	}
+	if shardBits == 0 {
+		for 1<<shardBits < runtime.GOMAXPROCS(0) {
+			shardBits++
+		}
+	}
+	if st.MachineID == nil {
+		return nil, ErrNoMachineID
+	}
+	base, err := st.MachineID()
+	if err != nil {
+		return nil, err
+	}
+	if st.CheckMachineID != nil && !st.CheckMachineID(base) {
+		return nil, ErrInvalidMachineID
+	}
+
+	s := &Sharded{shards: make([]*Snowflake, 1<<shardBits)}
+	for i := range s.shards {
+		shardSt := st
+		id := uint32(base)<<shardBits | uint32(i)
+		if id > 0xFFFF {
+			return nil, ErrInvalidMachineID
+		}
+		shardSt.MachineID = func() (uint16, error) { return uint16(id), nil }
+		shardSt.CheckMachineID = nil
+		if s.shards[i], err = NewSnowflake(shardSt); err != nil {
+			return nil, err
+		}
+	}
+	s.pool.New = func() any {
+		return s.shards[s.rr.Add(1)%uint32(len(s.shards))]
+	}
+	return s, nil
+}
+
+func (s *Sharded) acquire() *Snowflake {
+	return s.pool.Get().(*Snowflake)
+}
+
+// Next 生成 ID
+func (s *Sharded) Next() (int64, error) {
+	sf := s.acquire()
+	defer s.pool.Put(sf)
+	return sf.Next()
+}
+
+// NextId 同 Next, 出错时 panic
+func (s *Sharded) NextId() int64 {
+	sf := s.acquire()
+	defer s.pool.Put(sf)
+	return sf.NextId()
+}
+
+// NextN 从一个分片批量生成
+func (s *Sharded) NextN(n int) ([]int64, error) {
+	sf := s.acquire()
+	defer s.pool.Put(sf)
+	return sf.NextN(n)
+}
+
+// Shards 分片数
+func (s *Sharded) Shards() int {
+	return len(s.shards)
+}
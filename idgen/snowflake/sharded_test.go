@@ -0,0 +1,107 @@
+package snowflake
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixedMachineID(id uint16) func() (uint16, error) {
+	return func() (uint16, error) { return id, nil }
+}
+
+func TestNextN(t *testing.T) {
+	sf, err := NewSnowflake(Settings{MachineID: fixedMachineID(1), Layout: TwitterLayout})
+	require.NoError(t, err)
+
+	ids, err := sf.NextN(10000)
+	require.NoError(t, err)
+	assert.Len(t, ids, 10000)
+	for i := 1; i < len(ids); i++ {
+		assert.Greater(t, ids[i], ids[i-1])
+	}
+	next, err := sf.Next()
+	require.NoError(t, err)
+	assert.Greater(t, next, ids[len(ids)-1])
+
+	ids, err = sf.NextN(0)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestNewSharded(t *testing.T) {
+	s, err := NewSharded(Settings{MachineID: fixedMachineID(3), Layout: TwitterLayout}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, s.Shards())
+	for i, shard := range s.shards {
+		assert.Equal(t, uint16(3<<2|i), shard.machineID)
+	}
+
+	// 默认布局只有 5 位机器 ID
+	_, err = NewSharded(Settings{MachineID: fixedMachineID(8)}, 2)
+	assert.Equal(t, ErrInvalidMachineID, err)
+
+	s, err = NewSharded(Settings{MachineID: fixedMachineID(1), Layout: TwitterLayout}, 0)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, s.Shards(), 1)
+
+	for _, bits := range []int{-1, 17} {
+		_, err = NewSharded(Settings{MachineID: fixedMachineID(1), Layout: TwitterLayout}, bits)
+		assert.Equal(t, ErrInvalidLayout, err, bits)
+	}
+}
+
+// 使用 -race 运行
+func TestUniqueUnderConcurrency(t *testing.T) {
+	sf, err := NewSnowflake(Settings{MachineID: fixedMachineID(1), Layout: TwitterLayout})
+	require.NoError(t, err)
+	sharded, err := NewSharded(Settings{MachineID: fixedMachineID(2), Layout: TwitterLayout}, 3)
+	require.NoError(t, err)
+
+	generators := map[string]func() ([]int64, error){
+		"next": func() ([]int64, error) {
+			id, err := sf.Next()
+			return []int64{id}, err
+		},
+		"nextN": func() ([]int64, error) { return sf.NextN(37) },
+		"sharded": func() ([]int64, error) {
+			id, err := sharded.Next()
+			return []int64{id}, err
+		},
+		"shardedN": func() ([]int64, error) { return sharded.NextN(37) },
+	}
+
+	const workers, rounds = 16, 500
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		all = make(map[int64]struct{})
+		n   int
+	)
+	for name, gen := range generators {
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(name string, gen func() ([]int64, error)) {
+				defer wg.Done()
+				local := make([]int64, 0, rounds*37)
+				for i := 0; i < rounds; i++ {
+					ids, err := gen()
+					if !assert.NoError(t, err, name) {
+						return
+					}
+					local = append(local, ids...)
+				}
+				mu.Lock()
+				for _, id := range local {
+					all[id] = struct{}{}
+				}
+				n += len(local)
+				mu.Unlock()
+			}(name, gen)
+		}
+	}
+	wg.Wait()
+	assert.Equal(t, n, len(all))
+}
@@ -1,106 +1,292 @@
 package snowflake
 
 import (
-	"log"
+	"errors"
 	"math/rand"
+	"slices"
 	"sync"
 	"time"
 )
 
-// These constants are the bit lengths of Snowflake ID parts.
+// These constants are the bit lengths of Snowflake ID parts in DefaultLayout.
 const (
 	BitLenTime      = 32 // bit length of time
 	BitLenMachineID = 5  // bit length of machine id
 	BitLenSequence  = 16 // bit length of sequence number
 )
 
+var (
+	ErrStartTimeAhead   = errors.New("snowflake: start time is ahead of now")
+	ErrNoMachineID      = errors.New("snowflake: machine id provider is required")
+	ErrInvalidMachineID = errors.New("snowflake: invalid machine id")
+	ErrTimeOverflow     = errors.New("snowflake: over the time limit")
+)
+
 type Settings struct {
+	// StartTime epoch, 默认为 Layout.Epoch, 未设置时为 2021-01-01 UTC
 	StartTime      time.Time
 	MachineID      func() (uint16, error)
 	CheckMachineID func(uint16) bool
+	// Layout 位布局, 零值为 DefaultLayout
+	Layout Layout
+	// Clock 默认 SystemClock
+	Clock Clock
+	// Rollback 时钟回拨策略, 默认等待最多 1 秒
+	Rollback RollbackPolicy
 }
 
 // Snowflake is a distributed unique ID generator.
 type Snowflake struct {
 	mutex       *sync.Mutex
+	layout      Layout
+	clock       Clock
+	rollback    RollbackPolicy
+	epoch       time.Time
 	startTime   int64
 	elapsedTime int64
 	sequence    uint16
 	machineID   uint16
+
+	// observed 最近一次读取的时钟, 用于检测回拨
+	observed int64
+	// highwater 已使用过的最大时间
+	highwater int64
+	// generation 回拨代数, 占序号的高 rollback.ReservedBits 位
+	generation uint16
+	// used 各代数已使用过的最大时间, 回拨时只能选用之后的时间从未使用过的代数
+	used  []int64
+	stats stats
 }
 
-func NewSnowflake(st Settings) *Snowflake {
+func NewSnowflake(st Settings) (*Snowflake, error) {
 	sf := new(Snowflake)
 	sf.mutex = new(sync.Mutex)
-	sf.sequence = uint16(1<<BitLenSequence - 1)
 
-	if st.StartTime.After(time.Now()) {
-		return nil
+	sf.layout = st.Layout
+	if sf.layout == (Layout{}) {
+		sf.layout = DefaultLayout
+	}
+	if err := sf.layout.Validate(); err != nil {
+		return nil, err
 	}
-	if st.StartTime.IsZero() {
-		sf.startTime = toSnowflakeTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
-	} else {
-		sf.startTime = toSnowflakeTime(st.StartTime)
+	sf.clock = st.Clock
+	if sf.clock == nil {
+		sf.clock = SystemClock
+	}
+	sf.rollback = st.Rollback
+	if sf.rollback.MaxWait == 0 {
+		sf.rollback.MaxWait = time.Second
+	}
+	if sf.rollback.ReservedBits < 0 || sf.rollback.ReservedBits >= sf.layout.SequenceBits ||
+		(sf.rollback.Strategy == RollbackBorrow && sf.rollback.ReservedBits == 0) {
+		return nil, ErrInvalidLayout
+	}
+	sf.sequence = sf.maskSequence()
+	if sf.rollback.Strategy == RollbackBorrow {
+		sf.used = make([]int64, 1<<sf.rollback.ReservedBits)
+		for i := range sf.used {
+			sf.used[i] = -1
+		}
+	}
+
+	if st.StartTime.After(sf.clock.Now()) {
+		return nil, ErrStartTimeAhead
+	}
+	sf.epoch = st.StartTime
+	if sf.epoch.IsZero() {
+		sf.epoch = sf.layout.Epoch
+	}
+	if sf.epoch.IsZero() {
+		sf.epoch = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	sf.startTime = sf.toSnowflakeTime(sf.epoch)
+	sf.observed = sf.currentElapsedTime()
+	if sf.observed > sf.layout.maxElapsed() {
+		return nil, ErrTimeOverflow
 	}
 
-	var err error
 	if st.MachineID == nil {
-		//sf.machineID, err = lower16BitPrivateIP()
-		return nil
-	} else {
-		sf.machineID, err = st.MachineID()
+		return nil, ErrNoMachineID
+	}
+	var err error
+	sf.machineID, err = st.MachineID()
+	if err != nil {
+		return nil, err
 	}
-	if err != nil || (st.CheckMachineID != nil && !st.CheckMachineID(sf.machineID)) {
-		return nil
+	if sf.machineID > sf.layout.maxMachine() ||
+		(st.CheckMachineID != nil && !st.CheckMachineID(sf.machineID)) {
+		return nil, ErrInvalidMachineID
 	}
 
-	return sf
+	return sf, nil
 }
 
+// NextId 同 Next, 超出时间范围时 panic
 func (sf *Snowflake) NextId() int64 {
-	const maskSequence = uint16(1<<BitLenSequence - 1)
+	id, err := sf.Next()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
 
+// Next 生成 ID, 超出布局的时间范围时返回 ErrTimeOverflow, 无法处理时钟回拨时返回 ErrClockRollback
+func (sf *Snowflake) Next() (int64, error) {
 	sf.mutex.Lock()
 	defer sf.mutex.Unlock()
+	return sf.next()
+}
+
+// NextN 一次加锁生成 n 个 ID, 同一时间单位内剩余的序号整块分配
+func (sf *Snowflake) NextN(n int) ([]int64, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	ids := make([]int64, 0, n)
+	maskSequence := sf.maskSequence()
+
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+	for len(ids) < n {
+		id, err := sf.next()
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+
+		// 当前时间单位剩余的序号是连续的, 无需再读时钟
+		block := min(int(maskSequence-sf.sequence), n-len(ids))
+		step := sf.layout.sequenceStep()
+		for i := 1; i <= block; i++ {
+			ids = append(ids, id+int64(i)*step)
+		}
+		sf.sequence += uint16(block)
+	}
+	return ids, nil
+}
+
+// next 需持有 mutex
+func (sf *Snowflake) next() (int64, error) {
+	maskSequence := sf.maskSequence()
+
+	current := sf.currentElapsedTime()
+	if current < sf.observed {
+		var err error
+		if current, err = sf.handleRollback(current); err != nil {
+			return 0, err
+		}
+	}
+	sf.observed = current
+	if current > sf.highwater {
+		// 之后的时间从未被任何代数使用过, 回拨代数可以归零
+		sf.generation = 0
+	}
 
-	current := currentElapsedTime(sf.startTime)
 	if sf.elapsedTime < current {
 		sf.elapsedTime = current
 		// 初始序号0-9之间，增加一定的随机性
-		sf.sequence = uint16(rand.Intn(10))
+		sf.sequence = uint16(rand.Intn(10)) & maskSequence
 	} else { // sf.elapsedTime >= current
 		sf.sequence = (sf.sequence + 1) & maskSequence
 		if sf.sequence == 0 {
 			sf.elapsedTime++
 			overtime := sf.elapsedTime - current
-			time.Sleep(sleepTime(overtime))
+			sf.clock.Sleep(sf.sleepTime(overtime))
 		}
 	}
 
-	return sf.toID()
+	if sf.elapsedTime > sf.layout.maxElapsed() {
+		return 0, ErrTimeOverflow
+	}
+	if sf.elapsedTime > sf.highwater {
+		sf.highwater = sf.elapsedTime
+	}
+	if sf.used != nil {
+		sf.used[sf.generation] = max(sf.used[sf.generation], sf.elapsedTime)
+	}
+	sequence := sf.generation<<(sf.layout.SequenceBits-sf.rollback.ReservedBits) | sf.sequence
+	return sf.layout.compose(sf.elapsedTime, sf.machineID, sequence), nil
 }
 
-const snowflakeTimeUnit = 1e9 // nsec, i.e. 1 sec
+// handleRollback 按策略处理回拨, 返回处理后的当前时间
+func (sf *Snowflake) handleRollback(current int64) (int64, error) {
+	offset := time.Duration(sf.observed-current) * sf.layout.TimeUnit
+	sf.stats.rollbacks.Add(1)
+
+	var err error
+	switch sf.rollback.Strategy {
+	case RollbackWait:
+		if offset > sf.rollback.MaxWait {
+			err = ErrClockRollback
+			break
+		}
+		start := sf.clock.Now()
+		for current < sf.observed {
+			sf.clock.Sleep(sf.sleepTime(sf.observed - current))
+			current = sf.currentElapsedTime()
+		}
+		sf.stats.waited.Add(int64(sf.clock.Now().Sub(start)))
+	case RollbackBorrow:
+		// 代数在 current 及之后从未使用过才不会与已生成的 ID 重复
+		gen := slices.IndexFunc(sf.used, func(t int64) bool { return t < current })
+		if gen < 0 {
+			err = ErrClockRollback
+			break
+		}
+		sf.generation = uint16(gen)
+		// 以回拨后的时间重新开始
+		sf.elapsedTime = current - 1
+		sf.stats.borrowed.Add(1)
+	default:
+		err = ErrClockRollback
+	}
 
-func toSnowflakeTime(t time.Time) int64 {
-	return t.UTC().UnixNano() / snowflakeTimeUnit
+	if err != nil {
+		sf.stats.failed.Add(1)
+	}
+	if sf.rollback.OnRollback != nil {
+		sf.rollback.OnRollback(RollbackEvent{Offset: offset, Strategy: sf.rollback.Strategy, Err: err})
+	}
+	return current, err
 }
 
-func currentElapsedTime(startTime int64) int64 {
-	return toSnowflakeTime(time.Now()) - startTime
+// Stats 时钟回拨统计
+func (sf *Snowflake) Stats() Stats {
+	return sf.stats.load()
 }
 
-func sleepTime(overtime int64) time.Duration {
-	return time.Duration(overtime)*snowflakeTimeUnit*time.Nanosecond -
-		time.Duration(time.Now().UTC().UnixNano()%snowflakeTimeUnit)*time.Nanosecond
+func (sf *Snowflake) maskSequence() uint16 {
+	return uint16(1<<(sf.layout.SequenceBits-sf.rollback.ReservedBits) - 1)
 }
 
-func (sf *Snowflake) toID() int64 {
-	if sf.elapsedTime >= 1<<BitLenTime {
-		log.Panic("over the time limit")
+// Decompose 按当前的布局和 epoch 分解 ID
+func (sf *Snowflake) Decompose(id int64) Parts {
+	l := sf.layout
+	elapsed, machine, sequence := l.decompose(id)
+	return Parts{
+		Time:     time.Unix(0, (sf.startTime+elapsed)*int64(l.TimeUnit)),
+		Elapsed:  elapsed,
+		Machine:  machine,
+		Sequence: sequence & sf.maskSequence(),
+		// 未预留时为 0
+		Generation: sequence >> (l.SequenceBits - sf.rollback.ReservedBits),
 	}
+}
+
+// Layout 当前的位布局
+func (sf *Snowflake) Layout() Layout {
+	return sf.layout
+}
+
+func (sf *Snowflake) toSnowflakeTime(t time.Time) int64 {
+	return t.UTC().UnixNano() / int64(sf.layout.TimeUnit)
+}
+
+func (sf *Snowflake) currentElapsedTime() int64 {
+	return sf.toSnowflakeTime(sf.clock.Now()) - sf.startTime
+}
 
-	return sf.elapsedTime<<(BitLenSequence+BitLenMachineID) |
-		int64(sf.machineID)<<BitLenSequence |
-		int64(sf.sequence)
+func (sf *Snowflake) sleepTime(overtime int64) time.Duration {
+	unit := int64(sf.layout.TimeUnit)
+	return time.Duration(overtime*unit - sf.clock.Now().UTC().UnixNano()%unit)
 }
@@ -0,0 +1,276 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSnowflake(t *testing.T) {
+	failed := errors.New("failed")
+	fixed := func(id uint16) func() (uint16, error) {
+		return func() (uint16, error) { return id, nil }
+	}
+	tests := []struct {
+		name    string
+		st      Settings
+		wantErr error
+	}{
+		{"ok", Settings{MachineID: fixed(1)}, nil},
+		{"start time ahead", Settings{StartTime: time.Now().Add(time.Hour), MachineID: fixed(1)}, ErrStartTimeAhead},
+		{"no machine id", Settings{}, ErrNoMachineID},
+		{"provider failed", Settings{MachineID: func() (uint16, error) { return 0, failed }}, failed},
+		{"machine id overflow", Settings{MachineID: fixed(1 << BitLenMachineID)}, ErrInvalidMachineID},
+		{"check failed", Settings{MachineID: fixed(2), CheckMachineID: func(id uint16) bool { return id == 1 }}, ErrInvalidMachineID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sf, err := NewSnowflake(tt.st)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantErr == nil, sf != nil)
+		})
+	}
+}
+
+func TestNextId(t *testing.T) {
+	sf, err := NewSnowflake(Settings{MachineID: func() (uint16, error) { return 3, nil }})
+	assert.NoError(t, err)
+
+	seen := make(map[int64]struct{})
+	last := int64(0)
+	for i := 0; i < 1000; i++ {
+		id := sf.NextId()
+		assert.Greater(t, id, last)
+		assert.Equal(t, int64(3), id>>BitLenSequence&(1<<BitLenMachineID-1))
+		seen[id] = struct{}{}
+		last = id
+	}
+	assert.Len(t, seen, 1000)
+}
+
+func TestLayout_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  Layout
+		wantErr error
+	}{
+		{"default", DefaultLayout, nil},
+		{"sonyflake", SonyflakeLayout, nil},
+		{"twitter", TwitterLayout, nil},
+		{"sub millisecond", Layout{TimeUnit: time.Microsecond, TimeBits: 41, MachineBits: 10, SequenceBits: 12}, ErrInvalidLayout},
+		{"too many bits", Layout{TimeUnit: time.Millisecond, TimeBits: 42, MachineBits: 10, SequenceBits: 12}, ErrInvalidLayout},
+		{"machine bits", Layout{TimeUnit: time.Millisecond, TimeBits: 30, MachineBits: 17, SequenceBits: 12}, ErrInvalidLayout},
+		{"zero sequence", Layout{TimeUnit: time.Millisecond, TimeBits: 41, MachineBits: 10}, ErrInvalidLayout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, tt.layout.Validate())
+		})
+	}
+	assert.Greater(t, TwitterLayout.Lifetime(), 69*365*24*time.Hour)
+}
+
+func TestDecompose(t *testing.T) {
+	for _, layout := range []Layout{DefaultLayout, SonyflakeLayout, TwitterLayout} {
+		sf, err := NewSnowflake(Settings{
+			StartTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			MachineID: func() (uint16, error) { return 7, nil },
+			Layout:    layout,
+		})
+		assert.NoError(t, err)
+
+		before := time.Now()
+		id, err := sf.Next()
+		assert.NoError(t, err)
+		parts := sf.Decompose(id)
+		assert.Equal(t, uint16(7), parts.Machine)
+		assert.Less(t, parts.Sequence, uint16(10))
+		assert.WithinDuration(t, before, parts.Time, layout.TimeUnit)
+		assert.Equal(t, id, layout.compose(parts.Elapsed, parts.Machine, parts.Sequence))
+	}
+}
+
+func TestSonyflakeLayout(t *testing.T) {
+	sf, err := NewSnowflake(Settings{
+		MachineID: func() (uint16, error) { return 0x1234, nil },
+		Layout:    SonyflakeLayout,
+	})
+	assert.NoError(t, err)
+	ids, err := sf.NextN(300)
+	assert.NoError(t, err)
+	id, err := sf.Next()
+	assert.NoError(t, err)
+	ids = append(ids, id)
+
+	// 与 sonyflake 的 time(39) | sequence(8) | machine(16) 一致, epoch 为 2014-09-01
+	elapsed := time.Since(time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)) / (10 * time.Millisecond)
+	for i, id := range ids {
+		assert.Equal(t, int64(0x1234), id&0xFFFF)
+		assert.InDelta(t, int64(elapsed), id>>24, 10)
+		parts := sf.Decompose(id)
+		assert.Equal(t, uint16(0x1234), parts.Machine)
+		assert.Equal(t, uint16(id>>16&0xFF), parts.Sequence)
+		if i > 0 {
+			assert.Greater(t, id, ids[i-1])
+		}
+	}
+}
+
+func TestTimeOverflow(t *testing.T) {
+	_, err := NewSnowflake(Settings{
+		StartTime: time.Now().Add(-time.Hour),
+		MachineID: func() (uint16, error) { return 1, nil },
+		Layout:    Layout{TimeUnit: time.Second, TimeBits: 8, MachineBits: 5, SequenceBits: 16},
+	})
+	assert.Equal(t, ErrTimeOverflow, err)
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+func newRollbackSnowflake(t *testing.T, clock *fakeClock, policy RollbackPolicy) *Snowflake {
+	sf, err := NewSnowflake(Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+		Layout:    TwitterLayout,
+		Clock:     clock,
+		Rollback:  policy,
+	})
+	assert.NoError(t, err)
+	return sf
+}
+
+func TestRollbackWait(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	var events []RollbackEvent
+	sf := newRollbackSnowflake(t, clock, RollbackPolicy{
+		MaxWait:    time.Second,
+		OnRollback: func(e RollbackEvent) { events = append(events, e) },
+	})
+
+	id1, err := sf.Next()
+	assert.NoError(t, err)
+
+	clock.now = clock.now.Add(-500 * time.Millisecond)
+	id2, err := sf.Next()
+	assert.NoError(t, err)
+	assert.Greater(t, id2, id1)
+	assert.Len(t, events, 1)
+	assert.Equal(t, 500*time.Millisecond, events[0].Offset)
+	assert.NoError(t, events[0].Err)
+
+	clock.now = clock.now.Add(-2 * time.Second)
+	_, err = sf.Next()
+	assert.Equal(t, ErrClockRollback, err)
+
+	stats := sf.Stats()
+	assert.Equal(t, uint64(2), stats.Rollbacks)
+	assert.Equal(t, uint64(1), stats.Failed)
+	assert.InDelta(t, 500*time.Millisecond, stats.Waited, float64(time.Millisecond))
+}
+
+func TestRollbackError(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	sf := newRollbackSnowflake(t, clock, RollbackPolicy{Strategy: RollbackError})
+
+	_, err := sf.Next()
+	assert.NoError(t, err)
+	clock.now = clock.now.Add(-time.Millisecond)
+	_, err = sf.Next()
+	assert.Equal(t, ErrClockRollback, err)
+
+	// 时钟恢复后继续生成
+	clock.now = clock.now.Add(2 * time.Millisecond)
+	_, err = sf.Next()
+	assert.NoError(t, err)
+}
+
+func TestRollbackBorrow(t *testing.T) {
+	_, err := NewSnowflake(Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+		Rollback:  RollbackPolicy{Strategy: RollbackBorrow},
+	})
+	assert.Equal(t, ErrInvalidLayout, err)
+
+	clock := &fakeClock{now: time.Now()}
+	sf := newRollbackSnowflake(t, clock, RollbackPolicy{Strategy: RollbackBorrow, ReservedBits: 2})
+
+	seen := make(map[int64]struct{})
+	next := func() (int64, error) {
+		id, err := sf.Next()
+		if err == nil {
+			_, dup := seen[id]
+			assert.False(t, dup)
+			seen[id] = struct{}{}
+		}
+		return id, err
+	}
+	generate := func(n int) {
+		for i := 0; i < n; i++ {
+			_, err := next()
+			assert.NoError(t, err)
+			clock.now = clock.now.Add(time.Millisecond / 4)
+		}
+	}
+
+	generate(100)
+	// 3 次回拨用尽代数
+	for i := 1; i <= 3; i++ {
+		clock.now = clock.now.Add(-10 * time.Millisecond)
+		id, err := next()
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(i), sf.Decompose(id).Generation)
+		generate(10)
+	}
+	clock.now = clock.now.Add(-10 * time.Millisecond)
+	_, err = next()
+	assert.Equal(t, ErrClockRollback, err)
+
+	// 超过历史最大时间后代数归零
+	clock.now = clock.now.Add(time.Second)
+	id, err := next()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0), sf.Decompose(id).Generation)
+
+	stats := sf.Stats()
+	assert.Equal(t, uint64(4), stats.Rollbacks)
+	assert.Equal(t, uint64(3), stats.Borrowed)
+	assert.Equal(t, uint64(1), stats.Failed)
+}
+
+func TestRollbackBorrow_Overlap(t *testing.T) {
+	start := time.Now()
+	clock := &fakeClock{now: start}
+	sf := newRollbackSnowflake(t, clock, RollbackPolicy{Strategy: RollbackBorrow, ReservedBits: 2})
+
+	seen := make(map[int64]struct{})
+	run := func(from, to int) {
+		for ms := from; ms <= to; ms++ {
+			clock.now = start.Add(time.Duration(ms) * time.Millisecond)
+			for i := 0; i < 3; i++ {
+				id, err := sf.Next()
+				require.NoError(t, err)
+				_, dup := seen[id]
+				require.False(t, dup, "duplicate id %d at ms %d", id, ms)
+				seen[id] = struct{}{}
+			}
+		}
+	}
+	// 两次回拨到重叠的时间段, 第二次不能复用第一次回拨的代数
+	run(0, 100)
+	run(50, 101)
+	run(60, 102)
+	run(55, 103)
+	assert.Equal(t, uint64(3), sf.Stats().Borrowed)
+
+	// 所有代数在回拨后的时间都已使用
+	clock.now = start.Add(70 * time.Millisecond)
+	_, err := sf.Next()
+	assert.Equal(t, ErrClockRollback, err)
+}
@@ -0,0 +1,165 @@
+// Package ulid 生成 ULID (https://github.com/ulid/spec)
+package ulid
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidLength     = errors.New("ulid: invalid length")
+	ErrInvalidCharacter  = errors.New("ulid: invalid character")
+	ErrOverflow          = errors.New("ulid: value overflows 128 bits")
+	ErrMonotonicOverflow = errors.New("ulid: monotonic entropy overflow")
+)
+
+const encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var decoding = func() (d [256]byte) {
+	for i := range d {
+		d[i] = 0xFF
+	}
+	for i := 0; i < len(encoding); i++ {
+		d[encoding[i]] = byte(i)
+		d[encoding[i]|0x20] = byte(i) // 小写
+	}
+	return
+}()
+
+// ULID 48 位毫秒时间戳 + 80 位随机数
+type ULID [16]byte
+
+// String 26 位 Crockford base32
+func (u ULID) String() string {
+	var dst [26]byte
+	// 128 位按 5 位一组, 首字符只有 3 位
+	dst[0] = encoding[u[0]>>5]
+	bit := 3
+	for i := 1; i < 26; i++ {
+		var v uint16
+		byteIdx := bit / 8
+		v = uint16(u[byteIdx]) << 8
+		if byteIdx+1 < 16 {
+			v |= uint16(u[byteIdx+1])
+		}
+		dst[i] = encoding[v>>(11-bit%8)&0x1F]
+		bit += 5
+	}
+	return string(dst[:])
+}
+
+// Parse 解析 26 位字符串, 不区分大小写
+func Parse(s string) (u ULID, err error) {
+	if len(s) != 26 {
+		return u, ErrInvalidLength
+	}
+	if decoding[s[0]] > 7 {
+		if decoding[s[0]] == 0xFF {
+			return u, ErrInvalidCharacter
+		}
+		return u, ErrOverflow
+	}
+	bit := -2
+	for i := 0; i < 26; i++ {
+		v := decoding[s[i]]
+		if v == 0xFF {
+			return u, ErrInvalidCharacter
+		}
+		for j := 4; j >= 0; j-- {
+			if bit >= 0 && v>>j&1 == 1 {
+				u[bit/8] |= 1 << (7 - bit%8)
+			}
+			bit++
+		}
+	}
+	return u, nil
+}
+
+// Time 时间戳部分
+func (u ULID) Time() time.Time {
+	ms := uint64(u[0])<<40 | uint64(u[1])<<32 | uint64(u[2])<<24 | uint64(u[3])<<16 | uint64(u[4])<<8 | uint64(u[5])
+	return time.UnixMilli(int64(ms))
+}
+
+func (u *ULID) setTime(ms uint64) {
+	for i := 5; i >= 0; i-- {
+		u[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// Option 生成器选项
+type Option func(*Generator)
+
+// WithMonotonic 同一毫秒内随机部分递增, 保证严格有序
+func WithMonotonic() Option {
+	return func(g *Generator) {
+		g.monotonic = true
+	}
+}
+
+// WithClock 替换时钟, 用于测试
+func WithClock(now func() time.Time) Option {
+	return func(g *Generator) {
+		g.now = now
+	}
+}
+
+// WithEntropy 替换随机源, 默认 crypto/rand
+func WithEntropy(r io.Reader) Option {
+	return func(g *Generator) {
+		g.entropy = r
+	}
+}
+
+// Generator ULID 生成器, 并发安全
+type Generator struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	entropy   io.Reader
+	monotonic bool
+	last      ULID
+	lastMs    uint64
+}
+
+func NewGenerator(opts ...Option) *Generator {
+	g := &Generator{
+		now:     time.Now,
+		entropy: rand.Reader,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Next 生成 ULID, 单调模式下同一毫秒内随机部分溢出时返回 ErrMonotonicOverflow
+func (g *Generator) Next() (ULID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if g.monotonic && ms <= g.lastMs && g.lastMs != 0 {
+		// 时钟未前进或回拨时沿用上一个时间戳
+		u := g.last
+		for i := 15; i >= 6; i-- {
+			u[i]++
+			if u[i] != 0 {
+				g.last = u
+				return u, nil
+			}
+		}
+		return ULID{}, ErrMonotonicOverflow
+	}
+
+	var u ULID
+	u.setTime(ms)
+	if _, err := io.ReadFull(g.entropy, u[6:]); err != nil {
+		return ULID{}, err
+	}
+	g.last, g.lastMs = u, ms
+	return u, nil
+}
@@ -0,0 +1,63 @@
+package ulid
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	var u ULID
+	assert.Equal(t, "00000000000000000000000000", u.String())
+	for i := range u {
+		u[i] = 0xFF
+	}
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", u.String())
+
+	u, err := Parse("01ARYZ6S41TSV4RRFFQ69G5FAV")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1469918176385), u.Time().UnixMilli())
+	assert.Equal(t, "01ARYZ6S41TSV4RRFFQ69G5FAV", u.String())
+
+	lower, err := Parse("01aryz6s41tsv4rrffq69g5fav")
+	require.NoError(t, err)
+	assert.Equal(t, u, lower)
+
+	_, err = Parse("8ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	assert.Equal(t, ErrOverflow, err)
+	_, err = Parse("01ARYZ6S41TSV4RRFFQ69G5FA")
+	assert.Equal(t, ErrInvalidLength, err)
+	_, err = Parse("01ARYZ6S41TSV4RRFFQ69G5FAU")
+	assert.Equal(t, ErrInvalidCharacter, err)
+}
+
+func TestGenerator(t *testing.T) {
+	now := time.UnixMilli(1469918176385)
+	g := NewGenerator(WithMonotonic(), WithClock(func() time.Time { return now }))
+
+	var last ULID
+	for i := 0; i < 1000; i++ {
+		u, err := g.Next()
+		require.NoError(t, err)
+		assert.Equal(t, now, u.Time())
+		assert.Greater(t, u.String(), last.String())
+		last = u
+	}
+
+	// 时钟回拨时仍然递增
+	now = now.Add(-time.Second)
+	u, err := g.Next()
+	require.NoError(t, err)
+	assert.Greater(t, u.String(), last.String())
+
+	// 随机部分溢出
+	max := bytes.Repeat([]byte{0xFF}, 10)
+	g = NewGenerator(WithMonotonic(), WithClock(func() time.Time { return now }), WithEntropy(bytes.NewReader(max)))
+	_, err = g.Next()
+	require.NoError(t, err)
+	_, err = g.Next()
+	assert.Equal(t, ErrMonotonicOverflow, err)
+}
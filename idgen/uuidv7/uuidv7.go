@@ -0,0 +1,139 @@
+// Package uuidv7 生成 RFC 9562 UUID version 7
+package uuidv7
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+var ErrInvalidFormat = errors.New("uuidv7: invalid format")
+
+// UUID 48 位毫秒时间戳 | 4 位版本 | 12 位 rand_a | 2 位变体 | 62 位 rand_b
+type UUID [16]byte
+
+// String xxxxxxxx-xxxx-7xxx-xxxx-xxxxxxxxxxxx
+func (u UUID) String() string {
+	var dst [36]byte
+	hex.Encode(dst[0:8], u[0:4])
+	dst[8] = '-'
+	hex.Encode(dst[9:13], u[4:6])
+	dst[13] = '-'
+	hex.Encode(dst[14:18], u[6:8])
+	dst[18] = '-'
+	hex.Encode(dst[19:23], u[8:10])
+	dst[23] = '-'
+	hex.Encode(dst[24:], u[10:])
+	return string(dst[:])
+}
+
+// Parse 解析带连字符的 36 位字符串, 要求版本为 7
+func Parse(s string) (u UUID, err error) {
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, ErrInvalidFormat
+	}
+	b := []byte(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:])
+	if _, err = hex.Decode(u[:], b); err != nil {
+		return u, ErrInvalidFormat
+	}
+	if u.Version() != 7 || u[8]>>6 != 0b10 {
+		return u, ErrInvalidFormat
+	}
+	return u, nil
+}
+
+// Version 版本号
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time 时间戳部分
+func (u UUID) Time() time.Time {
+	ms := uint64(u[0])<<40 | uint64(u[1])<<32 | uint64(u[2])<<24 | uint64(u[3])<<16 | uint64(u[4])<<8 | uint64(u[5])
+	return time.UnixMilli(int64(ms))
+}
+
+// Option 生成器选项
+type Option func(*Generator)
+
+// WithMonotonic 使用 rand_a 作为 12 位计数器 (RFC 9562 6.2 方法 1),
+// 同一毫秒内严格递增, 计数器用尽时借用下一毫秒.
+func WithMonotonic() Option {
+	return func(g *Generator) {
+		g.monotonic = true
+	}
+}
+
+// WithClock 替换时钟, 用于测试
+func WithClock(now func() time.Time) Option {
+	return func(g *Generator) {
+		g.now = now
+	}
+}
+
+// WithEntropy 替换随机源, 默认 crypto/rand
+func WithEntropy(r io.Reader) Option {
+	return func(g *Generator) {
+		g.entropy = r
+	}
+}
+
+// Generator UUIDv7 生成器, 并发安全
+type Generator struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	entropy   io.Reader
+	monotonic bool
+	lastMs    int64
+	counter   uint16
+}
+
+func NewGenerator(opts ...Option) *Generator {
+	g := &Generator{
+		now:     time.Now,
+		entropy: rand.Reader,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Next 生成 UUIDv7
+func (g *Generator) Next() (UUID, error) {
+	var u UUID
+	if _, err := io.ReadFull(g.entropy, u[6:]); err != nil {
+		return u, err
+	}
+
+	g.mu.Lock()
+	ms := g.now().UnixMilli()
+	if g.monotonic {
+		if ms <= g.lastMs {
+			// 时钟未前进或回拨时沿用上一个时间戳
+			ms = g.lastMs
+			g.counter++
+			if g.counter > 0xFFF {
+				ms++
+				g.counter = 0
+			}
+		} else {
+			// 计数器初始值最高位为 0, 留出递增空间
+			g.counter = (uint16(u[6])<<8 | uint16(u[7])) & 0x7FF
+		}
+		g.lastMs = ms
+		u[6], u[7] = byte(g.counter>>8), byte(g.counter)
+	}
+	g.mu.Unlock()
+
+	for i := 5; i >= 0; i-- {
+		u[i] = byte(ms)
+		ms >>= 8
+	}
+	u[6] = u[6]&0x0F | 0x70
+	u[8] = u[8]&0x3F | 0x80
+	return u, nil
+}
@@ -0,0 +1,54 @@
+package uuidv7
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 9562 附录 A.6
+func TestParse(t *testing.T) {
+	u, err := Parse("017f22e2-79b0-7cc3-98c4-dc0c0c07398f")
+	require.NoError(t, err)
+	assert.Equal(t, 7, u.Version())
+	assert.Equal(t, int64(0x017F22E279B0), u.Time().UnixMilli())
+	assert.Equal(t, "017f22e2-79b0-7cc3-98c4-dc0c0c07398f", u.String())
+
+	for _, s := range []string{
+		"017f22e2-79b0-4cc3-98c4-dc0c0c07398f", // v4
+		"017f22e2-79b0-7cc3-c8c4-dc0c0c07398f", // variant
+		"017f22e279b07cc398c4dc0c0c07398f",
+		"017f22e2-79b0-7cc3-98c4-dc0c0c07398g",
+	} {
+		_, err = Parse(s)
+		assert.Equal(t, ErrInvalidFormat, err, s)
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	now := time.UnixMilli(0x017F22E279B0)
+	g := NewGenerator(WithMonotonic(), WithClock(func() time.Time { return now }))
+
+	var last string
+	for i := 0; i < 5000; i++ {
+		u, err := g.Next()
+		require.NoError(t, err)
+		assert.Equal(t, 7, u.Version())
+		s := u.String()
+		assert.Greater(t, s, last)
+		last = s
+	}
+
+	now = now.Add(-time.Second)
+	u, err := g.Next()
+	require.NoError(t, err)
+	assert.Greater(t, u.String(), last)
+
+	u, err = NewGenerator().Next()
+	require.NoError(t, err)
+	parsed, err := Parse(u.String())
+	require.NoError(t, err)
+	assert.Equal(t, u, parsed)
+}
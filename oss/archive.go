@@ -0,0 +1,108 @@
+package oss
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrArchiveTooLarge 压缩包解压后过大, 条目过多或压缩比异常 (解压炸弹)
+var ErrArchiveTooLarge = errors.New("oss: archive expands too far")
+
+// ArchiveLimits 压缩包的解压限制, 为 0 的项不限制
+type ArchiveLimits struct {
+	// MaxEntries 最多条目数
+	MaxEntries int `json:"max_entries" yaml:"maxEntries"`
+	// MaxSize 解压后的总大小
+	MaxSize int64 `json:"max_size" yaml:"maxSize"`
+	// MaxRatio 解压后与压缩后大小之比
+	MaxRatio float64 `json:"max_ratio" yaml:"maxRatio"`
+}
+
+// DefaultArchiveLimits 默认最多 10000 个条目, 解压后不超过 1GB 且压缩比不超过 100
+var DefaultArchiveLimits = ArchiveLimits{
+	MaxEntries: 10000,
+	MaxSize:    1 << 30,
+	MaxRatio:   100,
+}
+
+// checkArchive 按内容类型检查 zip 与 gzip, 实际解压计数而不信任头部声明的大小
+func checkArchive(contentType string, r io.ReaderAt, size int64, limits ArchiveLimits) error {
+	switch contentType {
+	case "application/zip":
+		return checkZip(r, size, limits)
+	case "application/x-gzip":
+		zr, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrArchiveTooLarge, err)
+		}
+		defer zr.Close()
+		_, err = expand(zr, size, 0, limits)
+		return err
+	}
+	return nil
+}
+
+func checkZip(r io.ReaderAt, size int64, limits ArchiveLimits) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		// 以 PK 开头但无法解析, 不会被当作压缩包解压
+		return nil
+	}
+	if limits.MaxEntries > 0 && len(zr.File) > limits.MaxEntries {
+		return fmt.Errorf("%w: %d entries exceeds %d", ErrArchiveTooLarge, len(zr.File), limits.MaxEntries)
+	}
+	// 先按头部声明的大小快速拒绝
+	var declared uint64
+	for _, f := range zr.File {
+		declared += f.UncompressedSize64
+	}
+	if err = checkExpanded(int64(min(declared, 1<<62)), size, limits); err != nil {
+		return err
+	}
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrArchiveTooLarge, f.Name, err)
+		}
+		total, err = expand(rc, size, total, limits)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expand 解压 r 并累加到 total, 超过限制时立即停止
+func expand(r io.Reader, compressed, total int64, limits ArchiveLimits) (int64, error) {
+	budget := int64(1<<63 - 2)
+	if limits.MaxSize > 0 {
+		budget = limits.MaxSize - total
+	}
+	if limits.MaxRatio > 0 {
+		budget = min(budget, int64(limits.MaxRatio*float64(compressed))-total)
+	}
+	n, err := io.Copy(io.Discard, io.LimitReader(r, max(budget, 0)+1))
+	total += n
+	if err != nil {
+		return total, fmt.Errorf("%w: %w", ErrArchiveTooLarge, err)
+	}
+	return total, checkExpanded(total, compressed, limits)
+}
+
+func checkExpanded(total, compressed int64, limits ArchiveLimits) error {
+	if limits.MaxSize > 0 && total > limits.MaxSize {
+		return fmt.Errorf("%w: expands to more than %d bytes", ErrArchiveTooLarge, limits.MaxSize)
+	}
+	if limits.MaxRatio > 0 && compressed > 0 && float64(total) > limits.MaxRatio*float64(compressed) {
+		return fmt.Errorf("%w: compression ratio exceeds %g", ErrArchiveTooLarge, limits.MaxRatio)
+	}
+	return nil
+}
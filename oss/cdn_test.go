@@ -0,0 +1,122 @@
+package oss
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixedNow(sec int64) func() time.Time {
+	return func() time.Time { return time.Unix(sec, 0) }
+}
+
+func TestAliyunCDNSigner(t *testing.T) {
+	// 阿里云文档中的示例
+	a := &AliyunCDNSigner{Type: "A", Key: "aliyuncdnexp1234", now: fixedNow(1444435200), rand: func() string { return "0" }}
+	u, err := a.SignURL("http://cdn.example.com/video/standard/1K.html", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "http://cdn.example.com/video/standard/1K.html?auth_key=1444435200-0-0-80cd3862d699b7118eed99103f2a3a4f", u)
+
+	b := &AliyunCDNSigner{Type: "B", Key: "aliyuncdnexp1234", now: fixedNow(1439596800)}
+	u, err = b.SignURL("http://cdn.example.com/4/44/44c0909bcfc20a01afaf256ca99a8b8b.mp3", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "http://cdn.example.com/201508150800/9044548ef1527deadafa49a890a377f0/4/44/44c0909bcfc20a01afaf256ca99a8b8b.mp3", u)
+
+	c := &AliyunCDNSigner{Type: "C", Key: "aliyuncdnexp1234", now: fixedNow(1439596800)}
+	u, err = c.SignURL("http://cdn.example.com/test.flv", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "http://cdn.example.com/a37fa50a5fb8f71214b1e7c95ec7a1bd/55CE8100/test.flv", u)
+
+	// 时间戳为过期时间减去控制台配置的有效时长
+	c.Validity = 30 * time.Minute
+	u2, err := c.SignURL("http://cdn.example.com/test.flv", 30*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, u, u2)
+
+	_, err = (&AliyunCDNSigner{Type: "D"}).SignURL("http://cdn.example.com/a", time.Minute)
+	assert.ErrorIs(t, err, ErrUnknownCDNScheme)
+}
+
+func TestCloudFrontSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	signer, err := NewURLSigner(CDNConfig{Scheme: CDNSchemeCloudFront, KeyPairID: "K2JCJMDEHXQW5F", PrivateKey: string(pemKey)})
+	require.NoError(t, err)
+	cf := signer.(*CloudFrontSigner)
+	cf.now = fixedNow(1700000000)
+
+	raw := "https://d111111abcdef8.cloudfront.net/images/a.png?size=1"
+	signed, err := cf.SignURL(raw, time.Hour)
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "1", q.Get("size"))
+	assert.Equal(t, "1700003600", q.Get("Expires"))
+	assert.Equal(t, "K2JCJMDEHXQW5F", q.Get("Key-Pair-Id"))
+
+	sig, err := base64.StdEncoding.DecodeString(strings.NewReplacer("-", "+", "_", "=", "~", "/").Replace(q.Get("Signature")))
+	require.NoError(t, err)
+	policy := `{"Statement":[{"Resource":"` + raw + `","Condition":{"DateLessThan":{"AWS:EpochTime":1700003600}}}]}`
+	digest := sha1.Sum([]byte(policy))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, digest[:], sig))
+
+	_, err = NewURLSigner(CDNConfig{Scheme: CDNSchemeCloudFront, PrivateKey: "invalid"})
+	assert.Error(t, err)
+	_, err = NewURLSigner(CDNConfig{Scheme: "akamai"})
+	assert.ErrorIs(t, err, ErrUnknownCDNScheme)
+}
+
+func TestOssUtil_URL(t *testing.T) {
+	o := NewOssUtil(OssUtilConfig{
+		EndPoint:   "oss.example.com",
+		Region:     "us-east-1",
+		BucketName: "bucket",
+		Dir:        "temp",
+		UseSSL:     true,
+		CDN:        CDNConfig{Domain: "https://cdn.example.com/", Scheme: CDNSchemeAliyunC, Key: "key"},
+	})
+
+	u, err := o.PublicURL("a b/中.png")
+	require.NoError(t, err)
+	assert.Equal(t, "https://oss.example.com/bucket/temp/a%20b/%E4%B8%AD.png", u)
+	o.Config.PublicBaseURL = "https://static.example.com/"
+	u, err = o.PublicURL("a.png")
+	require.NoError(t, err)
+	assert.Equal(t, "https://static.example.com/temp/a.png", u)
+	_, err = o.PublicURL("../a.png")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	o.cdn.(*AliyunCDNSigner).now = fixedNow(1439596800)
+	u, err = o.CDNURL("a.png", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/"+md5Hex("key/temp/a.png55CE8100")+"/55CE8100/temp/a.png", u)
+
+	o.cdn = nil
+	u, err = o.CDNURL("a.png", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/temp/a.png", u)
+	o.Config.CDN.Domain = ""
+	_, err = o.CDNURL("a.png", 0)
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+func TestContentDisposition(t *testing.T) {
+	assert.Equal(t, "inline", ContentDisposition("inline", ""))
+	assert.Equal(t, `attachment; filename="a \"b\".pdf"`, ContentDisposition("attachment", `a "b".pdf`))
+	assert.Equal(t, `attachment; filename="__ (1).pdf"; filename*=UTF-8''%E6%8A%A5%E5%91%8A%20%281%29.pdf`,
+		ContentDisposition("attachment", "报告 (1).pdf"))
+}
@@ -0,0 +1,371 @@
+package oss
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zmicro-team/ztlib/aesx"
+)
+
+// 客户端信封加密保存在元数据中的信息
+const (
+	// MetaEncryptionKey 用主密钥加密后的数据密钥, base64
+	MetaEncryptionKey = "Encryption-Key"
+	// MetaEncryptionKeyID 主密钥 ID
+	MetaEncryptionKeyID = "Encryption-Key-Id"
+	// MetaEncryptionChunkSize 分块加密的明文块大小
+	MetaEncryptionChunkSize = "Encryption-Chunk-Size"
+)
+
+// DefaultEncryptChunkSize 默认的明文块大小
+const DefaultEncryptChunkSize = 64 << 10
+
+// ErrDecrypt 主密钥未知、数据密钥无法解开或密文被篡改
+var ErrDecrypt = errors.New("oss: decrypt object failed")
+
+// EncryptedStorage 客户端信封加密的 Storage, 每个对象使用随机的数据密钥分块加密,
+// 数据密钥由主密钥加密后保存在元数据中. Get、Stat 与 List 返回明文与明文大小,
+// 未加密的对象原样返回; 预签名链接会绕过加解密, 不支持.
+type EncryptedStorage struct {
+	Storage
+	keyID     string
+	keys      map[string][]byte
+	chunkSize int
+}
+
+// EncryptOption 信封加密选项
+type EncryptOption func(*EncryptedStorage)
+
+// WithDecryptKey 添加只用于解密的主密钥, 用于轮换主密钥后读取旧对象
+func WithDecryptKey(id string, key []byte) EncryptOption {
+	return func(s *EncryptedStorage) {
+		s.keys[id] = key
+	}
+}
+
+// WithEncryptChunkSize 明文块大小, 默认 DefaultEncryptChunkSize
+func WithEncryptChunkSize(n int) EncryptOption {
+	return func(s *EncryptedStorage) {
+		if n > 0 {
+			s.chunkSize = n
+		}
+	}
+}
+
+// NewEncryptedStorage 用 ID 为 keyID 的主密钥 key (16、24 或 32 字节) 加密 store 中的对象
+func NewEncryptedStorage(store Storage, keyID string, key []byte, opts ...EncryptOption) (*EncryptedStorage, error) {
+	if keyID == "" {
+		return nil, errors.New("oss: empty encryption key id")
+	}
+	s := &EncryptedStorage{
+		Storage:   store,
+		keyID:     keyID,
+		keys:      make(map[string][]byte),
+		chunkSize: DefaultEncryptChunkSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.keys[keyID] = key
+	for id, k := range s.keys {
+		if _, err := aesx.GCMSeal(nil, nil, k); err != nil {
+			return nil, fmt.Errorf("oss: invalid encryption key %s: %w", id, err)
+		}
+	}
+	return s, nil
+}
+
+// stream 解开对象的数据密钥, 未加密的对象返回 nil
+func (s *EncryptedStorage) stream(info ObjectInfo) (*aesx.GCMStream, error) {
+	wrapped, ok := info.Metadata[MetaEncryptionKey]
+	if !ok {
+		return nil, nil
+	}
+	id := info.Metadata[MetaEncryptionKeyID]
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrDecrypt, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	dataKey, err := aesx.GCMOpen(sealed, []byte(id), key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	chunkSize, err := strconv.Atoi(info.Metadata[MetaEncryptionChunkSize])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	stream, err := aesx.NewGCMStream(dataKey, chunkSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	return stream, nil
+}
+
+// plainInfo 换成明文大小并去掉加密信息
+func plainInfo(info ObjectInfo, stream *aesx.GCMStream) (ObjectInfo, error) {
+	size, err := stream.PlainSize(info.Size)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	info.Size = size
+	info.Metadata = maps.Clone(info.Metadata)
+	delete(info.Metadata, MetaEncryptionKey)
+	delete(info.Metadata, MetaEncryptionKeyID)
+	delete(info.Metadata, MetaEncryptionChunkSize)
+	return info, nil
+}
+
+// Put 加密后上传, 未指定 Content-Type 时按明文检测
+func (s *EncryptedStorage) Put(ctx context.Context, name string, r io.Reader, size int64, opts ...PutOption) (ObjectInfo, error) {
+	opts = slices.Clip(opts)
+	if putOptions(opts).ContentType == "" {
+		var contentType string
+		contentType, r = detectContentType(name, r)
+		opts = append(opts, WithContentType(contentType))
+	}
+	dataKey := make([]byte, 32)
+	if _, err := crand.Read(dataKey); err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	wrapped, err := aesx.GCMSeal(dataKey, []byte(s.keyID), s.keys[s.keyID])
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	stream, err := aesx.NewGCMStream(dataKey, s.chunkSize)
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	if size >= 0 {
+		size = stream.CipherSize(size)
+	}
+	opts = append(opts, WithMetadata(map[string]string{
+		MetaEncryptionKey:       base64.StdEncoding.EncodeToString(wrapped),
+		MetaEncryptionKeyID:     s.keyID,
+		MetaEncryptionChunkSize: strconv.Itoa(s.chunkSize),
+	}))
+	info, err := s.Storage.Put(ctx, name, stream.EncryptReader(r), size, opts...)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err = plainInfo(info, stream)
+	return info, wrapError("put", name, err)
+}
+
+// Get 下载并解密, 支持 WithRange, 读取时发现密文被篡改返回 ErrDecrypt
+func (s *EncryptedStorage) Get(ctx context.Context, name string, opts ...GetOption) (io.ReadCloser, ObjectInfo, error) {
+	g, err := parseGetOptions(opts)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	if g.ranged {
+		return s.getRange(ctx, name, g, slices.Clip(opts))
+	}
+	rc, info, err := s.Storage.Get(ctx, name, opts...)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	stream, err := s.stream(info)
+	if err == nil && stream != nil {
+		info, err = plainInfo(info, stream)
+	}
+	if err != nil {
+		_ = rc.Close()
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	if stream == nil {
+		return rc, info, nil
+	}
+	return &decryptReader{r: stream.DecryptReader(rc, 0, true), c: rc, name: name}, info, nil
+}
+
+// getRange 只下载范围所在的块
+func (s *EncryptedStorage) getRange(ctx context.Context, name string, g getRange, opts []GetOption) (io.ReadCloser, ObjectInfo, error) {
+	info, err := s.Storage.Stat(ctx, name)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	stream, err := s.stream(info)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	if stream == nil {
+		return s.Storage.Get(ctx, name, opts...)
+	}
+	if g.matchETag != "" && g.matchETag != info.ETag {
+		return nil, ObjectInfo{}, wrapError("get", name, ErrPreconditionFailed)
+	}
+	cipherSize := info.Size
+	if info, err = plainInfo(info, stream); err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	offset, length := g.section(info.Size)
+	info.Size = length
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), info, nil
+	}
+
+	chunk, sealed := int64(stream.ChunkSize()), stream.CipherSize(int64(stream.ChunkSize()))
+	first, last := offset/chunk, (offset+length-1)/chunk
+	end := min((last+1)*sealed, cipherSize) - 1
+	// 锁定 ETag, 避免 Stat 之后对象被覆盖
+	opts = append(opts, WithRange(first*sealed, end), WithMatchETag(info.ETag))
+	rc, _, err := s.Storage.Get(ctx, name, opts...)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	r := stream.DecryptReader(rc, uint64(first), end == cipherSize-1)
+	if _, err = io.CopyN(io.Discard, r, offset-first*chunk); err != nil {
+		_ = rc.Close()
+		return nil, ObjectInfo{}, wrapError("get", name, decryptError(err))
+	}
+	return &decryptReader{r: io.LimitReader(r, length), c: rc, name: name}, info, nil
+}
+
+// Stat 获取对象信息, Size 为明文大小
+func (s *EncryptedStorage) Stat(ctx context.Context, name string) (ObjectInfo, error) {
+	info, err := s.Storage.Stat(ctx, name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	stream, err := s.stream(info)
+	if err == nil && stream != nil {
+		info, err = plainInfo(info, stream)
+	}
+	if err != nil {
+		return ObjectInfo{}, wrapError("stat", name, err)
+	}
+	return info, nil
+}
+
+// statInfo 列举结果换成明文大小, 元数据不在列举结果中, 需逐个 Stat
+func (s *EncryptedStorage) statInfo(ctx context.Context, info ObjectInfo) (ObjectInfo, error) {
+	if info.IsDir {
+		return info, nil
+	}
+	stat, err := s.Stat(ctx, info.Key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info.Size = stat.Size
+	return info, nil
+}
+
+// List 列举对象, 每个对象额外调用一次 Stat 获取明文大小, 列举后被删除的对象会跳过
+func (s *EncryptedStorage) List(ctx context.Context, prefix string, opts ...ListOption) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		for info, err := range s.Storage.List(ctx, prefix, opts...) {
+			if err == nil {
+				info, err = s.statInfo(ctx, info)
+				if errors.Is(err, ErrNotFound) {
+					continue
+				}
+			}
+			if !yield(info, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ListPage 分页列举, 大小同 List
+func (s *EncryptedStorage) ListPage(ctx context.Context, prefix, startAfter string, limit int, opts ...ListOption) ([]ObjectInfo, string, error) {
+	infos, next, err := s.Storage.ListPage(ctx, prefix, startAfter, limit, opts...)
+	if err != nil {
+		return nil, "", err
+	}
+	page := infos[:0]
+	for _, info := range infos {
+		info, err = s.statInfo(ctx, info)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		page = append(page, info)
+	}
+	return page, next, nil
+}
+
+// Copy 在服务端复制密文, 指定 opts 替换元数据时保留加密信息
+func (s *EncryptedStorage) Copy(ctx context.Context, src, dst string, opts ...PutOption) (ObjectInfo, error) {
+	srcInfo, err := s.Storage.Stat(ctx, src)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	stream, err := s.stream(srcInfo)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", src, err)
+	}
+	if stream != nil && len(opts) > 0 {
+		opts = append(slices.Clip(opts), WithMetadata(map[string]string{
+			MetaEncryptionKey:       srcInfo.Metadata[MetaEncryptionKey],
+			MetaEncryptionKeyID:     srcInfo.Metadata[MetaEncryptionKeyID],
+			MetaEncryptionChunkSize: srcInfo.Metadata[MetaEncryptionChunkSize],
+		}))
+	}
+	info, err := s.Storage.Copy(ctx, src, dst, opts...)
+	if err != nil || stream == nil {
+		return info, err
+	}
+	// S3 复制的结果不含大小
+	info.Size = srcInfo.Size
+	info, err = plainInfo(info, stream)
+	return info, wrapError("copy", dst, err)
+}
+
+// PresignedGet 不支持, 下载的是密文
+func (s *EncryptedStorage) PresignedGet(_ context.Context, name string, _ time.Duration, _ ...PresignOption) (*url.URL, error) {
+	return nil, wrapError("presign", name, ErrNotSupported)
+}
+
+// PresignedHead 不支持, 返回的是密文大小
+func (s *EncryptedStorage) PresignedHead(_ context.Context, name string, _ time.Duration, _ ...PresignOption) (*url.URL, error) {
+	return nil, wrapError("presign", name, ErrNotSupported)
+}
+
+// PresignedPut 不支持, 上传的内容不会加密
+func (s *EncryptedStorage) PresignedPut(_ context.Context, name string, _ time.Duration) (*url.URL, error) {
+	return nil, wrapError("presign", name, ErrNotSupported)
+}
+
+// decryptReader 读取解密后的内容, 认证失败时返回 ErrDecrypt
+type decryptReader struct {
+	r    io.Reader
+	c    io.Closer
+	name string
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = wrapError("get", d.name, decryptError(err))
+	}
+	return n, err
+}
+
+func (d *decryptReader) Close() error {
+	return d.c.Close()
+}
+
+func decryptError(err error) error {
+	if errors.Is(err, aesx.ErrAuthentication) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+package oss_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/oss"
+	"github.com/zmicro-team/ztlib/oss/internal/fakes3"
+	"github.com/zmicro-team/ztlib/oss/tests"
+)
+
+var masterKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncryptedStorage_Storage(t *testing.T) {
+	srv := fakes3.New("bucket")
+	defer srv.Close()
+	for name, store := range map[string]oss.Storage{
+		"memory": oss.NewMemoryStorage(),
+		"oss": oss.NewOssUtil(oss.OssUtilConfig{
+			EndPoint:   srv.Endpoint(),
+			Region:     "us-east-1",
+			BucketName: "bucket",
+			Dir:        "temp",
+		}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			enc, err := oss.NewEncryptedStorage(store, "k1", masterKey, oss.WithEncryptChunkSize(4))
+			require.NoError(t, err)
+			tests.TestStorage(t, enc)
+		})
+	}
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	ctx := context.Background()
+	store := oss.NewMemoryStorage()
+	enc, err := oss.NewEncryptedStorage(store, "k1", masterKey, oss.WithEncryptChunkSize(16))
+	require.NoError(t, err)
+
+	plain := []byte(strings.Repeat("身份证号 110101199003077777 ", 10))
+	info, err := enc.Put(ctx, "id.txt", bytes.NewReader(plain), -1, oss.WithMetadata(map[string]string{"Owner": "bob"}))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(plain)), info.Size)
+	assert.Equal(t, map[string]string{"Owner": "bob"}, info.Metadata)
+	assert.Equal(t, "text/plain; charset=utf-8", info.ContentType)
+
+	// 存储中只有密文与加密后的数据密钥
+	rc, raw, err := store.Get(ctx, "id.txt")
+	require.NoError(t, err)
+	sealed, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	_ = rc.Close()
+	assert.NotContains(t, string(sealed), "110101199003077777")
+	assert.Equal(t, "k1", raw.Metadata[oss.MetaEncryptionKeyID])
+	assert.NotEmpty(t, raw.Metadata[oss.MetaEncryptionKey])
+
+	info, err = enc.Stat(ctx, "id.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(plain)), info.Size)
+	assert.Equal(t, map[string]string{"Owner": "bob"}, info.Metadata)
+
+	read := func(s oss.Storage, opts ...oss.GetOption) ([]byte, error) {
+		rc, _, err := s.Get(ctx, "id.txt", opts...)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	data, err := read(enc)
+	require.NoError(t, err)
+	assert.Equal(t, plain, data)
+
+	// 范围读取只解密所在的块
+	n := int64(len(plain))
+	for _, r := range [][2]int64{{0, 0}, {0, 15}, {15, 16}, {17, 40}, {16, 31}, {n - 1, -1}, {n - 20, -1}, {5, n + 10}} {
+		var want []byte
+		switch {
+		case r[1] < 0 || r[1] >= n:
+			want = plain[r[0]:]
+		default:
+			want = plain[r[0] : r[1]+1]
+		}
+		rc, info, err := enc.Get(ctx, "id.txt", oss.WithRange(r[0], r[1]))
+		require.NoError(t, err, r)
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		require.NoError(t, err, r)
+		assert.Equal(t, string(want), string(data), fmt.Sprint(r))
+		assert.Equal(t, int64(len(want)), info.Size, r)
+	}
+	_, _, err = enc.Get(ctx, "id.txt", oss.WithRange(1, 2), oss.WithMatchETag("other"))
+	assert.ErrorIs(t, err, oss.ErrPreconditionFailed)
+
+	// 复制并替换元数据后仍可解密
+	_, err = enc.Copy(ctx, "id.txt", "copy.txt", oss.WithMetadata(map[string]string{"Owner": "alice"}))
+	require.NoError(t, err)
+	rc, info, err = enc.Get(ctx, "copy.txt")
+	require.NoError(t, err)
+	data, err = io.ReadAll(rc)
+	_ = rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, plain, data)
+	assert.Equal(t, map[string]string{"Owner": "alice"}, info.Metadata)
+
+	// 轮换主密钥后用旧密钥解密
+	rotated, err := oss.NewEncryptedStorage(store, "k2", bytes.Repeat([]byte{1}, 32), oss.WithDecryptKey("k1", masterKey))
+	require.NoError(t, err)
+	data, err = read(rotated)
+	require.NoError(t, err)
+	assert.Equal(t, plain, data)
+	other, err := oss.NewEncryptedStorage(store, "k1", bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	_, err = read(other)
+	assert.ErrorIs(t, err, oss.ErrDecrypt)
+	_, err = oss.NewEncryptedStorage(store, "k3", []byte("short"))
+	assert.Error(t, err)
+
+	// 篡改密文
+	sealed[len(sealed)/2] ^= 1
+	_, err = store.Put(ctx, "id.txt", bytes.NewReader(sealed), -1, oss.WithMetadata(raw.Metadata))
+	require.NoError(t, err)
+	_, err = read(enc)
+	assert.ErrorIs(t, err, oss.ErrDecrypt)
+	_, err = read(enc, oss.WithRange(0, 3))
+	require.NoError(t, err)
+
+	// 未加密的对象原样返回
+	_, err = store.Put(ctx, "plain.txt", strings.NewReader("plain"), 5)
+	require.NoError(t, err)
+	rc, info, err = enc.Get(ctx, "plain.txt", oss.WithRange(1, 2))
+	require.NoError(t, err)
+	data, err = io.ReadAll(rc)
+	_ = rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "la", string(data))
+
+	_, err = enc.PresignedGet(ctx, "id.txt", time.Minute)
+	assert.ErrorIs(t, err, oss.ErrNotSupported)
+}
+
+func TestOssUtil_SSE(t *testing.T) {
+	ctx := context.Background()
+	srv := fakes3.New("bucket")
+	defer srv.Close()
+	o := oss.NewOssUtil(oss.OssUtilConfig{
+		EndPoint:        srv.Endpoint(),
+		Region:          "us-east-1",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		BucketName:      "bucket",
+		Dir:             "temp",
+	})
+
+	_, err := o.Put(ctx, "s3.txt", strings.NewReader("s3"), 2, oss.WithSSES3())
+	require.NoError(t, err)
+	obj, ok := srv.Object("bucket", "temp/s3.txt")
+	require.True(t, ok)
+	assert.Equal(t, "AES256", obj.Header.Get("X-Amz-Server-Side-Encryption"))
+
+	var key [32]byte
+	copy(key[:], masterKey)
+	_, err = o.Put(ctx, "c.txt", strings.NewReader("customer"), 8, oss.WithSSEC(key))
+	require.NoError(t, err)
+	obj, ok = srv.Object("bucket", "temp/c.txt")
+	require.True(t, ok)
+	assert.Equal(t, "AES256", obj.Header.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm"))
+	assert.Empty(t, obj.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key"))
+
+	_, _, err = o.Get(ctx, "c.txt")
+	assert.Error(t, err)
+	rc, _, err := o.Get(ctx, "c.txt", oss.WithGetSSEC(key))
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	_ = rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "customer", string(data))
+
+	// 复制 SSE-C 对象
+	_, err = o.Copy(ctx, "c.txt", "c2.txt", oss.WithSSEC(key))
+	require.NoError(t, err)
+	rc, _, err = o.Get(ctx, "c2.txt", oss.WithGetSSEC(key))
+	require.NoError(t, err)
+	data, err = io.ReadAll(rc)
+	_ = rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "customer", string(data))
+	_, err = o.Copy(ctx, "c.txt", "c3.txt")
+	assert.Error(t, err)
+
+	// 预签名链接需带上返回的加密头
+	do := func(method string, u fmt.Stringer, header http.Header, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, u.String(), strings.NewReader(body))
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(data)
+	}
+	u, header, err := o.PresignedPutHeader(ctx, "up.txt", time.Minute, oss.WithSSEC(key), oss.WithContentType("text/plain"))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", header.Get("Content-Type"))
+	resp, _ := do(http.MethodPut, u, header, "uploaded")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	u, header, err = o.PresignedGetSSEC(ctx, "up.txt", time.Minute, key)
+	require.NoError(t, err)
+	resp, body := do(http.MethodGet, u, header, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "uploaded", body)
+	resp, _ = do(http.MethodGet, u, nil, "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestSSE_NotSupported(t *testing.T) {
+	ctx := context.Background()
+	local, err := oss.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	enc, err := oss.NewEncryptedStorage(oss.NewMemoryStorage(), "k1", masterKey)
+	require.NoError(t, err)
+	var key [32]byte
+	for name, store := range map[string]oss.Storage{
+		"memory":    oss.NewMemoryStorage(),
+		"local":     local,
+		"encrypted": enc,
+	} {
+		for _, opt := range []oss.PutOption{oss.WithSSES3(), oss.WithSSEC(key)} {
+			_, err := store.Put(ctx, "a.txt", strings.NewReader("a"), 1, opt)
+			assert.ErrorIs(t, err, oss.ErrNotSupported, name)
+			_, err = store.Put(ctx, "a.txt", strings.NewReader("a"), 1)
+			require.NoError(t, err, name)
+			_, err = store.Copy(ctx, "a.txt", "b.txt", opt)
+			assert.ErrorIs(t, err, oss.ErrNotSupported, name)
+		}
+	}
+}
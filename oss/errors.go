@@ -0,0 +1,74 @@
+package oss
+
+import (
+	"errors"
+
+	minIo "github.com/minio/minio-go/v7"
+)
+
+var (
+	ErrNotFound           = errors.New("oss: object not found")
+	ErrBucketNotFound     = errors.New("oss: bucket not found")
+	ErrAccessDenied       = errors.New("oss: access denied")
+	ErrPreconditionFailed = errors.New("oss: precondition failed")
+	ErrUploadNotFound     = errors.New("oss: multipart upload not found")
+	ErrInvalidKey         = errors.New("oss: invalid object key")
+	ErrInvalidPartSize    = errors.New("oss: invalid part size")
+	ErrNotSupported       = errors.New("oss: operation not supported")
+	ErrSignatureMismatch  = errors.New("oss: signature mismatch or expired")
+	ErrInvalidLimit       = errors.New("oss: page limit must be positive")
+)
+
+// Error 带操作与对象名的错误, errors.Is 可匹配 Err* 变量, errors.As 可取到 minio.ErrorResponse
+type Error struct {
+	Op   string
+	Key  string
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	msg := "oss: " + e.Op
+	if e.Key != "" {
+		msg += " " + e.Key
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg + ": " + e.Kind.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// wrapError 按 S3 错误码归类
+func wrapError(op, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var kind error
+	var resp minIo.ErrorResponse
+	if errors.As(err, &resp) {
+		switch resp.Code {
+		case "NoSuchKey", "NotFound":
+			kind = ErrNotFound
+		case "NoSuchBucket":
+			kind = ErrBucketNotFound
+		case "AccessDenied":
+			kind = ErrAccessDenied
+		case "PreconditionFailed":
+			kind = ErrPreconditionFailed
+		case "NoSuchUpload":
+			kind = ErrUploadNotFound
+		}
+	}
+	return &Error{Op: op, Key: key, Kind: kind, Err: err}
+}
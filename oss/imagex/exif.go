@@ -0,0 +1,161 @@
+package imagex
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const tagOrientation = 0x0112
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// Orientation 读取 EXIF 中的方向 (1-8), 没有或无法解析时返回 1
+func Orientation(data []byte) int {
+	var tiff []byte
+	switch DetectFormat(data) {
+	case JPEG:
+		tiff = jpegExif(data)
+	case PNG:
+		tiff = pngExif(data)
+	case WEBP:
+		tiff = webpExif(data)
+	}
+	return tiffOrientation(tiff)
+}
+
+// tiffOrientation 从 TIFF 结构的 IFD0 中读取方向
+func tiffOrientation(tiff []byte) int {
+	tiff = bytes.TrimPrefix(tiff, exifHeader)
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		e := ifd + 2 + i*12
+		if e+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[e:]) == tagOrientation {
+			if o := int(order.Uint16(tiff[e+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// minimalExif 只包含方向的 TIFF 结构
+func minimalExif(orientation int) []byte {
+	b := []byte{
+		'I', 'I', 0x2a, 0x00, 0x08, 0x00, 0x00, 0x00, // 头, IFD0 偏移 8
+		0x01, 0x00, // 1 个条目
+		0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Orientation, SHORT, 1
+		0x00, 0x00, 0x00, 0x00, // 没有下一个 IFD
+	}
+	b[18] = byte(orientation)
+	return b
+}
+
+// jpegSegments 遍历 SOS 之前的段, f 返回 false 时停止; 返回 SOS 的偏移
+func jpegSegments(data []byte, f func(marker byte, seg []byte) bool) int {
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xff {
+			return -1
+		}
+		marker := data[i+1]
+		if marker == 0xff {
+			i++
+			continue
+		}
+		if marker == 0xda {
+			return i
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			return -1
+		}
+		if !f(marker, data[i:i+2+n]) {
+			return i
+		}
+		i += 2 + n
+	}
+	return -1
+}
+
+func jpegExif(data []byte) []byte {
+	var tiff []byte
+	jpegSegments(data, func(marker byte, seg []byte) bool {
+		if marker == 0xe1 && bytes.HasPrefix(seg[4:], exifHeader) {
+			tiff = seg[4+len(exifHeader):]
+			return false
+		}
+		return true
+	})
+	return tiff
+}
+
+// pngChunks 遍历 PNG 的块, 返回 false 表示格式错误
+func pngChunks(data []byte, f func(typ string, chunk []byte)) bool {
+	i := 8
+	for i+12 <= len(data) {
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		if i+12+n > len(data) {
+			return false
+		}
+		f(string(data[i+4:i+8]), data[i:i+12+n])
+		i += 12 + n
+	}
+	return i == len(data)
+}
+
+func pngExif(data []byte) []byte {
+	var tiff []byte
+	pngChunks(data, func(typ string, chunk []byte) {
+		if typ == "eXIf" && tiff == nil {
+			tiff = chunk[8 : len(chunk)-4]
+		}
+	})
+	return tiff
+}
+
+// webpChunks 遍历 RIFF 中的块, 返回 false 表示格式错误
+func webpChunks(data []byte, f func(fourcc string, chunk []byte)) bool {
+	i := 12
+	for i+8 <= len(data) {
+		n := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if i+8+n > len(data) {
+			return false
+		}
+		// 奇数长度的块有 1 字节填充, 最后一块的填充可能缺失
+		end := min(i+8+n+n&1, len(data))
+		f(string(data[i:i+4]), data[i:end])
+		i = end
+	}
+	return i == len(data)
+}
+
+func webpExif(data []byte) []byte {
+	var tiff []byte
+	webpChunks(data, func(fourcc string, chunk []byte) {
+		if fourcc == "EXIF" && tiff == nil {
+			n := binary.LittleEndian.Uint32(chunk[4:])
+			tiff = chunk[8 : 8+n]
+		}
+	})
+	return tiff
+}
@@ -0,0 +1,88 @@
+// Package imagex 纯 Go 实现的图片处理流水线: 解码 jpeg/png/gif/webp, 纠正方向,
+// 去除 EXIF/GPS 等元数据, 按配置生成缩略图并存储到派生的对象名下.
+package imagex
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	_ "golang.org/x/image/webp" // 注册 webp 解码器
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("imagex: unsupported image format")
+	ErrTooLarge          = errors.New("imagex: image dimensions exceed limit")
+	ErrMalformed         = errors.New("imagex: malformed image")
+	ErrFileTooLarge      = errors.New("imagex: image file exceeds size limit")
+)
+
+// Format 图片格式
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	PNG  Format = "png"
+	GIF  Format = "gif"
+	// WEBP 只支持解码, 没有纯 Go 的编码器
+	WEBP Format = "webp"
+)
+
+// ContentType 对应的 MIME 类型
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+// Ext 对应的扩展名
+func (f Format) Ext() string {
+	if f == JPEG {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
+
+// DetectFormat 按文件头识别格式, 无法识别时返回空
+func DetectFormat(data []byte) Format {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff}):
+		return JPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return PNG
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return GIF
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return WEBP
+	}
+	return ""
+}
+
+// Encode 按格式编码, quality 只对 jpeg 有效; jpeg 不支持透明, 透明部分填充为白色
+func Encode(w io.Writer, img image.Image, f Format, quality int) error {
+	switch f {
+	case JPEG:
+		return jpeg.Encode(w, flatten(img), &jpeg.Options{Quality: quality})
+	case PNG:
+		return png.Encode(w, img)
+	case GIF:
+		return gif.Encode(w, img, nil)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// flatten 将带透明通道的图片合成到白色背景上
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
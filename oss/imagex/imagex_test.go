@@ -0,0 +1,365 @@
+package imagex
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/zmicro-team/ztlib/oss"
+)
+
+// exifWithGPS 含方向与 GPS 纬度的 TIFF 结构
+func exifWithGPS(orientation int) []byte {
+	b := []byte{
+		'I', 'I', 0x2a, 0x00, 0x08, 0x00, 0x00, 0x00,
+		0x02, 0x00,
+		0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, byte(orientation), 0x00, 0x00, 0x00,
+		0x25, 0x88, 0x04, 0x00, 0x01, 0x00, 0x00, 0x00, 0x26, 0x00, 0x00, 0x00, // GPSInfo -> 38
+		0x00, 0x00, 0x00, 0x00,
+		// GPS IFD: GPSLatitudeRef = "N"
+		0x01, 0x00,
+		0x01, 0x00, 0x02, 0x00, 0x02, 0x00, 0x00, 0x00, 'N', 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	return append(b, "GPS-SECRET"...)
+}
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// testJPEG 在 SOI 之后插入 EXIF、XMP 与注释段
+func testJPEG(t *testing.T, w, h, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(w, h), nil); err != nil {
+		t.Fatal(err)
+	}
+	segment := func(marker byte, payload []byte) []byte {
+		return append([]byte{0xff, marker, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment(0xe1, append(append([]byte{}, exifHeader...), exifWithGPS(orientation)...))...)
+	out = append(out, segment(0xe1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>XMP-SECRET</x:xmpmeta>"))...)
+	out = append(out, segment(0xfe, []byte("COMMENT-SECRET"))...)
+	return append(out, data[2:]...)
+}
+
+// testPNG 在 IHDR 之后插入 eXIf 与 tEXt 块
+func testPNG(t *testing.T, img image.Image, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	ihdr := 8 + 12 + int(binary.BigEndian.Uint32(data[8:]))
+	out := append([]byte{}, data[:ihdr]...)
+	out = appendPNGChunk(out, "eXIf", exifWithGPS(orientation))
+	out = appendPNGChunk(out, "tEXt", []byte("Comment\x00TEXT-SECRET"))
+	return append(out, data[ihdr:]...)
+}
+
+// testWebP 将 testdata 中的简单格式 webp 封装为带 EXIF 与 XMP 的扩展格式
+func testWebP(t *testing.T, orientation int) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/blue-purple-pink.lossy.webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk := func(out []byte, fourcc string, payload []byte) []byte {
+		out = append(out, fourcc...)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(payload)))
+		out = append(out, payload...)
+		if len(payload)&1 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 | 0x04
+	vp8x[4], vp8x[5], vp8x[6] = byte(cfg.Width-1), byte((cfg.Width-1)>>8), byte((cfg.Width-1)>>16)
+	vp8x[7], vp8x[8], vp8x[9] = byte(cfg.Height-1), byte((cfg.Height-1)>>8), byte((cfg.Height-1)>>16)
+
+	out := []byte("RIFF\x00\x00\x00\x00WEBP")
+	out = chunk(out, "VP8X", vp8x)
+	out = append(out, data[12:]...)
+	out = chunk(out, "EXIF", exifWithGPS(orientation))
+	out = chunk(out, "XMP ", []byte("<x:xmpmeta>XMP-SECRET</x:xmpmeta>"))
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out
+}
+
+func assertNoSecrets(t *testing.T, data []byte) {
+	t.Helper()
+	for _, s := range []string{"GPS-SECRET", "XMP-SECRET", "COMMENT-SECRET", "TEXT-SECRET"} {
+		if bytes.Contains(data, []byte(s)) {
+			t.Errorf("stripped data still contains %q", s)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"jpeg", testJPEG(t, 40, 20, 6)},
+		{"png", testPNG(t, testImage(40, 20), 6)},
+		{"webp", testWebP(t, 6)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Orientation(tt.data); got != 6 {
+				t.Fatalf("Orientation() = %d, want 6", got)
+			}
+			want, _, err := image.DecodeConfig(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			stripped, err := Strip(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertNoSecrets(t, stripped)
+			if got := Orientation(stripped); got != 6 {
+				t.Errorf("Orientation(stripped) = %d, want 6", got)
+			}
+			img, _, err := image.Decode(bytes.NewReader(stripped))
+			if err != nil {
+				t.Fatalf("decode stripped: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != want.Width || b.Dy() != want.Height {
+				t.Errorf("stripped size = %v, want %dx%d", b.Size(), want.Width, want.Height)
+			}
+		})
+	}
+
+	t.Run("orientation 1", func(t *testing.T) {
+		stripped, err := Strip(testJPEG(t, 8, 8, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(stripped, exifHeader) {
+			t.Error("EXIF kept for orientation 1")
+		}
+	})
+
+	t.Run("gif", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gif.Encode(&buf, testImage(10, 10), nil); err != nil {
+			t.Fatal(err)
+		}
+		stripped, err := Strip(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = gif.Decode(bytes.NewReader(stripped)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		data := testJPEG(t, 8, 8, 6)
+		if _, err := Strip(data[:30]); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Strip(truncated) = %v, want ErrMalformed", err)
+		}
+		if _, err := Strip([]byte("hello")); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("Strip(text) = %v, want ErrUnsupportedFormat", err)
+		}
+		// VP8X 块过短
+		webp := []byte("RIFF\x0c\x00\x00\x00WEBPVP8X\x00\x00\x00\x00")
+		if _, err := Strip(webp); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Strip(short VP8X) = %v, want ErrMalformed", err)
+		}
+	})
+}
+
+func TestOrient(t *testing.T) {
+	// 2x1: 左红右蓝
+	red, blue := color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, red)
+	src.SetNRGBA(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		size        image.Point
+		first       color.NRGBA // 左上角
+	}{
+		{1, image.Pt(2, 1), red},
+		{2, image.Pt(2, 1), blue},
+		{3, image.Pt(2, 1), blue},
+		{4, image.Pt(2, 1), red},
+		{5, image.Pt(1, 2), red},
+		{6, image.Pt(1, 2), red},
+		{7, image.Pt(1, 2), blue},
+		{8, image.Pt(1, 2), blue},
+	}
+	for _, tt := range tests {
+		img := Orient(src, tt.orientation)
+		if got := img.Bounds().Size(); got != tt.size {
+			t.Errorf("Orient(%d) size = %v, want %v", tt.orientation, got, tt.size)
+			continue
+		}
+		if got := color.NRGBAModel.Convert(img.At(0, 0)); got != tt.first {
+			t.Errorf("Orient(%d) top-left = %v, want %v", tt.orientation, got, tt.first)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	src := testImage(200, 100)
+	tests := []struct {
+		name string
+		v    Variant
+		want image.Point
+	}{
+		{"fit width", Variant{Width: 50}, image.Pt(50, 25)},
+		{"fit box", Variant{Width: 50, Height: 50}, image.Pt(50, 25)},
+		{"fit height", Variant{Height: 20}, image.Pt(40, 20)},
+		{"fill", Variant{Width: 30, Height: 30, Mode: Fill}, image.Pt(30, 30)},
+		{"no upscale", Variant{Width: 400}, image.Pt(200, 100)},
+		{"fill no upscale", Variant{Width: 300, Height: 300, Mode: Fill}, image.Pt(100, 100)},
+		{"upscale", Variant{Width: 400, Upscale: true}, image.Pt(400, 200)},
+		{"none", Variant{}, image.Pt(200, 100)},
+	}
+	for _, tt := range tests {
+		if got := Resize(src, tt.v).Bounds().Size(); got != tt.want {
+			t.Errorf("%s: size = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// 极端比例裁剪不能得到 0 宽或 0 高
+	for _, tt := range []struct {
+		src  image.Image
+		v    Variant
+		want image.Point
+	}{
+		{testImage(1000, 1), Variant{Width: 1, Height: 1000, Mode: Fill}, image.Pt(1, 1)},
+		{testImage(1, 1000), Variant{Width: 1000, Height: 1, Mode: Fill}, image.Pt(1, 1)},
+		{testImage(1000, 1), Variant{Width: 1, Height: 1000, Mode: Fill, Upscale: true}, image.Pt(1, 1000)},
+	} {
+		got := Resize(tt.src, tt.v)
+		if got.Bounds().Size() != tt.want {
+			t.Errorf("Resize(%v, %+v) size = %v, want %v", tt.src.Bounds().Size(), tt.v, got.Bounds().Size(), tt.want)
+		}
+		if err := png.Encode(io.Discard, got); err != nil {
+			t.Errorf("encode: %v", err)
+		}
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+	store := oss.NewMemoryStorage()
+	p := New(store, []Variant{
+		{Name: "thumb", Width: 50, Height: 50, Mode: Fill},
+		{Name: "small", Width: 40, Height: 40},
+		{Name: "png", Width: 10, Format: PNG},
+	})
+
+	// 200x100, 方向 6, 显示为 100x200
+	res, err := p.Put(ctx, "a/photo.jpeg", bytes.NewReader(testJPEG(t, 200, 100, 6)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Format != JPEG || res.Width != 100 || res.Height != 200 {
+		t.Errorf("result = %s %dx%d, want jpeg 100x200", res.Format, res.Width, res.Height)
+	}
+
+	tests := []struct {
+		key         string
+		contentType string
+		size        image.Point
+	}{
+		{"a/photo.jpeg", "image/jpeg", image.Pt(200, 100)},
+		{"a/photo_thumb.jpg", "image/jpeg", image.Pt(50, 50)},
+		{"a/photo_small.jpg", "image/jpeg", image.Pt(20, 40)},
+		{"a/photo_png.png", "image/png", image.Pt(10, 20)},
+	}
+	for _, tt := range tests {
+		rc, info, err := store.Get(ctx, tt.key)
+		if err != nil {
+			t.Errorf("Get(%s): %v", tt.key, err)
+			continue
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.ContentType != tt.contentType {
+			t.Errorf("%s: content type = %q, want %q", tt.key, info.ContentType, tt.contentType)
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.key, err)
+			continue
+		}
+		if got := image.Pt(cfg.Width, cfg.Height); got != tt.size {
+			t.Errorf("%s: size = %v, want %v", tt.key, got, tt.size)
+		}
+		assertNoSecrets(t, data)
+	}
+	if len(res.Variants) != 3 || res.Variants["thumb"].Key != "a/photo_thumb.jpg" {
+		t.Errorf("variants = %v", res.Variants)
+	}
+
+	t.Run("webp", func(t *testing.T) {
+		res, err := p.Put(ctx, "w.webp", bytes.NewReader(testWebP(t, 1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Original.ContentType != "image/webp" || res.Variants["thumb"].Key != "w_thumb.jpg" {
+			t.Errorf("result = %+v", res)
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		small := New(store, nil, WithMaxPixels(100))
+		if _, err := small.Put(ctx, "big.png", bytes.NewReader(testPNG(t, testImage(20, 20), 1))); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Put(big) = %v, want ErrTooLarge", err)
+		}
+		if _, err := p.Put(ctx, "x.txt", bytes.NewReader([]byte("hello"))); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("Put(text) = %v, want ErrUnsupportedFormat", err)
+		}
+		data := testPNG(t, testImage(20, 20), 1)
+		if _, err := New(store, nil, WithMaxBytes(int64(len(data)-1))).Put(ctx, "big.png", bytes.NewReader(data)); !errors.Is(err, ErrFileTooLarge) {
+			t.Errorf("Put(big file) = %v, want ErrFileTooLarge", err)
+		}
+		if _, err := store.Stat(ctx, "big.png"); !errors.Is(err, oss.ErrNotFound) {
+			t.Errorf("Stat(big.png) = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		p := New(store, []Variant{{Name: "bad", Width: 10, Format: WEBP}})
+		if _, err := p.Put(ctx, "r.png", bytes.NewReader(testPNG(t, testImage(20, 20), 1))); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Fatalf("Put = %v, want ErrUnsupportedFormat", err)
+		}
+		if _, err := store.Stat(ctx, "r.png"); !errors.Is(err, oss.ErrNotFound) {
+			t.Errorf("Stat(r.png) = %v, want ErrNotFound", err)
+		}
+	})
+}
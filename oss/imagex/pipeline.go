@@ -0,0 +1,234 @@
+package imagex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/zmicro-team/ztlib/oss"
+)
+
+// Mode 缩放模式
+type Mode int
+
+const (
+	// Fit 等比缩放到 Width x Height 之内
+	Fit Mode = iota
+	// Fill 等比缩放并居中裁剪为 Width x Height
+	Fill
+)
+
+// Variant 缩略图等派生尺寸
+type Variant struct {
+	// Name 变体名, 默认对象名为 原名_Name.扩展名
+	Name          string
+	Width, Height int
+	Mode          Mode
+	// Upscale 是否允许放大, 默认不放大
+	Upscale bool
+	// Format 输出格式, 默认与原图相同; webp 与 gif 默认输出 jpeg 与 png
+	Format Format
+	// Quality jpeg 质量, 默认使用 WithQuality
+	Quality int
+}
+
+// Output 处理结果, Variant 为空表示原图
+type Output struct {
+	Variant string
+	Key     string
+	Data    []byte
+	Format  Format
+	// Width, Height 纠正方向之后的尺寸
+	Width, Height int
+}
+
+// Result 上传结果
+type Result struct {
+	Original oss.ObjectInfo
+	Format   Format
+	// Width, Height 纠正方向之后的尺寸
+	Width, Height int
+	Variants      map[string]oss.ObjectInfo
+}
+
+// KeyFunc 生成变体的对象名
+type KeyFunc func(name string, v Variant, f Format) string
+
+// DefaultKey a/b/photo.png 的变体 thumb 为 a/b/photo_thumb.jpg
+func DefaultKey(name string, v Variant, f Format) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "_" + v.Name + f.Ext()
+}
+
+// Option 流水线选项
+type Option func(*Pipeline)
+
+// WithKeyFunc 自定义变体的对象名, 默认 DefaultKey
+func WithKeyFunc(f KeyFunc) Option {
+	return func(p *Pipeline) {
+		p.key = f
+	}
+}
+
+// WithMaxPixels 解码前校验宽 x 高的上限, 防止解压炸弹, 默认 5000 万
+func WithMaxPixels(n int) Option {
+	return func(p *Pipeline) {
+		p.maxPixels = n
+	}
+}
+
+// WithMaxBytes Put 读取原图的字节数上限, 默认 32 MiB
+func WithMaxBytes(n int64) Option {
+	return func(p *Pipeline) {
+		p.maxBytes = n
+	}
+}
+
+// WithQuality jpeg 默认质量, 默认 85
+func WithQuality(q int) Option {
+	return func(p *Pipeline) {
+		p.quality = q
+	}
+}
+
+// WithKeepMetadata 原图不去除元数据
+func WithKeepMetadata() Option {
+	return func(p *Pipeline) {
+		p.keepMetadata = true
+	}
+}
+
+// Pipeline 上传图片时去除原图元数据并生成各尺寸变体
+type Pipeline struct {
+	storage      oss.Storage
+	variants     []Variant
+	key          KeyFunc
+	maxPixels    int
+	maxBytes     int64
+	quality      int
+	keepMetadata bool
+}
+
+// New 创建流水线, 变体按 variants 的顺序生成
+func New(storage oss.Storage, variants []Variant, opts ...Option) *Pipeline {
+	p := &Pipeline{
+		storage:   storage,
+		variants:  variants,
+		key:       DefaultKey,
+		maxPixels: 50_000_000,
+		maxBytes:  32 << 20,
+		quality:   85,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Process 处理图片, 返回原图与各变体, 不访问存储
+func (p *Pipeline) Process(name string, data []byte) ([]Output, error) {
+	format := DetectFormat(data)
+	if format == "" {
+		return nil, ErrUnsupportedFormat
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(ErrMalformed, err)
+	}
+	if cfg.Width*cfg.Height > p.maxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(ErrMalformed, err)
+	}
+	img = Orient(img, Orientation(data))
+
+	original := data
+	if !p.keepMetadata {
+		if original, err = Strip(data); err != nil {
+			return nil, err
+		}
+	}
+	outputs := make([]Output, 0, len(p.variants)+1)
+	outputs = append(outputs, Output{
+		Key:    name,
+		Data:   original,
+		Format: format,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	})
+
+	for _, v := range p.variants {
+		f := v.Format
+		if f == "" {
+			switch format {
+			case WEBP:
+				f = JPEG
+			case GIF:
+				f = PNG
+			default:
+				f = format
+			}
+		}
+		quality := v.Quality
+		if quality <= 0 {
+			quality = p.quality
+		}
+		dst := Resize(img, v)
+		var buf bytes.Buffer
+		if err = Encode(&buf, dst, f, quality); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, Output{
+			Variant: v.Name,
+			Key:     p.key(name, v, f),
+			Data:    buf.Bytes(),
+			Format:  f,
+			Width:   dst.Bounds().Dx(),
+			Height:  dst.Bounds().Dy(),
+		})
+	}
+	return outputs, nil
+}
+
+// Put 处理并存储原图与各变体, 任一失败时删除已存储的对象
+func (p *Pipeline) Put(ctx context.Context, name string, r io.Reader, opts ...oss.PutOption) (Result, error) {
+	data, err := io.ReadAll(io.LimitReader(r, p.maxBytes+1))
+	if err != nil {
+		return Result{}, err
+	}
+	if int64(len(data)) > p.maxBytes {
+		return Result{}, ErrFileTooLarge
+	}
+	outputs, err := p.Process(name, data)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{
+		Format:   outputs[0].Format,
+		Width:    outputs[0].Width,
+		Height:   outputs[0].Height,
+		Variants: make(map[string]oss.ObjectInfo, len(outputs)-1),
+	}
+	stored := make([]string, 0, len(outputs))
+	for _, out := range outputs {
+		putOpts := append(opts[:len(opts):len(opts)], oss.WithContentType(out.Format.ContentType()))
+		info, err := p.storage.Put(ctx, out.Key, bytes.NewReader(out.Data), int64(len(out.Data)), putOpts...)
+		if err != nil {
+			return Result{}, errors.Join(err, p.storage.DeleteMany(ctx, stored))
+		}
+		stored = append(stored, out.Key)
+		if out.Variant == "" {
+			res.Original = info
+		} else {
+			res.Variants[out.Variant] = info
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,142 @@
+package imagex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image/gif"
+)
+
+// Strip 无损去除 EXIF(含 GPS)、XMP、IPTC 与注释等元数据, 不重新编码像素.
+// 方向不为 1 时保留一个只含方向的最小 EXIF, 以便查看器正确显示原图.
+func Strip(data []byte) ([]byte, error) {
+	switch DetectFormat(data) {
+	case JPEG:
+		return stripJPEG(data)
+	case PNG:
+		return stripPNG(data)
+	case GIF:
+		return stripGIF(data)
+	case WEBP:
+		return stripWebP(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// stripJPEG 去除 APP1(EXIF/XMP)、APP13(IPTC) 与 COM 段, 保留 JFIF、ICC 等
+func stripJPEG(data []byte) ([]byte, error) {
+	orientation := Orientation(data)
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	wroteExif := false
+	sos := jpegSegments(data, func(marker byte, seg []byte) bool {
+		switch marker {
+		case 0xe1:
+			if !wroteExif && orientation != 1 && bytes.HasPrefix(seg[4:], exifHeader) {
+				wroteExif = true
+				payload := append(append([]byte{}, exifHeader...), minimalExif(orientation)...)
+				out = append(out, 0xff, 0xe1, byte((len(payload)+2)>>8), byte(len(payload)+2))
+				out = append(out, payload...)
+			}
+		case 0xed, 0xfe:
+		default:
+			out = append(out, seg...)
+		}
+		return true
+	})
+	if sos < 0 {
+		return nil, ErrMalformed
+	}
+	return append(out, data[sos:]...), nil
+}
+
+// stripPNG 去除 eXIf 与文本、时间块
+func stripPNG(data []byte) ([]byte, error) {
+	orientation := Orientation(data)
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:8]...)
+	ok := pngChunks(data, func(typ string, chunk []byte) {
+		switch typ {
+		case "eXIf":
+			if orientation != 1 {
+				out = appendPNGChunk(out, "eXIf", minimalExif(orientation))
+				orientation = 1
+			}
+		case "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out = append(out, chunk...)
+		}
+	})
+	if !ok {
+		return nil, ErrMalformed
+	}
+	return out, nil
+}
+
+func appendPNGChunk(out []byte, typ string, data []byte) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+	start := len(out)
+	out = append(out, typ...)
+	out = append(out, data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[start:]))
+}
+
+// stripGIF 重新封装以去除注释与 XMP 等应用扩展, 像素与动画参数不变
+func stripGIF(data []byte) ([]byte, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var buf bytes.Buffer
+	if err = gif.EncodeAll(&buf, g); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// stripWebP 去除 EXIF 与 XMP 块并更新 VP8X 的标志位
+func stripWebP(data []byte) ([]byte, error) {
+	const (
+		flagXMP  = 0x04
+		flagEXIF = 0x08
+	)
+	var malformed bool
+	orientation := Orientation(data)
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	ok := webpChunks(data, func(fourcc string, chunk []byte) {
+		switch fourcc {
+		case "VP8X":
+			// 标志位在块头之后的第一个字节
+			if len(chunk) < 9 {
+				malformed = true
+				return
+			}
+			start := len(out)
+			out = append(out, chunk...)
+			out[start+8] &^= flagXMP
+			if orientation == 1 {
+				out[start+8] &^= flagEXIF
+			}
+		case "EXIF":
+			if orientation != 1 {
+				exif := minimalExif(orientation)
+				out = append(out, "EXIF"...)
+				out = binary.LittleEndian.AppendUint32(out, uint32(len(exif)))
+				out = append(out, exif...)
+			}
+		case "XMP ":
+		default:
+			out = append(out, chunk...)
+			if len(chunk)&1 == 1 {
+				out = append(out, 0)
+			}
+		}
+	})
+	if !ok || malformed {
+		return nil, ErrMalformed
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
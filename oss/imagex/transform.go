@@ -0,0 +1,95 @@
+package imagex
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// Orient 按 EXIF 方向 (1-8) 旋转或翻转, 使图片以正确方向显示
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	// src 返回目标像素 (x, y) 对应的原图坐标
+	var src func(x, y int) (int, int)
+	switch orientation {
+	case 2: // 水平翻转
+		src = func(x, y int) (int, int) { return w - 1 - x, y }
+	case 3: // 旋转 180°
+		src = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case 4: // 垂直翻转
+		src = func(x, y int) (int, int) { return x, h - 1 - y }
+	case 5: // 沿主对角线翻转
+		src = func(x, y int) (int, int) { return y, x }
+	case 6: // 顺时针旋转 90°
+		src = func(x, y int) (int, int) { return y, h - 1 - x }
+	case 7: // 沿副对角线翻转
+		src = func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }
+	case 8: // 逆时针旋转 90°
+		src = func(x, y int) (int, int) { return w - 1 - y, x }
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := src(x, y)
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// Resize 按 Variant 的尺寸与模式缩放, 默认不放大
+func Resize(img image.Image, v Variant) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 || (v.Width <= 0 && v.Height <= 0) {
+		return img
+	}
+
+	srcRect := b
+	var dw, dh int
+	switch {
+	case v.Mode == Fill && v.Width > 0 && v.Height > 0:
+		// 按比例裁剪中间区域, 再缩放到正好 Width x Height
+		dw, dh = v.Width, v.Height
+		if w*dh > h*dw {
+			// 极端比例下至少保留 1 像素
+			cw := max(h*dw/dh, 1)
+			srcRect = image.Rect(b.Min.X+(w-cw)/2, b.Min.Y, b.Min.X+(w-cw)/2+cw, b.Max.Y)
+		} else {
+			ch := max(w*dh/dw, 1)
+			srcRect = image.Rect(b.Min.X, b.Min.Y+(h-ch)/2, b.Max.X, b.Min.Y+(h-ch)/2+ch)
+		}
+		if !v.Upscale && (dw > srcRect.Dx() || dh > srcRect.Dy()) {
+			dw, dh = srcRect.Dx(), srcRect.Dy()
+		}
+	default:
+		// 等比缩放到 Width x Height 之内, 为 0 的一边不限制
+		scale := 0.0
+		if v.Width > 0 {
+			scale = float64(v.Width) / float64(w)
+		}
+		if v.Height > 0 {
+			if s := float64(v.Height) / float64(h); scale == 0 || s < scale {
+				scale = s
+			}
+		}
+		if scale >= 1 && !v.Upscale {
+			scale = 1
+		}
+		dw, dh = max(int(float64(w)*scale+0.5), 1), max(int(float64(h)*scale+0.5), 1)
+	}
+	if srcRect == b && dw == w && dh == h {
+		return img
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, srcRect, draw.Src, nil)
+	return dst
+}
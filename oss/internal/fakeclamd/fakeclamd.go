@@ -0,0 +1,133 @@
+// Package fakeclamd 本地的 clamd 替身, 仅用于测试, 实现 PING 与 INSTREAM 命令,
+// 内容包含 EICAR 测试串或自定义特征时报告发现病毒.
+package fakeclamd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// EICAR 标准的杀毒软件测试串
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Server 测试用 clamd 服务
+type Server struct {
+	// Signatures 特征名到内容片段, 默认只有 Eicar-Signature
+	Signatures map[string]string
+	// StreamMaxLength 与 clamd 的同名配置相同, 超过时返回 size limit exceeded
+	StreamMaxLength int
+
+	ln    net.Listener
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	scans int
+}
+
+// New 在 127.0.0.1 的随机端口上启动服务
+func New() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{
+		Signatures:      map[string]string{"Eicar-Signature": EICAR},
+		StreamMaxLength: 25 << 20,
+		ln:              ln,
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Addr host:port
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Scans 已完成的 INSTREAM 扫描次数
+func (s *Server) Scans() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scans
+}
+
+// Close 停止服务
+func (s *Server) Close() {
+	_ = s.ln.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	// z 前缀的命令以 \0 结尾, n 前缀的以 \n 结尾
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	delim := byte('\n')
+	if prefix == 'z' {
+		delim = 0
+	}
+	cmd, err := r.ReadString(delim)
+	if err != nil {
+		return
+	}
+	reply := func(msg string) {
+		_, _ = io.WriteString(conn, msg+string(delim))
+	}
+	switch strings.TrimSuffix(cmd, string(delim)) {
+	case "PING":
+		reply("PONG")
+	case "INSTREAM":
+		var data bytes.Buffer
+		for {
+			var n uint32
+			if err = binary.Read(r, binary.BigEndian, &n); err != nil {
+				return
+			}
+			if n == 0 {
+				break
+			}
+			if data.Len()+int(n) > s.StreamMaxLength {
+				reply("INSTREAM size limit exceeded. ERROR")
+				return
+			}
+			if _, err = io.CopyN(&data, r, int64(n)); err != nil {
+				return
+			}
+		}
+		s.mu.Lock()
+		s.scans++
+		s.mu.Unlock()
+		for name, sig := range s.Signatures {
+			if bytes.Contains(data.Bytes(), []byte(sig)) {
+				reply("stream: " + name + " FOUND")
+				return
+			}
+		}
+		reply("stream: OK")
+	default:
+		reply("UNKNOWN COMMAND")
+	}
+}
@@ -0,0 +1,542 @@
+// Package fakes3 内存中的简易 S3 服务, 仅用于测试, 只实现 oss 用到的接口且不校验签名
+package fakes3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Object 存储的对象
+type Object struct {
+	Data    []byte
+	Header  http.Header
+	ModTime time.Time
+	ETag    string
+}
+
+type upload struct {
+	bucket, key string
+	header      http.Header
+	parts       map[int]*Object
+}
+
+// Server 测试用 S3 服务
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*Object
+	uploads map[string]*upload
+	// lifecycle 各桶生命周期配置的原始 XML
+	lifecycle map[string][]byte
+	seq       int
+}
+
+// New 启动服务并创建 buckets
+func New(buckets ...string) *Server {
+	s := &Server{
+		buckets:   make(map[string]map[string]*Object),
+		uploads:   make(map[string]*upload),
+		lifecycle: make(map[string][]byte),
+	}
+	for _, b := range buckets {
+		s.buckets[b] = make(map[string]*Object)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Endpoint host:port
+func (s *Server) Endpoint() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// Object 读取对象, 用于断言
+func (s *Server) Object(bucket, key string) (*Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.buckets[bucket][key]
+	return o, ok
+}
+
+// Uploads 未完成的分片上传数
+func (s *Server) Uploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+// Lifecycle 桶的生命周期配置 XML
+func (s *Server) Lifecycle(bucket string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	config, ok := s.lifecycle[bucket]
+	return config, ok
+}
+
+type errorResponse struct {
+	XMLName    xml.Name `xml:"Error"`
+	Code       string
+	Message    string
+	BucketName string
+	Key        string
+}
+
+// readBody 读取请求体, 解码 aws-chunked 分块签名格式
+func readBody(r *http.Request) ([]byte, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil || !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return data, err
+	}
+	var out []byte
+	for len(data) > 0 {
+		line, rest, ok := bytes.Cut(data, []byte("\r\n"))
+		if !ok {
+			return nil, io.ErrUnexpectedEOF
+		}
+		sizeHex, _, _ := bytes.Cut(line, []byte(";"))
+		size, err := strconv.ParseInt(string(sizeHex), 16, 64)
+		if err != nil || int64(len(rest)) < size+2 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if size == 0 {
+			break
+		}
+		out = append(out, rest[:size]...)
+		data = rest[size+2:]
+	}
+	return out, nil
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, bucket, key string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_ = xml.NewEncoder(w).Encode(errorResponse{Code: code, Message: code, BucketName: bucket, Key: key})
+	}
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// storedHeader 需要保存的请求头
+func storedHeader(h http.Header) http.Header {
+	stored := make(http.Header)
+	for k, v := range h {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-meta-") || strings.HasPrefix(lk, "x-amz-server-side-encryption") ||
+			lk == "content-type" || lk == "cache-control" || lk == "content-disposition" ||
+			lk == "content-encoding" || lk == "content-language" || lk == "expires" || lk == "x-amz-tagging" {
+			if lk == "x-amz-server-side-encryption-customer-key" {
+				continue
+			}
+			stored[k] = v
+		}
+	}
+	if stored.Get("Content-Type") == "" {
+		stored.Set("Content-Type", "binary/octet-stream")
+	}
+	return stored
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key == "" {
+		s.serveBucket(w, r, bucket, q)
+		return
+	}
+	objects, ok := s.buckets[bucket]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket", bucket, key)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		s.seq++
+		id := fmt.Sprintf("upload-%d", s.seq)
+		s.uploads[id] = &upload{bucket: bucket, key: key, header: storedHeader(r.Header), parts: make(map[int]*Object)}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: bucket, Key: key, UploadId: id})
+	case q.Has("uploadId"):
+		s.serveUpload(w, r, bucket, key, q)
+	case q.Has("tagging"):
+		s.serveTagging(w, r, bucket, key, objects)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		src, _ := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+		srcBucket, srcKey, _ := strings.Cut(strings.TrimPrefix(src, "/"), "/")
+		srcKey, _, _ = strings.Cut(srcKey, "?")
+		so, ok := s.buckets[srcBucket][srcKey]
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "NoSuchKey", srcBucket, srcKey)
+			return
+		}
+		if md5 := so.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5"); md5 != "" &&
+			r.Header.Get("X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key-Md5") != md5 {
+			writeError(w, r, http.StatusBadRequest, "InvalidRequest", srcBucket, srcKey)
+			return
+		}
+		header := so.Header
+		if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+			header = storedHeader(r.Header)
+			header.Del("X-Amz-Tagging")
+			if tagging := so.Header.Get("X-Amz-Tagging"); tagging != "" {
+				header.Set("X-Amz-Tagging", tagging)
+			}
+		}
+		if r.Header.Get("X-Amz-Tagging-Directive") == "REPLACE" {
+			header = header.Clone()
+			header.Set("X-Amz-Tagging", r.Header.Get("X-Amz-Tagging"))
+		}
+		o := &Object{Data: so.Data, Header: header, ModTime: time.Now().UTC(), ETag: so.ETag}
+		objects[key] = o
+		writeXML(w, struct {
+			XMLName      xml.Name `xml:"CopyObjectResult"`
+			LastModified string
+			ETag         string
+		}{LastModified: o.ModTime.Format(time.RFC3339), ETag: `"` + o.ETag + `"`})
+	case r.Method == http.MethodPut:
+		data, err := readBody(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "IncompleteBody", bucket, key)
+			return
+		}
+		o := &Object{Data: data, Header: storedHeader(r.Header), ModTime: time.Now().UTC(), ETag: etag(data)}
+		objects[key] = o
+		w.Header().Set("ETag", `"`+o.ETag+`"`)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		o, ok := objects[key]
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "NoSuchKey", bucket, key)
+			return
+		}
+		// SSE-C 加密的对象需提供同一密钥
+		if md5 := o.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5"); md5 != "" &&
+			r.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5") != md5 {
+			writeError(w, r, http.StatusBadRequest, "InvalidRequest", bucket, key)
+			return
+		}
+		for k, v := range o.Header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("ETag", `"`+o.ETag+`"`)
+		for k, v := range q {
+			if header, ok := strings.CutPrefix(k, "response-"); ok {
+				w.Header().Set(header, v[0])
+			}
+		}
+		http.ServeContent(w, r, "", o.ModTime, bytes.NewReader(o.Data))
+	case r.Method == http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", bucket, key)
+	}
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, bucket string, q url.Values) {
+	objects, ok := s.buckets[bucket]
+	switch {
+	case ok && q.Has("lifecycle"):
+		s.serveLifecycle(w, r, bucket)
+	case r.Method == http.MethodPut:
+		if ok {
+			writeError(w, r, http.StatusConflict, "BucketAlreadyOwnedByYou", bucket, "")
+			return
+		}
+		s.buckets[bucket] = make(map[string]*Object)
+	case !ok:
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket", bucket, "")
+	case r.Method == http.MethodHead:
+	case r.Method == http.MethodGet && q.Has("location"):
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"LocationConstraint"`
+			Value   string   `xml:",chardata"`
+		}{})
+	case r.Method == http.MethodGet:
+		s.list(w, bucket, objects, q)
+	case r.Method == http.MethodPost && q.Has("delete"):
+		var req struct {
+			Object []struct{ Key string }
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", bucket, "")
+			return
+		}
+		type deleted struct{ Key string }
+		var res struct {
+			XMLName xml.Name `xml:"DeleteResult"`
+			Deleted []deleted
+		}
+		for _, o := range req.Object {
+			delete(objects, o.Key)
+			res.Deleted = append(res.Deleted, deleted{Key: o.Key})
+		}
+		writeXML(w, res)
+	case r.Method == http.MethodPost:
+		s.postObject(w, r, bucket, objects)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", bucket, "")
+	}
+}
+
+type tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Tags    []struct {
+		Key   string
+		Value string
+	} `xml:"TagSet>Tag"`
+}
+
+func (s *Server) serveTagging(w http.ResponseWriter, r *http.Request, bucket, key string, objects map[string]*Object) {
+	o, ok := objects[key]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", bucket, key)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		values, _ := url.ParseQuery(o.Header.Get("X-Amz-Tagging"))
+		var res tagging
+		for k := range values {
+			res.Tags = append(res.Tags, struct {
+				Key   string
+				Value string
+			}{k, values.Get(k)})
+		}
+		sort.Slice(res.Tags, func(i, j int) bool { return res.Tags[i].Key < res.Tags[j].Key })
+		writeXML(w, res)
+	case http.MethodPut:
+		data, err := readBody(r)
+		var req tagging
+		if err == nil {
+			err = xml.Unmarshal(data, &req)
+		}
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", bucket, key)
+			return
+		}
+		values := url.Values{}
+		for _, t := range req.Tags {
+			values.Set(t.Key, t.Value)
+		}
+		o.Header = o.Header.Clone()
+		o.Header.Set("X-Amz-Tagging", values.Encode())
+	case http.MethodDelete:
+		o.Header = o.Header.Clone()
+		o.Header.Del("X-Amz-Tagging")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", bucket, key)
+	}
+}
+
+func (s *Server) serveLifecycle(w http.ResponseWriter, r *http.Request, bucket string) {
+	switch r.Method {
+	case http.MethodGet:
+		config, ok := s.lifecycle[bucket]
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "NoSuchLifecycleConfiguration", bucket, "")
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write(config)
+	case http.MethodPut:
+		data, err := readBody(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "IncompleteBody", bucket, "")
+			return
+		}
+		s.lifecycle[bucket] = data
+	case http.MethodDelete:
+		delete(s.lifecycle, bucket)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", bucket, "")
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, bucket string, objects map[string]*Object, q url.Values) {
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	after := q.Get("start-after")
+	if token := q.Get("continuation-token"); token != "" {
+		after = token
+	}
+	maxKeys, _ := strconv.Atoi(q.Get("max-keys"))
+	if maxKeys <= 0 || maxKeys > 1000 {
+		maxKeys = 1000
+	}
+
+	keys := make([]string, 0, len(objects))
+	for k := range objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int64
+		StorageClass string
+	}
+	type commonPrefix struct{ Prefix string }
+	var res struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Name                  string
+		Prefix                string
+		Delimiter             string
+		MaxKeys               int
+		KeyCount              int
+		IsTruncated           bool
+		NextContinuationToken string
+		Contents              []content
+		CommonPrefixes        []commonPrefix
+	}
+	res.Name, res.Prefix, res.Delimiter, res.MaxKeys = bucket, prefix, delimiter, maxKeys
+
+	seen := make(map[string]bool)
+	last := ""
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) || k <= after {
+			continue
+		}
+		if res.KeyCount == maxKeys {
+			res.IsTruncated, res.NextContinuationToken = true, last
+			break
+		}
+		if delimiter != "" {
+			if i := strings.Index(k[len(prefix):], delimiter); i >= 0 {
+				p := k[:len(prefix)+i+len(delimiter)]
+				if !seen[p] && p > after {
+					seen[p] = true
+					res.CommonPrefixes = append(res.CommonPrefixes, commonPrefix{Prefix: p})
+					res.KeyCount++
+				}
+				last = k
+				continue
+			}
+		}
+		o := objects[k]
+		res.Contents = append(res.Contents, content{
+			Key: k, LastModified: o.ModTime.Format(time.RFC3339), ETag: `"` + o.ETag + `"`,
+			Size: int64(len(o.Data)), StorageClass: "STANDARD",
+		})
+		res.KeyCount++
+		last = k
+	}
+	writeXML(w, res)
+}
+
+func (s *Server) serveUpload(w http.ResponseWriter, r *http.Request, bucket, key string, q url.Values) {
+	id := q.Get("uploadId")
+	u, ok := s.uploads[id]
+	if !ok || u.bucket != bucket || u.key != key {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload", bucket, key)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		n, err := strconv.Atoi(q.Get("partNumber"))
+		if err != nil || n < 1 || n > 10000 {
+			writeError(w, r, http.StatusBadRequest, "InvalidArgument", bucket, key)
+			return
+		}
+		data, err := readBody(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "IncompleteBody", bucket, key)
+			return
+		}
+		p := &Object{Data: data, ModTime: time.Now().UTC(), ETag: etag(data)}
+		u.parts[n] = p
+		w.Header().Set("ETag", `"`+p.ETag+`"`)
+	case http.MethodGet:
+		type part struct {
+			PartNumber   int
+			LastModified string
+			ETag         string
+			Size         int64
+		}
+		var res struct {
+			XMLName  xml.Name `xml:"ListPartsResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+			MaxParts int
+			Part     []part
+		}
+		res.Bucket, res.Key, res.UploadId, res.MaxParts = bucket, key, id, 1000
+		marker, _ := strconv.Atoi(q.Get("part-number-marker"))
+		for n, p := range u.parts {
+			if n > marker {
+				res.Part = append(res.Part, part{PartNumber: n, LastModified: p.ModTime.Format(time.RFC3339), ETag: `"` + p.ETag + `"`, Size: int64(len(p.Data))})
+			}
+		}
+		sort.Slice(res.Part, func(i, j int) bool { return res.Part[i].PartNumber < res.Part[j].PartNumber })
+		writeXML(w, res)
+	case http.MethodPost:
+		var req struct {
+			Part []struct {
+				PartNumber int
+				ETag       string
+			}
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", bucket, key)
+			return
+		}
+		var data []byte
+		for i, p := range req.Part {
+			stored, ok := u.parts[p.PartNumber]
+			if !ok || strings.Trim(p.ETag, `"`) != stored.ETag || (i > 0 && p.PartNumber <= req.Part[i-1].PartNumber) {
+				writeError(w, r, http.StatusBadRequest, "InvalidPart", bucket, key)
+				return
+			}
+			data = append(data, stored.Data...)
+		}
+		o := &Object{Data: data, Header: u.header, ModTime: time.Now().UTC(), ETag: etag(data) + "-" + strconv.Itoa(len(req.Part))}
+		s.buckets[bucket][key] = o
+		delete(s.uploads, id)
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: bucket, Key: key, ETag: `"` + o.ETag + `"`})
+	case http.MethodDelete:
+		delete(s.uploads, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", bucket, key)
+	}
+}
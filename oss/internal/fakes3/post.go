@@ -0,0 +1,127 @@
+package fakes3
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// postObject 浏览器表单直传, 校验策略条件但不校验签名
+func (s *Server) postObject(w http.ResponseWriter, r *http.Request, bucket string, objects map[string]*Object) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "MalformedPOSTRequest", bucket, "")
+		return
+	}
+	fields := make(map[string]string)
+	var (
+		data     []byte
+		filename string
+	)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedPOSTRequest", bucket, "")
+			return
+		}
+		b, _ := io.ReadAll(part)
+		if part.FormName() == "file" {
+			data, filename = b, part.FileName()
+			break
+		}
+		fields[strings.ToLower(part.FormName())] = string(b)
+	}
+	fields["bucket"] = bucket
+	key := strings.ReplaceAll(fields["key"], "${filename}", filename)
+	fields["key"] = key
+	if !checkPolicy(fields["policy"], fields, int64(len(data))) {
+		writeError(w, r, http.StatusForbidden, "AccessDenied", bucket, key)
+		return
+	}
+
+	header := make(http.Header)
+	for k, v := range fields {
+		if strings.HasPrefix(k, "x-amz-meta-") || k == "content-type" {
+			header.Set(k, v)
+		}
+	}
+	o := &Object{Data: data, Header: storedHeader(header), ModTime: time.Now().UTC(), ETag: etag(data)}
+	objects[key] = o
+
+	if redirect := fields["success_action_redirect"]; redirect != "" {
+		if u, err := url.Parse(redirect); err == nil {
+			q := u.Query()
+			q.Set("bucket", bucket)
+			q.Set("key", key)
+			q.Set("etag", `"`+o.ETag+`"`)
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.String(), http.StatusSeeOther)
+			return
+		}
+	}
+	w.Header().Set("ETag", `"`+o.ETag+`"`)
+	switch fields["success_action_status"] {
+	case "200":
+		w.WriteHeader(http.StatusOK)
+	case "201":
+		w.WriteHeader(http.StatusCreated)
+		_ = xml.NewEncoder(w).Encode(struct {
+			XMLName xml.Name `xml:"PostResponse"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: bucket, Key: key, ETag: `"` + o.ETag + `"`})
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// checkPolicy 校验 eq、starts-with 与 content-length-range 条件
+func checkPolicy(policy string, fields map[string]string, size int64) bool {
+	b, err := base64.StdEncoding.DecodeString(policy)
+	if err != nil {
+		return false
+	}
+	var p struct {
+		Expiration time.Time
+		Conditions [][]json.RawMessage
+	}
+	if err = json.Unmarshal(b, &p); err != nil || time.Now().After(p.Expiration) {
+		return false
+	}
+	for _, cond := range p.Conditions {
+		if len(cond) != 3 {
+			return false
+		}
+		var op string
+		_ = json.Unmarshal(cond[0], &op)
+		if op == "content-length-range" {
+			var lo, hi int64
+			_ = json.Unmarshal(cond[1], &lo)
+			_ = json.Unmarshal(cond[2], &hi)
+			if size < lo || size > hi {
+				return false
+			}
+			continue
+		}
+		var name, value string
+		_ = json.Unmarshal(cond[1], &name)
+		_ = json.Unmarshal(cond[2], &value)
+		got, ok := fields[strings.ToLower(strings.TrimPrefix(name, "$"))]
+		switch {
+		case !ok:
+			return false
+		case op == "eq" && got != value, op == "starts-with" && !strings.HasPrefix(got, value):
+			return false
+		}
+	}
+	return true
+}
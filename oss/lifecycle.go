@@ -0,0 +1,168 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	minIo "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// ErrInvalidLifecycle 生命周期规则无效
+var ErrInvalidLifecycle = errors.New("oss: invalid lifecycle rule")
+
+// LifecycleRule 桶的生命周期规则, 由存储服务按天执行
+type LifecycleRule struct {
+	// ID 规则名, 设置了 Dir 时保存为 Dir/ID
+	ID string `json:"id" yaml:"id"`
+	// Prefix 对象名前缀 (不含 Dir)
+	Prefix string `json:"prefix" yaml:"prefix"`
+	// Tags 对象需同时带有的标签
+	Tags map[string]string `json:"tags" yaml:"tags"`
+	// ExpireDays 对象创建多少天后删除
+	ExpireDays int `json:"expire_days" yaml:"expireDays"`
+	// AbortMultipartDays 未完成的分片上传多少天后清理, 不能与 Tags 同时使用
+	AbortMultipartDays int  `json:"abort_multipart_days" yaml:"abortMultipartDays"`
+	Disabled           bool `json:"disabled" yaml:"disabled"`
+}
+
+// PendingExpirationRule 删除 days 天后仍未确认的上传
+func PendingExpirationRule(days int) LifecycleRule {
+	return LifecycleRule{
+		ID:         "expire-pending",
+		Tags:       map[string]string{PendingTagKey: PendingTagValue},
+		ExpireDays: days,
+	}
+}
+
+func (r LifecycleRule) validate() error {
+	switch {
+	case r.ID == "" || strings.Contains(r.ID, "/"):
+		return fmt.Errorf("%w: id %q", ErrInvalidLifecycle, r.ID)
+	case r.ExpireDays < 0 || r.AbortMultipartDays < 0 || r.ExpireDays+r.AbortMultipartDays == 0:
+		return fmt.Errorf("%w: %s has no action", ErrInvalidLifecycle, r.ID)
+	case r.AbortMultipartDays > 0 && len(r.Tags) > 0:
+		return fmt.Errorf("%w: %s aborts multipart uploads with tag filter", ErrInvalidLifecycle, r.ID)
+	}
+	return nil
+}
+
+// lifecycleID 规则名加上 Dir 前缀, 区分共用一个桶的各个 Dir
+func (o *OssUtil) lifecycleID(id string) string {
+	if dir := strings.Trim(o.Config.Dir, "/"); dir != "" {
+		return dir + "/" + id
+	}
+	return id
+}
+
+// ownsLifecycle 规则是否属于当前 Dir
+func (o *OssUtil) ownsLifecycle(id string) bool {
+	dir := strings.Trim(o.Config.Dir, "/")
+	return dir == "" || strings.HasPrefix(id, dir+"/")
+}
+
+func (o *OssUtil) lifecycleConfig(ctx context.Context) (*lifecycle.Configuration, error) {
+	config, err := o.Client.GetBucketLifecycle(ctx, o.Config.BucketName)
+	var resp minIo.ErrorResponse
+	if errors.As(err, &resp) && resp.Code == "NoSuchLifecycleConfiguration" {
+		return lifecycle.NewConfiguration(), nil
+	}
+	return config, err
+}
+
+// SetLifecycle 替换当前 Dir 的全部生命周期规则, rules 为空时删除;
+// 其他 Dir 的规则保持不变, 没有设置 Dir 时替换整个桶的规则.
+func (o *OssUtil) SetLifecycle(ctx context.Context, rules ...LifecycleRule) error {
+	config, err := o.lifecycleConfig(ctx)
+	if err != nil {
+		return wrapError("lifecycle", o.Config.BucketName, err)
+	}
+	config.Rules = slices.DeleteFunc(config.Rules, func(r lifecycle.Rule) bool {
+		return o.ownsLifecycle(r.ID)
+	})
+	for _, r := range rules {
+		if err = r.validate(); err != nil {
+			return wrapError("lifecycle", o.Config.BucketName, err)
+		}
+		prefix, err := cleanPrefix(r.Prefix)
+		if err != nil {
+			return wrapError("lifecycle", r.Prefix, err)
+		}
+		if dir := strings.Trim(o.Config.Dir, "/"); dir != "" {
+			prefix = dir + "/" + prefix
+		}
+		rule := lifecycle.Rule{
+			ID:     o.lifecycleID(r.ID),
+			Status: "Enabled",
+			Expiration: lifecycle.Expiration{
+				Days: lifecycle.ExpirationDays(r.ExpireDays),
+			},
+			AbortIncompleteMultipartUpload: lifecycle.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: lifecycle.ExpirationDays(r.AbortMultipartDays),
+			},
+		}
+		if r.Disabled {
+			rule.Status = "Disabled"
+		}
+		tags := make([]lifecycle.Tag, 0, len(r.Tags))
+		for k, v := range r.Tags {
+			tags = append(tags, lifecycle.Tag{Key: k, Value: v})
+		}
+		slices.SortFunc(tags, func(a, b lifecycle.Tag) int { return strings.Compare(a.Key, b.Key) })
+		switch {
+		case len(tags) == 0:
+			rule.RuleFilter.Prefix = prefix
+		case len(tags) == 1 && prefix == "":
+			rule.RuleFilter.Tag = tags[0]
+		default:
+			rule.RuleFilter.And = lifecycle.And{Prefix: prefix, Tags: tags}
+		}
+		config.Rules = append(config.Rules, rule)
+	}
+	return wrapError("lifecycle", o.Config.BucketName, o.Client.SetBucketLifecycle(ctx, o.Config.BucketName, config))
+}
+
+// Lifecycle 获取当前 Dir 的生命周期规则
+func (o *OssUtil) Lifecycle(ctx context.Context) ([]LifecycleRule, error) {
+	config, err := o.lifecycleConfig(ctx)
+	if err != nil {
+		return nil, wrapError("lifecycle", o.Config.BucketName, err)
+	}
+	var rules []LifecycleRule
+	for _, r := range config.Rules {
+		if !o.ownsLifecycle(r.ID) {
+			continue
+		}
+		rule := LifecycleRule{
+			ID:                 strings.TrimPrefix(r.ID, o.lifecycleID("")),
+			Prefix:             r.RuleFilter.Prefix,
+			ExpireDays:         int(r.Expiration.Days),
+			AbortMultipartDays: int(r.AbortIncompleteMultipartUpload.DaysAfterInitiation),
+			Disabled:           r.Status != "Enabled",
+		}
+		tags := r.RuleFilter.And.Tags
+		if !r.RuleFilter.And.IsEmpty() {
+			rule.Prefix = r.RuleFilter.And.Prefix
+		} else if !r.RuleFilter.Tag.IsEmpty() {
+			tags = []lifecycle.Tag{r.RuleFilter.Tag}
+		}
+		if rule.Prefix == "" {
+			rule.Prefix = r.Prefix
+		}
+		rule.Prefix = o.name(rule.Prefix)
+		if dir := strings.Trim(o.Config.Dir, "/"); rule.Prefix == dir+"/" {
+			rule.Prefix = ""
+		}
+		for _, t := range tags {
+			if rule.Tags == nil {
+				rule.Tags = make(map[string]string, len(tags))
+			}
+			rule.Tags[t.Key] = t.Value
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
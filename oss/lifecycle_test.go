@@ -0,0 +1,285 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"iter"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOssUtil_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	o, srv := newTestOss(t)
+
+	rules, err := o.Lifecycle(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	want := []LifecycleRule{
+		PendingExpirationRule(1),
+		{ID: "logs", Prefix: "logs/", ExpireDays: 30, AbortMultipartDays: 2},
+		{ID: "tmp", Prefix: "tmp/", Tags: map[string]string{"a": "1", "b": "2"}, ExpireDays: 7, Disabled: true},
+	}
+	require.NoError(t, o.SetLifecycle(ctx, want...))
+	rules, err = o.Lifecycle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, rules)
+	config, ok := srv.Lifecycle("bucket")
+	require.True(t, ok)
+	assert.Contains(t, string(config), "<ID>temp/expire-pending</ID>")
+	assert.Contains(t, string(config), "<Prefix>temp/logs/</Prefix>")
+
+	// 其他 Dir 的规则互不影响
+	other := NewOssUtil(o.Config)
+	other.Config.Dir = "other"
+	require.NoError(t, other.SetLifecycle(ctx, LifecycleRule{ID: "logs", ExpireDays: 1}))
+	rules, err = o.Lifecycle(ctx)
+	require.NoError(t, err)
+	assert.Len(t, rules, 3)
+	require.NoError(t, o.SetLifecycle(ctx))
+	rules, err = o.Lifecycle(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+	rules, err = other.Lifecycle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []LifecycleRule{{ID: "logs", ExpireDays: 1}}, rules)
+
+	for _, r := range []LifecycleRule{
+		{ExpireDays: 1},
+		{ID: "a/b", ExpireDays: 1},
+		{ID: "none"},
+		{ID: "abort", Tags: map[string]string{"a": "1"}, AbortMultipartDays: 1},
+	} {
+		assert.ErrorIs(t, o.SetLifecycle(ctx, r), ErrInvalidLifecycle, r.ID)
+	}
+	assert.ErrorIs(t, o.SetLifecycle(ctx, LifecycleRule{ID: "bad", Prefix: "../", ExpireDays: 1}), ErrInvalidKey)
+}
+
+func TestOssUtil_Tags(t *testing.T) {
+	ctx := context.Background()
+	_, srv := newTestOss(t)
+	o := NewOssUtil(OssUtilConfig{
+		EndPoint:        srv.Endpoint(),
+		Region:          "us-east-1",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		BucketName:      "bucket",
+		Dir:             "temp",
+	})
+
+	_, err := o.Put(ctx, "a.txt", strings.NewReader("a"), 1, WithPending(), WithTags(map[string]string{"k": "v"}))
+	require.NoError(t, err)
+	tags, err := o.Tags(ctx, "a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k": "v", PendingTagKey: PendingTagValue}, tags)
+
+	// 复制时默认保留标签, 指定标签时替换
+	_, err = o.Copy(ctx, "a.txt", "b.txt")
+	require.NoError(t, err)
+	pending, err := IsPending(ctx, o, "b.txt")
+	require.NoError(t, err)
+	assert.True(t, pending)
+	_, err = o.Copy(ctx, "a.txt", "c.txt", WithTags(map[string]string{"k": "c"}))
+	require.NoError(t, err)
+	tags, err = o.Tags(ctx, "c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k": "c"}, tags)
+
+	require.NoError(t, Confirm(ctx, o, "a.txt"))
+	tags, err = o.Tags(ctx, "a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"k": "v"}, tags)
+	require.NoError(t, o.SetTags(ctx, "a.txt", nil))
+	tags, err = o.Tags(ctx, "a.txt")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+	_, err = o.Tags(ctx, "none")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 预签名上传需带上返回的标签头
+	u, header, err := o.PresignedPutPending(ctx, "up.txt", time.Minute)
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPut, u.String(), strings.NewReader("up"))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	obj, ok := srv.Object("bucket", "temp/up.txt")
+	require.True(t, ok)
+	assert.Equal(t, PendingTagKey+"="+PendingTagValue, obj.Header.Get("X-Amz-Tagging"))
+	pending, err = IsPending(ctx, o, "up.txt")
+	require.NoError(t, err)
+	assert.True(t, pending)
+}
+
+func TestMemoryStorage_Tags(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStorage()
+	_, err := m.Put(ctx, "a", strings.NewReader("a"), 1, WithPending())
+	require.NoError(t, err)
+	_, err = m.Copy(ctx, "a", "b")
+	require.NoError(t, err)
+	require.NoError(t, Confirm(ctx, m, "a"))
+	pending, err := IsPending(ctx, m, "a")
+	require.NoError(t, err)
+	assert.False(t, pending)
+	pending, err = IsPending(ctx, m, "b")
+	require.NoError(t, err)
+	assert.True(t, pending)
+
+	// 租户视图的标签作用于带前缀的对象
+	tenant, err := NewTenant(m, "acme")
+	require.NoError(t, err)
+	_, err = tenant.Put(ctx, "c", strings.NewReader("c"), 1, WithPending())
+	require.NoError(t, err)
+	tags, err := m.Tags(ctx, "tenants/acme/c")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{PendingTagKey: PendingTagValue}, tags)
+	require.NoError(t, Confirm(ctx, tenant, "c"))
+	_, err = tenant.Tags(ctx, "none")
+	assert.EqualError(t, err, "oss: tags none: oss: object not found")
+
+	ls, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	local, err := NewTenant(ls, "acme")
+	require.NoError(t, err)
+	_, err = local.Tags(ctx, "c")
+	assert.ErrorIs(t, err, ErrNotSupported)
+	_, err = ls.Put(ctx, "c", strings.NewReader("c"), 1, WithPending())
+	assert.ErrorIs(t, err, ErrNotSupported)
+	_, err = ls.Put(ctx, "c", strings.NewReader("c"), 1)
+	require.NoError(t, err)
+	_, err = ls.Copy(ctx, "c", "d", WithTags(map[string]string{"k": "v"}))
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+func TestSweeper(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range map[string]Storage{
+		"memory": NewMemoryStorage(),
+		"oss":    func() Storage { o, _ := newTestOss(t); return o }(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, n := range []string{"up/a", "up/b", "up/c"} {
+				_, err := store.Put(ctx, n, strings.NewReader(n), int64(len(n)), WithPending())
+				require.NoError(t, err)
+			}
+			_, err := store.Put(ctx, "up/done", strings.NewReader("done"), 4)
+			require.NoError(t, err)
+			_, err = store.Put(ctx, "other/x", strings.NewReader("x"), 1, WithPending())
+			require.NoError(t, err)
+			require.NoError(t, Confirm(ctx, store.(Tagger), "up/c"))
+
+			var out bytes.Buffer
+			s, err := NewSweeper(store, time.Hour, WithSweepPrefix("up/"), WithSweepDryRun(), WithSweepOutput(&out))
+			require.NoError(t, err)
+			res, err := s.Sweep(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, res.Expired)
+			assert.Equal(t, 4, res.Scanned)
+
+			// 一小时后 a 与 b 过期
+			s.now = func() time.Time { return time.Now().Add(time.Hour + time.Second) }
+			res, err = s.Sweep(ctx)
+			require.NoError(t, err)
+			require.Len(t, res.Expired, 2)
+			assert.Equal(t, "up/a", res.Expired[0].Key)
+			assert.Equal(t, int64(8), res.Bytes)
+			assert.Zero(t, res.Deleted)
+			assert.True(t, res.DryRun)
+			assert.Contains(t, out.String(), "would delete up/a (4 bytes, modified ")
+			assert.Contains(t, out.String(), "sweep: scanned 4, would delete 2 pending objects older than 1h0m0s (8 bytes)\n")
+			_, err = store.Stat(ctx, "up/a")
+			require.NoError(t, err)
+
+			out.Reset()
+			s.dryRun = false
+			s.batch = 1
+			res, err = s.Sweep(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 2, res.Deleted)
+			assert.Equal(t, "deleted up/a\ndeleted up/b\nsweep: scanned 4, deleted 2 pending objects older than 1h0m0s (8 bytes)\n", out.String())
+			for n, want := range map[string]bool{"up/a": false, "up/b": false, "up/c": true, "up/done": true, "other/x": true} {
+				_, err = store.Stat(ctx, n)
+				assert.Equal(t, want, err == nil, n)
+			}
+		})
+	}
+
+	ls, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	_, err = NewSweeper(ls, time.Hour)
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+// sweepRaceStorage 列举到 trigger 时修改之前列出的对象, 模拟清理期间的确认与覆盖
+type sweepRaceStorage struct {
+	*MemoryStorage
+	trigger string
+	race    func()
+}
+
+func (s *sweepRaceStorage) List(ctx context.Context, prefix string, opts ...ListOption) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		for info, err := range s.MemoryStorage.List(ctx, prefix, opts...) {
+			if info.Key == s.trigger {
+				s.race()
+			}
+			if !yield(info, err) {
+				return
+			}
+		}
+	}
+}
+
+func TestSweeper_Race(t *testing.T) {
+	ctx := context.Background()
+	store := &sweepRaceStorage{MemoryStorage: NewMemoryStorage(), trigger: "d"}
+	for _, n := range []string{"a", "b", "c", "d"} {
+		_, err := store.Put(ctx, n, strings.NewReader(n), 1, WithPending())
+		require.NoError(t, err)
+	}
+	store.race = func() {
+		// a 被确认, b 被覆盖为新的未确认上传
+		require.NoError(t, Confirm(ctx, store, "a"))
+		_, err := store.Put(ctx, "b", strings.NewReader("new"), 3, WithPending())
+		require.NoError(t, err)
+	}
+	s, err := NewSweeper(store, 0)
+	require.NoError(t, err)
+	s.now = func() time.Time { return time.Now().Add(time.Second) }
+	res, err := s.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Deleted)
+	for n, want := range map[string]bool{"a": true, "b": true, "c": false, "d": false} {
+		_, err = store.Stat(ctx, n)
+		assert.Equal(t, want, err == nil, n)
+	}
+}
+
+func TestSweeper_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := NewMemoryStorage()
+	_, err := store.Put(ctx, "a", strings.NewReader("a"), 1, WithPending())
+	require.NoError(t, err)
+	s, err := NewSweeper(store, 0)
+	require.NoError(t, err)
+	done := make(chan error)
+	go func() { done <- s.Run(ctx, time.Millisecond) }()
+	assert.Eventually(t, func() bool {
+		_, err := store.Stat(context.Background(), "a")
+		return err != nil
+	}, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,108 @@
+package oss
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"strings"
+
+	minIo "github.com/minio/minio-go/v7"
+)
+
+// ListOption 列举选项
+type ListOption func(*minIo.ListObjectsOptions)
+
+// WithNonRecursive 只列出当前层级, 子目录以 IsDir 的 ObjectInfo 返回
+func WithNonRecursive() ListOption {
+	return func(o *minIo.ListObjectsOptions) {
+		o.Recursive = false
+	}
+}
+
+// WithStartAfter 从 name 之后开始列举 (不含 name)
+func WithStartAfter(name string) ListOption {
+	return func(o *minIo.ListObjectsOptions) {
+		o.StartAfter = name
+	}
+}
+
+func (o *OssUtil) listOptions(prefix string, opts []ListOption) (minIo.ListObjectsOptions, error) {
+	options := minIo.ListObjectsOptions{Recursive: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	// prefix 可以为空或以 "/" 结尾, 不能直接用 Key
+	prefix, err := cleanPrefix(prefix)
+	if err != nil {
+		return options, err
+	}
+	after, err := cleanPrefix(options.StartAfter)
+	if err != nil {
+		return options, err
+	}
+	if dir := strings.Trim(o.Config.Dir, "/"); dir != "" {
+		prefix = dir + "/" + prefix
+		if after != "" {
+			after = dir + "/" + after
+		}
+	}
+	options.Prefix, options.StartAfter = prefix, after
+	return options, nil
+}
+
+// List 列出 prefix 下的对象, 默认递归, 按 key 升序; 遍历中止时停止请求
+func (o *OssUtil) List(ctx context.Context, prefix string, opts ...ListOption) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		options, err := o.listOptions(prefix, opts)
+		if err != nil {
+			yield(ObjectInfo{}, wrapError("list", prefix, err))
+			return
+		}
+		delimiter := ""
+		if !options.Recursive {
+			delimiter = "/"
+		}
+		// 逐页请求, 同一页内的对象与公共前缀按 key 合并, 保证整体有序
+		core := minIo.Core{Client: o.Client}
+		token := ""
+		for {
+			if err = ctx.Err(); err != nil {
+				yield(ObjectInfo{}, wrapError("list", prefix, err))
+				return
+			}
+			res, err := core.ListObjectsV2(o.Config.BucketName, options.Prefix, options.StartAfter, token, delimiter, options.MaxKeys)
+			if err != nil {
+				yield(ObjectInfo{}, wrapError("list", prefix, err))
+				return
+			}
+			page := make([]ObjectInfo, 0, len(res.Contents)+len(res.CommonPrefixes))
+			for _, info := range res.Contents {
+				page = append(page, o.toObjectInfo(info))
+			}
+			for _, p := range res.CommonPrefixes {
+				page = append(page, ObjectInfo{Key: o.name(p.Prefix), IsDir: true})
+			}
+			slices.SortFunc(page, func(a, b ObjectInfo) int { return strings.Compare(a.Key, b.Key) })
+			for _, info := range page {
+				if !yield(info, nil) {
+					return
+				}
+			}
+			if !res.IsTruncated {
+				return
+			}
+			token = res.NextContinuationToken
+		}
+	}
+}
+
+// ListPage 分页列举, 返回最多 limit 个对象以及下一页的 startAfter, 为空表示没有更多
+func (o *OssUtil) ListPage(ctx context.Context, prefix, startAfter string, limit int, opts ...ListOption) ([]ObjectInfo, string, error) {
+	if startAfter != "" {
+		opts = append(opts, WithStartAfter(startAfter))
+	}
+	opts = append(opts, func(lo *minIo.ListObjectsOptions) {
+		lo.MaxKeys = limit + 1
+	})
+	return collectPage(o.List(ctx, prefix, opts...), limit)
+}
@@ -0,0 +1,365 @@
+package oss
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"iter"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// localMetaDir 元数据目录, 对象名不能以它开头
+const localMetaDir = ".meta"
+
+type localMeta struct {
+	ContentType string            `json:"content_type"`
+	ETag        string            `json:"etag"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// LocalStorage 本地文件系统实现, 用于本地开发.
+// 对象保存在 root/name, 元数据保存在 root/.meta/name.json;
+// 与 S3 不同, 同一目录下不能同时存在对象 "a" 与 "a/b"; 不支持标签与服务端加密, 相关选项返回 ErrNotSupported.
+type LocalStorage struct {
+	root    string
+	presign *presigner
+}
+
+// NewLocalStorage 以 root 为根目录创建本地存储, 目录不存在时自动创建
+func NewLocalStorage(root string, opts ...StorageOption) (*LocalStorage, error) {
+	var options storageOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Join(root, localMetaDir), 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{root: root, presign: options.presign}, nil
+}
+
+// Handler 预签名链接的 HTTP 处理器, 需配合 WithPresign 使用
+func (l *LocalStorage) Handler() http.Handler {
+	return presignHandler(l.presign, l)
+}
+
+// path 返回对象与元数据文件的路径
+func (l *LocalStorage) path(name string) (key, file, meta string, err error) {
+	key, err = cleanName(name)
+	if err != nil {
+		return "", "", "", err
+	}
+	if key == localMetaDir || strings.HasPrefix(key, localMetaDir+"/") {
+		return "", "", "", ErrInvalidKey
+	}
+	file = filepath.Join(l.root, filepath.FromSlash(key))
+	// 再确认拼接后的路径仍在根目录下
+	if rel, err := filepath.Rel(l.root, file); err != nil || !filepath.IsLocal(rel) {
+		return "", "", "", ErrInvalidKey
+	}
+	return key, file, filepath.Join(l.root, localMetaDir, filepath.FromSlash(key)+".json"), nil
+}
+
+func (l *LocalStorage) readMeta(file string) (localMeta, error) {
+	var meta localMeta
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(b, &meta)
+	return meta, err
+}
+
+func (l *LocalStorage) stat(key, file, meta string) (ObjectInfo, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ObjectInfo{}, ErrNotFound
+		}
+		return ObjectInfo{}, err
+	}
+	if fi.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+	m, err := l.readMeta(meta)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ETag:         m.ETag,
+		ContentType:  m.ContentType,
+		LastModified: fi.ModTime().UTC(),
+		Metadata:     m.Metadata,
+	}, nil
+}
+
+// write 先写临时文件再重命名, 保证读者看不到写了一半的对象
+func (l *LocalStorage) write(key, file, meta string, r io.Reader, size int64, m localMeta) (ObjectInfo, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return ObjectInfo{}, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(file), ".upload-*")
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer os.Remove(f.Name())
+
+	h := md5.New()
+	if size >= 0 {
+		r = io.LimitReader(r, size)
+	}
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if err == nil && size >= 0 && n < size {
+		err = io.ErrUnexpectedEOF
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	m.ETag = hex.EncodeToString(h.Sum(nil))
+	b, err := json.Marshal(m)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = os.MkdirAll(filepath.Dir(meta), 0o755); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = os.WriteFile(meta, b, 0o644); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = os.Rename(f.Name(), file); err != nil {
+		return ObjectInfo{}, err
+	}
+	return l.stat(key, file, meta)
+}
+
+// Put 上传对象, size 未知时传 -1
+func (l *LocalStorage) Put(_ context.Context, name string, r io.Reader, size int64, opts ...PutOption) (ObjectInfo, error) {
+	key, file, meta, err := l.path(name)
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	options := putOptions(opts)
+	// 不能静默丢弃 pending 等标签
+	if options.ServerSideEncryption != nil || len(options.UserTags) > 0 {
+		return ObjectInfo{}, wrapError("put", name, ErrNotSupported)
+	}
+	if options.ContentType == "" {
+		options.ContentType, r = detectContentType(key, r)
+	}
+	info, err := l.write(key, file, meta, r, size, localMeta{
+		ContentType: options.ContentType,
+		Metadata:    canonicalMetadata(options.UserMetadata),
+	})
+	return info, wrapError("put", name, err)
+}
+
+// Get 下载对象, 返回的 ReadCloser 在未指定范围时实现 io.Seeker
+func (l *LocalStorage) Get(_ context.Context, name string, opts ...GetOption) (io.ReadCloser, ObjectInfo, error) {
+	g, err := parseGetOptions(opts)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	key, file, meta, err := l.path(name)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	info, err := l.stat(key, file, meta)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	if g.matchETag != "" && g.matchETag != info.ETag {
+		return nil, ObjectInfo{}, wrapError("get", name, ErrPreconditionFailed)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	if !g.ranged {
+		return f, info, nil
+	}
+	offset, length := g.section(info.Size)
+	info.Size = length
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(f, offset, length), f}, info, nil
+}
+
+// Stat 获取对象信息
+func (l *LocalStorage) Stat(_ context.Context, name string) (ObjectInfo, error) {
+	key, file, meta, err := l.path(name)
+	if err != nil {
+		return ObjectInfo{}, wrapError("stat", name, err)
+	}
+	info, err := l.stat(key, file, meta)
+	return info, wrapError("stat", name, err)
+}
+
+// List 列出 prefix 下的对象, 默认递归, 按 key 升序
+func (l *LocalStorage) List(_ context.Context, prefix string, opts ...ListOption) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		clean, err := cleanPrefix(prefix)
+		if err != nil {
+			yield(ObjectInfo{}, wrapError("list", prefix, err))
+			return
+		}
+		// 只遍历 prefix 所在的目录
+		dir := filepath.Join(l.root, filepath.FromSlash(path.Dir("/"+clean+"_")))
+		var infos []ObjectInfo
+		err = filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			rel, err := filepath.Rel(l.root, file)
+			if err != nil {
+				return err
+			}
+			key := filepath.ToSlash(rel)
+			if d.IsDir() {
+				if key == localMetaDir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".upload-") || !strings.HasPrefix(key, clean) {
+				return nil
+			}
+			info, err := l.stat(key, file, filepath.Join(l.root, localMetaDir, rel+".json"))
+			if err != nil {
+				if errors.Is(err, ErrNotFound) {
+					return nil
+				}
+				return err
+			}
+			infos = append(infos, info)
+			return nil
+		})
+		if err != nil {
+			yield(ObjectInfo{}, wrapError("list", prefix, err))
+			return
+		}
+		slices.SortFunc(infos, func(a, b ObjectInfo) int { return strings.Compare(a.Key, b.Key) })
+		for info, err := range listInfos(infos, clean, opts) {
+			if !yield(info, err) {
+				return
+			}
+		}
+	}
+}
+
+// ListPage 分页列举, 返回最多 limit 个对象以及下一页的 startAfter, 为空表示没有更多
+func (l *LocalStorage) ListPage(ctx context.Context, prefix, startAfter string, limit int, opts ...ListOption) ([]ObjectInfo, string, error) {
+	return collectPage(l.List(ctx, prefix, append(opts, WithStartAfter(startAfter))...), limit)
+}
+
+// Copy 复制对象, 指定 opts 时替换目标的元数据
+func (l *LocalStorage) Copy(_ context.Context, src, dst string, opts ...PutOption) (ObjectInfo, error) {
+	srcKey, srcFile, srcMeta, err := l.path(src)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", src, err)
+	}
+	dstKey, dstFile, dstMeta, err := l.path(dst)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", dst, err)
+	}
+	info, err := l.stat(srcKey, srcFile, srcMeta)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", src, err)
+	}
+	m := localMeta{ContentType: info.ContentType, Metadata: info.Metadata}
+	if len(opts) > 0 {
+		options := putOptions(opts)
+		if options.ServerSideEncryption != nil || len(options.UserTags) > 0 {
+			return ObjectInfo{}, wrapError("copy", dst, ErrNotSupported)
+		}
+		m = localMeta{ContentType: options.ContentType, Metadata: canonicalMetadata(options.UserMetadata)}
+	}
+	f, err := os.Open(srcFile)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", src, err)
+	}
+	defer f.Close()
+	info, err = l.write(dstKey, dstFile, dstMeta, f, info.Size, m)
+	return info, wrapError("copy", dst, err)
+}
+
+// Delete 删除对象并清理空目录, 对象不存在时不返回错误
+func (l *LocalStorage) Delete(_ context.Context, name string) error {
+	_, file, meta, err := l.path(name)
+	if err != nil {
+		return wrapError("delete", name, err)
+	}
+	if fi, err := os.Stat(file); err == nil && fi.IsDir() {
+		return nil
+	}
+	for _, p := range []string{file, meta} {
+		if err = os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return wrapError("delete", name, err)
+		}
+	}
+	l.removeEmptyDirs(filepath.Dir(file), l.root)
+	l.removeEmptyDirs(filepath.Dir(meta), filepath.Join(l.root, localMetaDir))
+	return nil
+}
+
+func (l *LocalStorage) removeEmptyDirs(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// DeleteMany 批量删除, 返回每个失败对象的错误 (errors.Join)
+func (l *LocalStorage) DeleteMany(ctx context.Context, names []string) error {
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, l.Delete(ctx, name))
+	}
+	return errors.Join(errs...)
+}
+
+// PresignedGet 返回有效期为 expires 的下载链接, 需配合 WithPresign 与 Handler 使用
+func (l *LocalStorage) PresignedGet(_ context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error) {
+	if _, _, _, err := l.path(name); err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	return l.presign.sign(http.MethodGet, name, expires, presignParams(opts))
+}
+
+// PresignedHead 返回有效期为 expires 的 HEAD 链接, 与下载链接的签名相同
+func (l *LocalStorage) PresignedHead(ctx context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error) {
+	return l.PresignedGet(ctx, name, expires, opts...)
+}
+
+// PresignedPut 返回有效期为 expires 的上传链接, 需配合 WithPresign 与 Handler 使用
+func (l *LocalStorage) PresignedPut(_ context.Context, name string, expires time.Duration) (*url.URL, error) {
+	if _, _, _, err := l.path(name); err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	return l.presign.sign(http.MethodPut, name, expires, nil)
+}
@@ -0,0 +1,232 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"iter"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+	tags map[string]string
+}
+
+// MemoryStorage 内存实现, 用于单元测试
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string]*memoryObject
+	presign *presigner
+}
+
+// NewMemoryStorage 创建内存存储
+func NewMemoryStorage(opts ...StorageOption) *MemoryStorage {
+	var options storageOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &MemoryStorage{
+		objects: make(map[string]*memoryObject),
+		presign: options.presign,
+	}
+}
+
+// Handler 预签名链接的 HTTP 处理器, 需配合 WithPresign 使用
+func (m *MemoryStorage) Handler() http.Handler {
+	return presignHandler(m.presign, m)
+}
+
+func (m *MemoryStorage) put(name string, data []byte, options ObjectInfo, tags map[string]string) ObjectInfo {
+	sum := md5.Sum(data)
+	info := ObjectInfo{
+		Key:          name,
+		Size:         int64(len(data)),
+		ETag:         hex.EncodeToString(sum[:]),
+		ContentType:  options.ContentType,
+		LastModified: time.Now().UTC(),
+		Metadata:     options.Metadata,
+	}
+	m.mu.Lock()
+	m.objects[name] = &memoryObject{data: data, info: info, tags: maps.Clone(tags)}
+	m.mu.Unlock()
+	return cloneInfo(info)
+}
+
+// Put 上传对象, size 未知时传 -1
+func (m *MemoryStorage) Put(_ context.Context, name string, r io.Reader, size int64, opts ...PutOption) (ObjectInfo, error) {
+	key, err := cleanName(name)
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	options := putOptions(opts)
+	if options.ServerSideEncryption != nil {
+		return ObjectInfo{}, wrapError("put", name, ErrNotSupported)
+	}
+	if options.ContentType == "" {
+		options.ContentType, r = detectContentType(key, r)
+	}
+	data, err := readAll(r, size)
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	return m.put(key, data, ObjectInfo{
+		ContentType: options.ContentType,
+		Metadata:    canonicalMetadata(options.UserMetadata),
+	}, options.UserTags), nil
+}
+
+func (m *MemoryStorage) object(op, name string) (*memoryObject, error) {
+	key, err := cleanName(name)
+	if err != nil {
+		return nil, wrapError(op, name, err)
+	}
+	m.mu.RLock()
+	obj, ok := m.objects[key]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, wrapError(op, name, ErrNotFound)
+	}
+	return obj, nil
+}
+
+// Get 下载对象
+func (m *MemoryStorage) Get(_ context.Context, name string, opts ...GetOption) (io.ReadCloser, ObjectInfo, error) {
+	g, err := parseGetOptions(opts)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	obj, err := m.object("get", name)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	if g.matchETag != "" && g.matchETag != obj.info.ETag {
+		return nil, ObjectInfo{}, wrapError("get", name, ErrPreconditionFailed)
+	}
+	offset, length := g.section(obj.info.Size)
+	info := cloneInfo(obj.info)
+	info.Size = length
+	return readSeekNopCloser{bytes.NewReader(obj.data[offset : offset+length])}, info, nil
+}
+
+// Stat 获取对象信息
+func (m *MemoryStorage) Stat(_ context.Context, name string) (ObjectInfo, error) {
+	obj, err := m.object("stat", name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return cloneInfo(obj.info), nil
+}
+
+// List 列出 prefix 下的对象, 默认递归, 按 key 升序
+func (m *MemoryStorage) List(_ context.Context, prefix string, opts ...ListOption) iter.Seq2[ObjectInfo, error] {
+	m.mu.RLock()
+	infos := make([]ObjectInfo, 0, len(m.objects))
+	for _, obj := range m.objects {
+		infos = append(infos, cloneInfo(obj.info))
+	}
+	m.mu.RUnlock()
+	slices.SortFunc(infos, func(a, b ObjectInfo) int { return strings.Compare(a.Key, b.Key) })
+	return listInfos(infos, prefix, opts)
+}
+
+// ListPage 分页列举, 返回最多 limit 个对象以及下一页的 startAfter, 为空表示没有更多
+func (m *MemoryStorage) ListPage(ctx context.Context, prefix, startAfter string, limit int, opts ...ListOption) ([]ObjectInfo, string, error) {
+	return collectPage(m.List(ctx, prefix, append(opts, WithStartAfter(startAfter))...), limit)
+}
+
+// Copy 复制对象, 指定 opts 时替换目标的元数据
+func (m *MemoryStorage) Copy(_ context.Context, src, dst string, opts ...PutOption) (ObjectInfo, error) {
+	obj, err := m.object("copy", src)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	key, err := cleanName(dst)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", dst, err)
+	}
+	m.mu.RLock()
+	info, tags := obj.info, obj.tags
+	m.mu.RUnlock()
+	if len(opts) > 0 {
+		options := putOptions(opts)
+		if options.ServerSideEncryption != nil {
+			return ObjectInfo{}, wrapError("copy", dst, ErrNotSupported)
+		}
+		info.ContentType = options.ContentType
+		info.Metadata = canonicalMetadata(options.UserMetadata)
+		if len(options.UserTags) > 0 {
+			tags = options.UserTags
+		}
+	}
+	return m.put(key, obj.data, cloneInfo(info), tags), nil
+}
+
+// Delete 删除对象, 对象不存在时不返回错误
+func (m *MemoryStorage) Delete(_ context.Context, name string) error {
+	key, err := cleanName(name)
+	if err != nil {
+		return wrapError("delete", name, err)
+	}
+	m.mu.Lock()
+	delete(m.objects, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// DeleteMany 批量删除, 返回每个失败对象的错误 (errors.Join)
+func (m *MemoryStorage) DeleteMany(ctx context.Context, names []string) error {
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, m.Delete(ctx, name))
+	}
+	return errors.Join(errs...)
+}
+
+// PresignedGet 返回有效期为 expires 的下载链接, 需配合 WithPresign 与 Handler 使用
+func (m *MemoryStorage) PresignedGet(_ context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error) {
+	return m.presign.sign(http.MethodGet, name, expires, presignParams(opts))
+}
+
+// PresignedHead 返回有效期为 expires 的 HEAD 链接, 与下载链接的签名相同
+func (m *MemoryStorage) PresignedHead(_ context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error) {
+	return m.presign.sign(http.MethodGet, name, expires, presignParams(opts))
+}
+
+// PresignedPut 返回有效期为 expires 的上传链接, 需配合 WithPresign 与 Handler 使用
+func (m *MemoryStorage) PresignedPut(_ context.Context, name string, expires time.Duration) (*url.URL, error) {
+	return m.presign.sign(http.MethodPut, name, expires, nil)
+}
+
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error { return nil }
+
+func cloneInfo(info ObjectInfo) ObjectInfo {
+	info.Metadata = maps.Clone(info.Metadata)
+	return info
+}
+
+// readAll 读取 size 字节, size 为 -1 时读到结尾
+func readAll(r io.Reader, size int64) ([]byte, error) {
+	if size < 0 {
+		return io.ReadAll(r)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,192 @@
+package oss
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	minIo "github.com/minio/minio-go/v7"
+)
+
+const (
+	// MinPartSize S3 要求除最后一片外每片至少 5MiB
+	MinPartSize = 5 << 20
+	// DefaultPartSize 默认分片大小
+	DefaultPartSize = 16 << 20
+	// MaxParts S3 分片数上限
+	MaxParts = 10000
+)
+
+// MultipartOption 分片上传选项
+type MultipartOption func(*multipartOptions)
+
+type multipartOptions struct {
+	partSize    int64
+	concurrency int
+	uploadID    string
+	onUploadID  func(uploadID string)
+	put         []PutOption
+}
+
+// WithPartSize 分片大小, 不小于 MinPartSize, 默认 DefaultPartSize.
+// 分片数超过 MaxParts 时自动增大, 续传时需保持一致.
+func WithPartSize(size int64) MultipartOption {
+	return func(o *multipartOptions) {
+		o.partSize = size
+	}
+}
+
+// WithConcurrency 并发上传的分片数, 默认 4
+func WithConcurrency(n int) MultipartOption {
+	return func(o *multipartOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithUploadID 续传之前未完成的上传, 已上传且大小一致的分片会跳过
+func WithUploadID(uploadID string) MultipartOption {
+	return func(o *multipartOptions) {
+		o.uploadID = uploadID
+	}
+}
+
+// WithUploadIDHandler 新建上传后回调, 调用方可保存 uploadID 以便失败后续传
+func WithUploadIDHandler(f func(uploadID string)) MultipartOption {
+	return func(o *multipartOptions) {
+		o.onUploadID = f
+	}
+}
+
+// WithPutOptions 新建上传时的 Content-Type、元数据等
+func WithPutOptions(opts ...PutOption) MultipartOption {
+	return func(o *multipartOptions) {
+		o.put = append(o.put, opts...)
+	}
+}
+
+// PutMultipart 分片上传, 失败时不会中止上传, 可通过 WithUploadID 续传或调用 AbortMultipart 放弃
+func (o *OssUtil) PutMultipart(ctx context.Context, name string, r io.ReaderAt, size int64, opts ...MultipartOption) (ObjectInfo, error) {
+	options := multipartOptions{partSize: DefaultPartSize, concurrency: 4}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	key, err := o.Key(name)
+	if err != nil {
+		return ObjectInfo{}, wrapError("multipart", name, err)
+	}
+	if options.partSize < MinPartSize {
+		return ObjectInfo{}, wrapError("multipart", name, ErrInvalidPartSize)
+	}
+	partSize := options.partSize
+	for size > partSize*MaxParts {
+		partSize += MinPartSize
+	}
+	parts := int((size + partSize - 1) / partSize)
+	if parts == 0 {
+		parts = 1
+	}
+
+	core := minIo.Core{Client: o.Client}
+	bucket := o.Config.BucketName
+	put := putOptions(options.put)
+
+	uploadID := options.uploadID
+	uploaded := make(map[int]minIo.ObjectPart)
+	if uploadID == "" {
+		if put.ContentType == "" {
+			put.ContentType, _ = detectContentType(name, io.NewSectionReader(r, 0, size))
+		}
+		if uploadID, err = core.NewMultipartUpload(ctx, bucket, key, put); err != nil {
+			return ObjectInfo{}, wrapError("multipart", name, err)
+		}
+		if options.onUploadID != nil {
+			options.onUploadID(uploadID)
+		}
+	} else if uploaded, err = o.listParts(ctx, core, key, uploadID); err != nil {
+		return ObjectInfo{}, wrapError("multipart", name, err)
+	}
+
+	complete := make([]minIo.CompletePart, parts)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, options.concurrency)
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for i := 0; i < parts; i++ {
+		number := i + 1
+		offset := int64(i) * partSize
+		length := min(partSize, size-offset)
+		if p, ok := uploaded[number]; ok && p.Size == length {
+			complete[i] = minIo.CompletePart{PartNumber: number, ETag: p.ETag}
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			p, err := core.PutObjectPart(ctx, bucket, key, uploadID, number,
+				io.NewSectionReader(r, offset, length), length, minIo.PutObjectPartOptions{SSE: put.ServerSideEncryption})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			complete[i] = minIo.CompletePart{PartNumber: number, ETag: p.ETag}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return ObjectInfo{}, wrapError("multipart", name, firstErr)
+	}
+
+	info, err := core.CompleteMultipartUpload(ctx, bucket, key, uploadID, complete, put)
+	if err != nil {
+		return ObjectInfo{}, wrapError("multipart", name, err)
+	}
+	return ObjectInfo{
+		Key:          name,
+		Size:         size,
+		ETag:         info.ETag,
+		ContentType:  put.ContentType,
+		LastModified: info.LastModified,
+		Metadata:     put.UserMetadata,
+	}, nil
+}
+
+func (o *OssUtil) listParts(ctx context.Context, core minIo.Core, key, uploadID string) (map[int]minIo.ObjectPart, error) {
+	parts := make(map[int]minIo.ObjectPart)
+	marker := 0
+	for {
+		res, err := core.ListObjectParts(ctx, o.Config.BucketName, key, uploadID, marker, 1000)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range res.ObjectParts {
+			parts[p.PartNumber] = p
+		}
+		if !res.IsTruncated {
+			return parts, nil
+		}
+		marker = res.NextPartNumberMarker
+	}
+}
+
+// AbortMultipart 放弃未完成的分片上传并清理已上传的分片
+func (o *OssUtil) AbortMultipart(ctx context.Context, name, uploadID string) error {
+	key, err := o.Key(name)
+	if err != nil {
+		return wrapError("abort", name, err)
+	}
+	core := minIo.Core{Client: o.Client}
+	return wrapError("abort", name, core.AbortMultipartUpload(ctx, o.Config.BucketName, key, uploadID))
+}
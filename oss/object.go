@@ -0,0 +1,262 @@
+package oss
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	minIo "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// ObjectInfo 对象信息, Key 为去掉 Config.Dir 前缀后的名称
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string
+	ContentType  string
+	LastModified time.Time
+	Metadata     map[string]string
+	// IsDir 非递归列举时的公共前缀
+	IsDir bool
+}
+
+// PutOption 上传选项
+type PutOption func(*minIo.PutObjectOptions)
+
+// WithContentType 指定 Content-Type, 默认按扩展名或内容检测
+func WithContentType(contentType string) PutOption {
+	return func(o *minIo.PutObjectOptions) {
+		o.ContentType = contentType
+	}
+}
+
+// WithMetadata 用户自定义元数据, 以 x-amz-meta- 保存
+func WithMetadata(metadata map[string]string) PutOption {
+	return func(o *minIo.PutObjectOptions) {
+		if o.UserMetadata == nil {
+			o.UserMetadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			o.UserMetadata[k] = v
+		}
+	}
+}
+
+// WithCacheControl 指定 Cache-Control
+func WithCacheControl(cacheControl string) PutOption {
+	return func(o *minIo.PutObjectOptions) {
+		o.CacheControl = cacheControl
+	}
+}
+
+// WithContentDisposition 指定 Content-Disposition, 如 attachment; filename="a.pdf"
+func WithContentDisposition(disposition string) PutOption {
+	return func(o *minIo.PutObjectOptions) {
+		o.ContentDisposition = disposition
+	}
+}
+
+// Key 对象名加上 Config.Dir 前缀, 不允许包含 ".." 路径段
+func (o *OssUtil) Key(name string) (string, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+	if dir := strings.Trim(o.Config.Dir, "/"); dir != "" {
+		return dir + "/" + name, nil
+	}
+	return name, nil
+}
+
+// name 去掉 Config.Dir 前缀
+func (o *OssUtil) name(key string) string {
+	if dir := strings.Trim(o.Config.Dir, "/"); dir != "" {
+		return strings.TrimPrefix(key, dir+"/")
+	}
+	return key
+}
+
+func (o *OssUtil) toObjectInfo(info minIo.ObjectInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:          o.name(info.Key),
+		Size:         info.Size,
+		ETag:         info.ETag,
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+		Metadata:     info.UserMetadata,
+	}
+}
+
+// detectContentType 先按扩展名, 再按内容前 512 字节检测
+func detectContentType(name string, r io.Reader) (string, io.Reader) {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t, r
+	}
+	br := bufio.NewReaderSize(r, 512)
+	head, _ := br.Peek(512)
+	return http.DetectContentType(head), br
+}
+
+func putOptions(opts []PutOption) minIo.PutObjectOptions {
+	var o minIo.PutObjectOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Put 上传对象, size 未知时传 -1
+func (o *OssUtil) Put(ctx context.Context, name string, r io.Reader, size int64, opts ...PutOption) (ObjectInfo, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	options := putOptions(opts)
+	if options.ContentType == "" {
+		options.ContentType, r = detectContentType(name, r)
+	}
+	info, err := o.Client.PutObject(ctx, o.Config.BucketName, key, r, size, options)
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	return ObjectInfo{
+		Key:          name,
+		Size:         info.Size,
+		ETag:         info.ETag,
+		ContentType:  options.ContentType,
+		LastModified: info.LastModified,
+		Metadata:     options.UserMetadata,
+	}, nil
+}
+
+// GetOption 下载选项
+type GetOption func(*minIo.GetObjectOptions) error
+
+// WithRange 只读取 [start, end] 字节, end 为 -1 表示到结尾
+func WithRange(start, end int64) GetOption {
+	return func(o *minIo.GetObjectOptions) error {
+		if end < 0 {
+			if start == 0 {
+				return nil
+			}
+			return o.SetRange(start, 0)
+		}
+		return o.SetRange(start, end)
+	}
+}
+
+// WithMatchETag 只在 ETag 一致时返回, 否则返回 ErrPreconditionFailed
+func WithMatchETag(etag string) GetOption {
+	return func(o *minIo.GetObjectOptions) error {
+		return o.SetMatchETag(etag)
+	}
+}
+
+// Get 下载对象, 调用方负责关闭返回的 ReadCloser
+func (o *OssUtil) Get(ctx context.Context, name string, opts ...GetOption) (io.ReadCloser, ObjectInfo, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	var options minIo.GetObjectOptions
+	for _, opt := range opts {
+		if err = opt(&options); err != nil {
+			return nil, ObjectInfo{}, wrapError("get", name, err)
+		}
+	}
+	// Core.GetObject 立即发起请求, 便于尽早返回错误, 且不会丢弃 Range
+	core := minIo.Core{Client: o.Client}
+	rc, info, _, err := core.GetObject(ctx, o.Config.BucketName, key, options)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	return rc, o.toObjectInfo(info), nil
+}
+
+// Stat 获取对象信息
+func (o *OssUtil) Stat(ctx context.Context, name string) (ObjectInfo, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return ObjectInfo{}, wrapError("stat", name, err)
+	}
+	info, err := o.Client.StatObject(ctx, o.Config.BucketName, key, minIo.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, wrapError("stat", name, err)
+	}
+	return o.toObjectInfo(info), nil
+}
+
+// Copy 在桶内复制对象, 指定 opts 时替换目标的元数据
+func (o *OssUtil) Copy(ctx context.Context, src, dst string, opts ...PutOption) (ObjectInfo, error) {
+	srcKey, err := o.Key(src)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", src, err)
+	}
+	dstKey, err := o.Key(dst)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", dst, err)
+	}
+	dstOpts := minIo.CopyDestOptions{Bucket: o.Config.BucketName, Object: dstKey}
+	srcOpts := minIo.CopySrcOptions{Bucket: o.Config.BucketName, Object: srcKey}
+	if len(opts) > 0 {
+		options := putOptions(opts)
+		dstOpts.ReplaceMetadata = true
+		dstOpts.UserMetadata = options.UserMetadata
+		if options.ContentType != "" {
+			if dstOpts.UserMetadata == nil {
+				dstOpts.UserMetadata = make(map[string]string)
+			}
+			dstOpts.UserMetadata["Content-Type"] = options.ContentType
+		}
+		dstOpts.Encryption = options.ServerSideEncryption
+		// SSE-C 的源对象使用同一密钥解密
+		if sse := options.ServerSideEncryption; sse != nil && sse.Type() == encrypt.SSEC {
+			srcOpts.Encryption = encrypt.SSECopy(sse)
+		}
+		if len(options.UserTags) > 0 {
+			dstOpts.ReplaceTags = true
+			dstOpts.UserTags = options.UserTags
+		}
+	}
+	info, err := o.Client.CopyObject(ctx, dstOpts, srcOpts)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", src, err)
+	}
+	return ObjectInfo{Key: dst, Size: info.Size, ETag: info.ETag, LastModified: info.LastModified}, nil
+}
+
+// Delete 删除对象, 对象不存在时不返回错误
+func (o *OssUtil) Delete(ctx context.Context, name string) error {
+	key, err := o.Key(name)
+	if err != nil {
+		return wrapError("delete", name, err)
+	}
+	return wrapError("delete", name, o.Client.RemoveObject(ctx, o.Config.BucketName, key, minIo.RemoveObjectOptions{}))
+}
+
+// DeleteMany 批量删除, 返回每个失败对象的错误 (errors.Join)
+func (o *OssUtil) DeleteMany(ctx context.Context, names []string) error {
+	var errs []error
+	objects := make(chan minIo.ObjectInfo, len(names))
+	for _, name := range names {
+		key, err := o.Key(name)
+		if err != nil {
+			errs = append(errs, wrapError("delete", name, err))
+			continue
+		}
+		objects <- minIo.ObjectInfo{Key: key}
+	}
+	close(objects)
+
+	for e := range o.Client.RemoveObjects(ctx, o.Config.BucketName, objects, minIo.RemoveObjectsOptions{}) {
+		errs = append(errs, wrapError("delete", o.name(e.ObjectName), e.Err))
+	}
+	return errors.Join(errs...)
+}
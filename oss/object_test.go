@@ -0,0 +1,187 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	minIo "github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/oss/internal/fakes3"
+)
+
+func TestMain(m *testing.M) {
+	// 模拟失败时不需要重试
+	minIo.MaxRetry = 1
+	os.Exit(m.Run())
+}
+
+func newTestOss(t *testing.T) (*OssUtil, *fakes3.Server) {
+	srv := fakes3.New("bucket")
+	t.Cleanup(srv.Close)
+	return NewOssUtil(OssUtilConfig{
+		EndPoint:   srv.Endpoint(),
+		Region:     "us-east-1",
+		BucketName: "bucket",
+		Dir:        "temp",
+	}), srv
+}
+
+func TestOssUtil_Key(t *testing.T) {
+	o := &OssUtil{Config: OssUtilConfig{Dir: "/temp/"}}
+	key, err := o.Key("/a/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "temp/a/b.txt", key)
+	assert.Equal(t, "a/b.txt", o.name(key))
+
+	for _, name := range []string{"", "/", "../a", "a/../../b"} {
+		_, err = o.Key(name)
+		assert.ErrorIs(t, err, ErrInvalidKey, name)
+	}
+}
+
+func TestOssUtil_Object(t *testing.T) {
+	ctx := context.Background()
+	o, srv := newTestOss(t)
+
+	info, err := o.Put(ctx, "a.json", strings.NewReader(`{}`), 2, WithMetadata(map[string]string{"Owner": "bob"}))
+	require.NoError(t, err)
+	assert.Equal(t, "a.json", info.Key)
+	obj, ok := srv.Object("bucket", "temp/a.json")
+	require.True(t, ok)
+	assert.Equal(t, "application/json", obj.Header.Get("Content-Type"))
+
+	_, err = o.Put(ctx, "b", strings.NewReader("<html><body>hi</body></html>"), -1)
+	require.NoError(t, err)
+	info, err = o.Stat(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", info.ContentType)
+
+	rc, info, err := o.Get(ctx, "a.json")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "{}", string(data))
+	assert.Equal(t, "bob", info.Metadata["Owner"])
+
+	rc, _, err = o.Get(ctx, "b", WithRange(6, 9))
+	require.NoError(t, err)
+	data, _ = io.ReadAll(rc)
+	rc.Close()
+	assert.Equal(t, "<bod", string(data))
+
+	_, err = o.Copy(ctx, "a.json", "c.json")
+	require.NoError(t, err)
+	_, err = o.Stat(ctx, "c.json")
+	require.NoError(t, err)
+
+	require.NoError(t, o.Delete(ctx, "a.json"))
+	_, err = o.Stat(ctx, "a.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, _, err = o.Get(ctx, "a.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+	var e *Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, "a.json", e.Key)
+
+	require.NoError(t, o.DeleteMany(ctx, []string{"b", "c.json"}))
+	_, ok = srv.Object("bucket", "temp/c.json")
+	assert.False(t, ok)
+
+	_, err = o.Put(ctx, "../x", strings.NewReader("x"), 1)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestOssUtil_List(t *testing.T) {
+	ctx := context.Background()
+	o, _ := newTestOss(t)
+	for _, name := range []string{"a/1", "a/2", "a/3", "a/sub/4", "b/5"} {
+		_, err := o.Put(ctx, name, strings.NewReader(name), int64(len(name)))
+		require.NoError(t, err)
+	}
+
+	var keys []string
+	for info, err := range o.List(ctx, "a/") {
+		require.NoError(t, err)
+		keys = append(keys, info.Key)
+	}
+	assert.Equal(t, []string{"a/1", "a/2", "a/3", "a/sub/4"}, keys)
+
+	keys = keys[:0]
+	for info, err := range o.List(ctx, "a/", WithNonRecursive()) {
+		require.NoError(t, err)
+		keys = append(keys, info.Key)
+		assert.Equal(t, strings.HasSuffix(info.Key, "/"), info.IsDir)
+	}
+	assert.Equal(t, []string{"a/1", "a/2", "a/3", "a/sub/"}, keys)
+
+	page, next, err := o.ListPage(ctx, "", "", 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "a/2", next)
+	page, next, err = o.ListPage(ctx, "", next, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "a/3", page[0].Key)
+	assert.Equal(t, "a/sub/4", next)
+	page, next, err = o.ListPage(ctx, "", next, 2)
+	require.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Empty(t, next)
+}
+
+type failingReaderAt struct {
+	io.ReaderAt
+	failFrom int64
+}
+
+func (f failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.failFrom {
+		return 0, errors.New("read failed")
+	}
+	return f.ReaderAt.ReadAt(p, off)
+}
+
+func TestOssUtil_PutMultipart(t *testing.T) {
+	ctx := context.Background()
+	o, srv := newTestOss(t)
+
+	_, err := o.PutMultipart(ctx, "big.bin", bytes.NewReader(nil), 0, WithPartSize(1024))
+	assert.ErrorIs(t, err, ErrInvalidPartSize)
+
+	data := bytes.Repeat([]byte("0123456789"), MinPartSize/5+3)
+	var uploadID string
+	_, err = o.PutMultipart(ctx, "big.bin", failingReaderAt{bytes.NewReader(data), 2 * MinPartSize}, int64(len(data)),
+		WithPartSize(MinPartSize),
+		WithConcurrency(1),
+		WithUploadIDHandler(func(id string) { uploadID = id }),
+	)
+	require.Error(t, err)
+	require.NotEmpty(t, uploadID)
+	assert.Equal(t, 1, srv.Uploads())
+
+	info, err := o.PutMultipart(ctx, "big.bin", bytes.NewReader(data), int64(len(data)),
+		WithPartSize(MinPartSize),
+		WithUploadID(uploadID),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), info.Size)
+	assert.Equal(t, 0, srv.Uploads())
+	obj, ok := srv.Object("bucket", "temp/big.bin")
+	require.True(t, ok)
+	assert.Equal(t, data, obj.Data)
+
+	_, err = o.PutMultipart(ctx, "big.bin", bytes.NewReader(data), int64(len(data)), WithUploadID("missing"))
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+
+	_, err = o.PutMultipart(ctx, "small.bin", failingReaderAt{bytes.NewReader(data), 0}, int64(len(data)),
+		WithUploadIDHandler(func(id string) { uploadID = id }))
+	require.Error(t, err)
+	require.NoError(t, o.AbortMultipart(ctx, "small.bin", uploadID))
+	assert.Equal(t, 0, srv.Uploads())
+}
@@ -17,11 +17,17 @@ type OssUtilConfig struct {
 	BucketName      string `json:"bucket_name" yaml:"bucketName"`
 	Dir             string `json:"dir" yaml:"dir"`
 	UseSSL          bool   `json:"use_ssl" yaml:"useSsl"`
+	// PublicBaseURL 公共读桶的访问地址, 如 https://bucket.s3.amazonaws.com, 为空时使用 EndPoint/BucketName
+	PublicBaseURL string `json:"public_base_url" yaml:"publicBaseUrl"`
+	// CDN 桶对应的 CDN 配置
+	CDN CDNConfig `json:"cdn" yaml:"cdn"`
 }
 
 type OssUtil struct {
 	Config OssUtilConfig
 	Client *minIo.Client
+
+	cdn URLSigner
 }
 
 func NewOssUtil(config OssUtilConfig) *OssUtil {
@@ -33,9 +39,14 @@ func NewOssUtil(config OssUtilConfig) *OssUtil {
 	if err != nil {
 		panic(err)
 	}
+	cdn, err := NewURLSigner(config.CDN)
+	if err != nil {
+		panic(err)
+	}
 	return &OssUtil{
 		Config: config,
 		Client: client,
+		cdn:    cdn,
 	}
 }
 
@@ -0,0 +1,315 @@
+package oss
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	minIo "github.com/minio/minio-go/v7"
+)
+
+// PostFileField 表单直传的文件字段名, S3 要求放在最后一个字段
+const PostFileField = "file"
+
+// PostTokenParam 回调中携带 token 的参数名
+const PostTokenParam = "token"
+
+// MetaPostNonce 前缀直传时每个策略的随机数, 上传时作为元数据写入并签入 token,
+// 回调时据此确认对象由该策略上传
+const MetaPostNonce = "Post-Nonce"
+
+// PostForm 浏览器表单直传所需信息.
+// 浏览器以 multipart/form-data POST 到 URL, Fields 原样作为表单字段, 最后附上文件字段 PostFileField.
+// 允许多种 Content-Type 时 Fields["Content-Type"] 只是公共前缀, 需替换为文件实际类型.
+type PostForm struct {
+	URL    string            `json:"url"`
+	Fields map[string]string `json:"fields"`
+	// Key 对象名 (不含 Dir), WithPostKeyPrefix 时为前缀
+	Key    string `json:"key"`
+	Expire int64  `json:"expire"`
+	// Token 回调校验用, 仅在 WithPostToken 时生成
+	Token string `json:"token,omitempty"`
+}
+
+// PostConstraints 表单直传的约束, 签入 token 中, 回调时由 PostVerifier 校验
+type PostConstraints struct {
+	// Dir 对象名前缀, 即 OssUtilConfig.Dir
+	Dir string `json:"d,omitempty"`
+	// Key 对象名 (不含 Dir), KeyPrefix 为 true 时为前缀
+	Key          string            `json:"k"`
+	KeyPrefix    bool              `json:"p,omitempty"`
+	MinSize      int64             `json:"min,omitempty"`
+	MaxSize      int64             `json:"max,omitempty"`
+	ContentTypes []string          `json:"ct,omitempty"`
+	Metadata     map[string]string `json:"md,omitempty"`
+	Expire       int64             `json:"exp"`
+	// Nonce KeyPrefix 时对象元数据 MetaPostNonce 的值
+	Nonce string `json:"n,omitempty"`
+}
+
+// PostOption 表单直传选项
+type PostOption func(*postOptions)
+
+type postOptions struct {
+	constraints     PostConstraints
+	successRedirect string
+	successStatus   int
+	secret          []byte
+}
+
+// WithPostContentLengthRange 限制文件大小在 [min, max] 字节之间
+func WithPostContentLengthRange(min, max int64) PostOption {
+	return func(o *postOptions) {
+		o.constraints.MinSize, o.constraints.MaxSize = min, max
+	}
+}
+
+// WithPostContentTypes 允许的 Content-Type, 支持 "image/*" 这样的通配.
+// S3 策略只能校验一个值或前缀, 多个类型时策略只限制公共前缀, 精确的类型由 PostVerifier 校验.
+func WithPostContentTypes(contentTypes ...string) PostOption {
+	return func(o *postOptions) {
+		o.constraints.ContentTypes = append(o.constraints.ContentTypes, contentTypes...)
+	}
+}
+
+// WithPostKeyPrefix name 作为前缀, 浏览器可在前缀下任意命名, 默认表单字段 key 为 name + "${filename}";
+// 同时使用 WithPostToken 时表单带有 MetaPostNonce 元数据, 回调只接受该策略上传的对象
+func WithPostKeyPrefix() PostOption {
+	return func(o *postOptions) {
+		o.constraints.KeyPrefix = true
+	}
+}
+
+// WithPostMetadata 上传时必须携带的用户元数据
+func WithPostMetadata(metadata map[string]string) PostOption {
+	return func(o *postOptions) {
+		if o.constraints.Metadata == nil {
+			o.constraints.Metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			o.constraints.Metadata[k] = v
+		}
+	}
+}
+
+// WithPostSuccessRedirect 上传成功后重定向到 redirect, S3 会追加 bucket、key、etag 参数;
+// 同时使用 WithPostToken 时 token 会附加到 redirect 上, 回调可直接交给 PostVerifier.VerifyRequest.
+func WithPostSuccessRedirect(redirect string) PostOption {
+	return func(o *postOptions) {
+		o.successRedirect = redirect
+	}
+}
+
+// WithPostSuccessStatus 上传成功后返回的状态码, 可选 200, 201, 204(默认)
+func WithPostSuccessStatus(status int) PostOption {
+	return func(o *postOptions) {
+		o.successStatus = status
+	}
+}
+
+// WithPostToken 使用 secret 生成回调校验的 token, 与 NewPostVerifier 的 secret 一致
+func WithPostToken(secret []byte) PostOption {
+	return func(o *postOptions) {
+		o.secret = secret
+	}
+}
+
+// PresignedPostPolicy 生成浏览器表单直传的策略, 有效期为 expires
+func (o *OssUtil) PresignedPostPolicy(ctx context.Context, name string, expires time.Duration, opts ...PostOption) (PostForm, error) {
+	options := postOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	c := &options.constraints
+	var (
+		key string
+		err error
+	)
+	if c.KeyPrefix {
+		name, err = cleanPrefix(name)
+		key = name
+		if dir := strings.Trim(o.Config.Dir, "/"); dir != "" {
+			key = dir + "/" + name
+		}
+	} else {
+		key, err = o.Key(name)
+		name = o.name(key)
+	}
+	if err != nil {
+		return PostForm{}, wrapError("post", name, err)
+	}
+	expire := time.Now().Add(expires)
+	c.Dir, c.Key, c.Expire = strings.Trim(o.Config.Dir, "/"), name, expire.Unix()
+	c.Metadata = canonicalMetadata(c.Metadata)
+
+	policy := minIo.NewPostPolicy()
+	if err = policy.SetBucket(o.Config.BucketName); err != nil {
+		return PostForm{}, wrapError("post", name, err)
+	}
+	if err = policy.SetExpires(expire); err != nil {
+		return PostForm{}, wrapError("post", name, err)
+	}
+	if c.KeyPrefix {
+		err = policy.SetKeyStartsWith(key)
+	} else {
+		err = policy.SetKey(key)
+	}
+	if err != nil {
+		return PostForm{}, wrapError("post", name, err)
+	}
+	if c.MaxSize > 0 {
+		if err = policy.SetContentLengthRange(c.MinSize, c.MaxSize); err != nil {
+			return PostForm{}, wrapError("post", name, err)
+		}
+	}
+	if len(c.ContentTypes) > 0 {
+		if t, exact := contentTypeCondition(c.ContentTypes); exact {
+			err = policy.SetContentType(t)
+		} else {
+			err = policy.SetContentTypeStartsWith(t)
+		}
+		if err != nil {
+			return PostForm{}, wrapError("post", name, err)
+		}
+	}
+	for k, v := range c.Metadata {
+		if err = policy.SetUserMetadata(k, v); err != nil {
+			return PostForm{}, wrapError("post", name, err)
+		}
+	}
+	// 前缀下的对象可能由其他策略上传, 回调时用 nonce 确认归属
+	if c.KeyPrefix && options.secret != nil {
+		var b [16]byte
+		if _, err = rand.Read(b[:]); err != nil {
+			return PostForm{}, wrapError("post", name, err)
+		}
+		c.Nonce = hex.EncodeToString(b[:])
+		if err = policy.SetUserMetadata(MetaPostNonce, c.Nonce); err != nil {
+			return PostForm{}, wrapError("post", name, err)
+		}
+	}
+
+	var token string
+	if options.secret != nil {
+		if token, err = signPostToken(options.secret, *c); err != nil {
+			return PostForm{}, wrapError("post", name, err)
+		}
+	}
+	if options.successRedirect != "" {
+		redirect := options.successRedirect
+		if token != "" {
+			if redirect, err = appendQuery(redirect, PostTokenParam, token); err != nil {
+				return PostForm{}, wrapError("post", name, err)
+			}
+		}
+		if err = policy.SetSuccessActionRedirect(redirect); err != nil {
+			return PostForm{}, wrapError("post", name, err)
+		}
+	}
+	if options.successStatus != 0 {
+		if err = policy.SetSuccessStatusAction(strconv.Itoa(options.successStatus)); err != nil {
+			return PostForm{}, wrapError("post", name, err)
+		}
+	}
+
+	u, fields, err := o.Client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return PostForm{}, wrapError("post", name, err)
+	}
+	if c.KeyPrefix {
+		fields["key"] = key + "${filename}"
+	}
+	return PostForm{
+		URL:    u.String(),
+		Fields: fields,
+		Key:    name,
+		Expire: c.Expire,
+		Token:  token,
+	}, nil
+}
+
+// contentTypeCondition 单个类型精确匹配, 否则返回公共前缀
+func contentTypeCondition(contentTypes []string) (string, bool) {
+	if len(contentTypes) == 1 && !strings.HasSuffix(contentTypes[0], "*") {
+		return contentTypes[0], true
+	}
+	prefix := strings.TrimSuffix(contentTypes[0], "*")
+	for _, t := range contentTypes[1:] {
+		t = strings.TrimSuffix(t, "*")
+		for !strings.HasPrefix(t, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix, false
+}
+
+// matchContentType 判断 contentType 是否在允许的列表中, 忽略参数部分
+func matchContentType(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	contentType, _, _ = strings.Cut(contentType, ";")
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if prefix, ok := strings.CutSuffix(a, "*"); ok {
+			if strings.HasPrefix(contentType, prefix) {
+				return true
+			}
+		} else if contentType == a {
+			return true
+		}
+	}
+	return false
+}
+
+func appendQuery(rawURL, key, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// signPostToken token 格式: base64url(json).base64url(hmac-sha256)
+func signPostToken(secret []byte, c PostConstraints) (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + postTokenSignature(secret, payload), nil
+}
+
+func postTokenSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func parsePostToken(secret []byte, token string) (PostConstraints, error) {
+	var c PostConstraints
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(postTokenSignature(secret, payload))) {
+		return c, ErrSignatureMismatch
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return c, ErrSignatureMismatch
+	}
+	if err = json.Unmarshal(b, &c); err != nil {
+		return c, ErrSignatureMismatch
+	}
+	return c, nil
+}
@@ -0,0 +1,217 @@
+package oss_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/oss"
+	"github.com/zmicro-team/ztlib/oss/internal/fakes3"
+)
+
+var postSecret = []byte("post-secret")
+
+func newPostOss(t *testing.T) *oss.OssUtil {
+	srv := fakes3.New("bucket")
+	t.Cleanup(srv.Close)
+	return oss.NewOssUtil(oss.OssUtilConfig{
+		EndPoint:        srv.Endpoint(),
+		Region:          "us-east-1",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		BucketName:      "bucket",
+		Dir:             "temp",
+	})
+}
+
+// postFile 模拟浏览器表单直传
+func postFile(t *testing.T, form oss.PostForm, filename, contentType string, data []byte) *http.Response {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range form.Fields {
+		if k == "Content-Type" {
+			v = contentType
+		}
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	fw, err := mw.CreateFormFile(oss.PostFileField, filename)
+	require.NoError(t, err)
+	_, _ = fw.Write(data)
+	require.NoError(t, mw.Close())
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Post(form.URL, mw.FormDataContentType(), &body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestOssUtil_PresignedPostPolicy(t *testing.T) {
+	ctx := context.Background()
+	o := newPostOss(t)
+
+	form, err := o.PresignedPostPolicy(ctx, "avatars/", time.Minute,
+		oss.WithPostKeyPrefix(),
+		oss.WithPostContentLengthRange(1, 16),
+		oss.WithPostContentTypes("image/png", "image/jpeg"),
+		oss.WithPostMetadata(map[string]string{"uid": "42"}),
+		oss.WithPostSuccessRedirect("https://api.example.com/upload/done?from=web"),
+		oss.WithPostToken(postSecret),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "avatars/", form.Key)
+	assert.Equal(t, "temp/avatars/${filename}", form.Fields["key"])
+	assert.Equal(t, "image/", form.Fields["Content-Type"])
+	assert.Equal(t, "42", form.Fields["x-amz-meta-Uid"])
+	assert.Len(t, form.Fields["x-amz-meta-Post-Nonce"], 32)
+	assert.NotEmpty(t, form.Token)
+
+	redirect, err := url.Parse(form.Fields["success_action_redirect"])
+	require.NoError(t, err)
+	assert.Equal(t, "web", redirect.Query().Get("from"))
+	assert.Equal(t, form.Token, redirect.Query().Get(oss.PostTokenParam))
+
+	b, err := base64.StdEncoding.DecodeString(form.Fields["policy"])
+	require.NoError(t, err)
+	var policy struct {
+		Conditions []json.RawMessage
+	}
+	require.NoError(t, json.Unmarshal(b, &policy))
+	conditions := make([]string, 0, len(policy.Conditions))
+	for _, c := range policy.Conditions {
+		conditions = append(conditions, string(c))
+	}
+	assert.Contains(t, conditions, `["starts-with","$key","temp/avatars/"]`)
+	assert.Contains(t, conditions, `["starts-with","$Content-Type","image/"]`)
+	assert.Contains(t, conditions, `["content-length-range", 1, 16]`)
+	assert.Contains(t, conditions, `["eq","$x-amz-meta-Uid","42"]`)
+
+	form, err = o.PresignedPostPolicy(ctx, "a.png", time.Minute, oss.WithPostContentTypes("image/png"), oss.WithPostSuccessStatus(201))
+	require.NoError(t, err)
+	assert.Equal(t, "temp/a.png", form.Fields["key"])
+	assert.Equal(t, "image/png", form.Fields["Content-Type"])
+	assert.Equal(t, "201", form.Fields["success_action_status"])
+	assert.Empty(t, form.Token)
+
+	_, err = o.PresignedPostPolicy(ctx, "../a.png", time.Minute)
+	assert.ErrorIs(t, err, oss.ErrInvalidKey)
+}
+
+func TestPostVerifier(t *testing.T) {
+	ctx := context.Background()
+	o := newPostOss(t)
+	verifier := oss.NewPostVerifier(o, postSecret, oss.WithPostDeleteRejected())
+
+	var (
+		info      oss.ObjectInfo
+		verifyErr error
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, verifyErr = verifier.VerifyRequest(r)
+	}))
+	defer api.Close()
+
+	form, err := o.PresignedPostPolicy(ctx, "avatars/", time.Minute,
+		oss.WithPostKeyPrefix(),
+		oss.WithPostContentLengthRange(1, 16),
+		oss.WithPostContentTypes("image/png", "image/jpeg"),
+		oss.WithPostMetadata(map[string]string{"uid": "42"}),
+		oss.WithPostSuccessRedirect(api.URL+"/done"),
+		oss.WithPostToken(postSecret),
+	)
+	require.NoError(t, err)
+
+	// 超出大小或类型不符, 被 S3 拒绝
+	assert.Equal(t, http.StatusForbidden, postFile(t, form, "a.png", "image/png", bytes.Repeat([]byte("x"), 17)).StatusCode)
+	assert.Equal(t, http.StatusForbidden, postFile(t, form, "a.gif", "text/plain", []byte("gif")).StatusCode)
+
+	resp := postFile(t, form, "a.png", "image/png", []byte("png"))
+	require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	loc, err := resp.Location()
+	require.NoError(t, err)
+	resp, err = http.Get(loc.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.NoError(t, verifyErr)
+	assert.Equal(t, "avatars/a.png", info.Key)
+	assert.Equal(t, int64(3), info.Size)
+	assert.Equal(t, "42", info.Metadata["Uid"])
+
+	// 类型在公共前缀内但不在允许列表中, 通过了策略但回调拒绝并删除
+	assert.Equal(t, http.StatusSeeOther, postFile(t, form, "b.png", "image/gif", []byte("gif")).StatusCode)
+	_, err = verifier.Verify(ctx, form.Token, "avatars/b.png", "")
+	assert.ErrorIs(t, err, oss.ErrUploadRejected)
+	_, err = o.Stat(ctx, "avatars/b.png")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+
+	// 对象名不在前缀下, token 被篡改, etag 不一致
+	_, err = verifier.Verify(ctx, form.Token, "other/a.png", "")
+	assert.ErrorIs(t, err, oss.ErrInvalidKey)
+	_, err = verifier.Verify(ctx, form.Token+"x", "avatars/a.png", "")
+	assert.ErrorIs(t, err, oss.ErrSignatureMismatch)
+	_, err = verifier.Verify(ctx, form.Token, "avatars/a.png", `"0123"`)
+	assert.ErrorIs(t, err, oss.ErrUploadRejected)
+	_, err = verifier.Verify(ctx, form.Token, "avatars/none.png", "")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+
+	// 同一前缀下其他策略上传的对象, 不能确认也不能删除
+	other, err := o.PresignedPostPolicy(ctx, "avatars/", time.Minute,
+		oss.WithPostKeyPrefix(),
+		oss.WithPostSuccessStatus(204),
+		oss.WithPostToken(postSecret),
+	)
+	require.NoError(t, err)
+	assert.NotEqual(t, form.Fields["x-amz-meta-Post-Nonce"], other.Fields["x-amz-meta-Post-Nonce"])
+	assert.Equal(t, http.StatusSeeOther, postFile(t, form, "d.png", "image/png", []byte("png")).StatusCode)
+	_, err = verifier.Verify(ctx, other.Token, "avatars/d.png", "")
+	assert.ErrorIs(t, err, oss.ErrInvalidKey)
+	_, err = o.Stat(ctx, "avatars/d.png")
+	assert.NoError(t, err)
+	_, err = o.Put(ctx, "avatars/c.png", strings.NewReader("png"), 3)
+	require.NoError(t, err)
+	_, err = verifier.Verify(ctx, form.Token, "avatars/c.png", "")
+	assert.ErrorIs(t, err, oss.ErrInvalidKey)
+	_, err = o.Stat(ctx, "avatars/c.png")
+	assert.NoError(t, err)
+}
+
+func TestPostVerifier_Expired(t *testing.T) {
+	ctx := context.Background()
+	store := oss.NewMemoryStorage()
+	o := newPostOss(t)
+	form, err := o.PresignedPostPolicy(ctx, "a.txt", -time.Second, oss.WithPostToken(postSecret))
+	require.NoError(t, err)
+	_, err = store.Put(ctx, "a.txt", strings.NewReader("a"), 1)
+	require.NoError(t, err)
+
+	_, err = oss.NewPostVerifier(store, postSecret).Verify(ctx, form.Token, "a.txt", "")
+	assert.NoError(t, err)
+	_, err = oss.NewPostVerifier(store, postSecret, oss.WithPostVerifyGrace(0)).Verify(ctx, form.Token, "a.txt", "")
+	assert.ErrorIs(t, err, oss.ErrSignatureMismatch)
+}
+
+func TestPostVerifier_Confirm(t *testing.T) {
+	ctx := context.Background()
+	store := oss.NewMemoryStorage()
+	form, err := newPostOss(t).PresignedPostPolicy(ctx, "a.txt", time.Minute, oss.WithPostToken(postSecret))
+	require.NoError(t, err)
+	_, err = store.Put(ctx, "a.txt", strings.NewReader("a"), 1, oss.WithPending())
+	require.NoError(t, err)
+
+	_, err = oss.NewPostVerifier(store, postSecret, oss.WithPostConfirm()).Verify(ctx, form.Token, "a.txt", "")
+	require.NoError(t, err)
+	pending, err := oss.IsPending(ctx, store, "a.txt")
+	require.NoError(t, err)
+	assert.False(t, pending)
+}
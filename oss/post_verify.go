@@ -0,0 +1,153 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrUploadRejected 上传的对象不满足直传约束
+var ErrUploadRejected = errors.New("oss: uploaded object rejected")
+
+// PostVerifier 表单直传的回调校验, 后端在记录上传结果之前确认对象确实满足约束
+type PostVerifier struct {
+	storage        Storage
+	secret         []byte
+	grace          time.Duration
+	deleteRejected bool
+	validator      *Validator
+	confirm        bool
+}
+
+// PostVerifierOption 回调校验选项
+type PostVerifierOption func(*PostVerifier)
+
+// WithPostVerifyGrace 策略过期后仍允许回调的时间, 默认 1 小时
+func WithPostVerifyGrace(d time.Duration) PostVerifierOption {
+	return func(v *PostVerifier) {
+		v.grace = d
+	}
+}
+
+// WithPostDeleteRejected 校验不通过时删除已上传的对象
+func WithPostDeleteRejected() PostVerifierOption {
+	return func(v *PostVerifier) {
+		v.deleteRejected = true
+	}
+}
+
+// WithPostValidator 约束满足后再下载对象校验真实类型、校验和并扫描,
+// 客户端可通过表单字段 x-amz-meta-content-sha256 等提供校验和
+func WithPostValidator(validator *Validator) PostVerifierOption {
+	return func(v *PostVerifier) {
+		v.validator = validator
+	}
+}
+
+// WithPostConfirm 校验通过后清除 pending 标签, 存储需实现 Tagger
+func WithPostConfirm() PostVerifierOption {
+	return func(v *PostVerifier) {
+		v.confirm = true
+	}
+}
+
+// NewPostVerifier 创建回调校验, secret 与 WithPostToken 一致
+func NewPostVerifier(storage Storage, secret []byte, opts ...PostVerifierOption) *PostVerifier {
+	v := &PostVerifier{
+		storage: storage,
+		secret:  secret,
+		grace:   time.Hour,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify 校验对象 name (不含 Dir) 是否满足 token 中的约束, etag 不为空时还需一致
+func (v *PostVerifier) Verify(ctx context.Context, token, name, etag string) (ObjectInfo, error) {
+	c, err := parsePostToken(v.secret, token)
+	if err != nil {
+		return ObjectInfo{}, wrapError("verify", name, err)
+	}
+	if time.Now().After(time.Unix(c.Expire, 0).Add(v.grace)) {
+		return ObjectInfo{}, wrapError("verify", name, ErrSignatureMismatch)
+	}
+	name = strings.TrimLeft(name, "/")
+	if c.KeyPrefix {
+		if !strings.HasPrefix(name, c.Key) || name == c.Key {
+			return ObjectInfo{}, wrapError("verify", name, ErrInvalidKey)
+		}
+	} else if name != c.Key {
+		return ObjectInfo{}, wrapError("verify", name, ErrInvalidKey)
+	}
+
+	info, err := v.storage.Stat(ctx, name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	// 前缀下不是该策略上传的对象, 不能确认也不能删除
+	if c.KeyPrefix && (c.Nonce == "" || info.Metadata[MetaPostNonce] != c.Nonce) {
+		return ObjectInfo{}, wrapError("verify", name, fmt.Errorf("%w: object was not uploaded with this policy", ErrInvalidKey))
+	}
+	err = checkPostConstraints(c, info, strings.Trim(etag, `"`))
+	if err == nil && v.validator != nil {
+		if _, err = v.validator.verify(ctx, v.storage, name, Checksums{}); err != nil {
+			if !IsRejected(err) {
+				// 下载或扫描失败, 不能确定对象是否合规
+				return ObjectInfo{}, wrapError("verify", name, err)
+			}
+			err = fmt.Errorf("%w: %w", ErrUploadRejected, err)
+		}
+	}
+	if err != nil {
+		if v.deleteRejected {
+			if e := v.storage.Delete(ctx, name); e != nil {
+				err = errors.Join(err, e)
+			}
+		}
+		return ObjectInfo{}, wrapError("verify", name, err)
+	}
+	if v.confirm {
+		tagger, ok := v.storage.(Tagger)
+		if !ok {
+			return ObjectInfo{}, wrapError("verify", name, ErrNotSupported)
+		}
+		if err = Confirm(ctx, tagger, name); err != nil {
+			return ObjectInfo{}, err
+		}
+	}
+	return info, nil
+}
+
+// VerifyRequest 从回调请求的参数中读取 key, etag 与 token 并校验,
+// 适用于 S3 的 success_action_redirect 回调, key 为包含 Dir 的完整对象名.
+func (v *PostVerifier) VerifyRequest(r *http.Request) (ObjectInfo, error) {
+	token := r.FormValue(PostTokenParam)
+	key := r.FormValue("key")
+	if c, err := parsePostToken(v.secret, token); err == nil && c.Dir != "" {
+		key = strings.TrimPrefix(key, c.Dir+"/")
+	}
+	return v.Verify(r.Context(), token, key, r.FormValue("etag"))
+}
+
+func checkPostConstraints(c PostConstraints, info ObjectInfo, etag string) error {
+	if c.MaxSize > 0 && (info.Size < c.MinSize || info.Size > c.MaxSize) {
+		return fmt.Errorf("%w: size %d not in [%d, %d]", ErrUploadRejected, info.Size, c.MinSize, c.MaxSize)
+	}
+	if !matchContentType(info.ContentType, c.ContentTypes) {
+		return fmt.Errorf("%w: content type %q not allowed", ErrUploadRejected, info.ContentType)
+	}
+	for k, want := range c.Metadata {
+		if got := info.Metadata[k]; got != want {
+			return fmt.Errorf("%w: metadata %s is %q, want %q", ErrUploadRejected, k, got, want)
+		}
+	}
+	if etag != "" && etag != info.ETag {
+		return fmt.Errorf("%w: etag mismatch", ErrUploadRejected)
+	}
+	return nil
+}
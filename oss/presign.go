@@ -0,0 +1,206 @@
+package oss
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 本地预签名链接的查询参数
+const (
+	PresignExpiresParam   = "X-Oss-Expires"
+	PresignSignatureParam = "X-Oss-Signature"
+)
+
+// StorageOption LocalStorage 与 MemoryStorage 的选项
+type StorageOption func(*storageOptions)
+
+type storageOptions struct {
+	presign *presigner
+}
+
+// WithPresign 启用预签名链接模拟, baseURL 为 Handler 对外的地址, 如 http://127.0.0.1:8080/oss,
+// 链接使用 secret 做 HMAC-SHA256 签名.
+func WithPresign(baseURL string, secret []byte) StorageOption {
+	return func(o *storageOptions) {
+		o.presign = &presigner{baseURL: strings.TrimRight(baseURL, "/"), secret: secret}
+	}
+}
+
+// presigner 模拟 S3 的预签名链接: baseURL/name?X-Oss-Expires=unix&X-Oss-Signature=hmac
+type presigner struct {
+	baseURL string
+	secret  []byte
+}
+
+// signature 签名覆盖方法、对象名、过期时间与响应头覆盖参数
+func (p *presigner) signature(method, name string, expires int64, overrides url.Values) string {
+	mac := hmac.New(sha256.New, p.secret)
+	_, _ = io.WriteString(mac, method+"\n"+name+"\n"+strconv.FormatInt(expires, 10)+"\n"+overrides.Encode())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *presigner) sign(method, name string, expires time.Duration, overrides url.Values) (*url.URL, error) {
+	if p == nil {
+		return nil, wrapError("presign", name, ErrNotSupported)
+	}
+	key, err := cleanName(name)
+	if err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	u, err := url.Parse(p.baseURL + "/" + escapeKey(key))
+	if err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	exp := time.Now().Add(expires).Unix()
+	q := make(url.Values, len(overrides)+2)
+	for k, v := range overrides {
+		if _, ok := responseOverrides[k]; ok {
+			q[k] = v
+		}
+	}
+	sig := p.signature(method, key, exp, q)
+	q.Set(PresignExpiresParam, strconv.FormatInt(exp, 10))
+	q.Set(PresignSignatureParam, sig)
+	u.RawQuery = q.Encode()
+	return u, nil
+}
+
+// verify 校验请求签名, HEAD 使用 GET 的签名, 返回对象名
+func (p *presigner) verify(r *http.Request) (string, error) {
+	if p == nil {
+		return "", ErrNotSupported
+	}
+	base, err := url.Parse(p.baseURL)
+	if err != nil {
+		return "", err
+	}
+	name, ok := strings.CutPrefix(r.URL.Path, base.Path+"/")
+	if !ok {
+		return "", ErrInvalidKey
+	}
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	q := r.URL.Query()
+	exp, err := strconv.ParseInt(q.Get(PresignExpiresParam), 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return "", ErrSignatureMismatch
+	}
+	overrides := make(url.Values)
+	for k := range responseOverrides {
+		if q.Has(k) {
+			overrides[k] = q[k]
+		}
+	}
+	if !hmac.Equal([]byte(q.Get(PresignSignatureParam)), []byte(p.signature(method, name, exp, overrides))) {
+		return "", ErrSignatureMismatch
+	}
+	return name, nil
+}
+
+// presignHandler 处理预签名链接的 GET/HEAD/PUT 请求
+func presignHandler(p *presigner, s Storage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPut {
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		name, err := p.verify(r)
+		if err != nil {
+			writeStorageError(w, err)
+			return
+		}
+		if r.Method == http.MethodPut {
+			var opts []PutOption
+			if ct := r.Header.Get("Content-Type"); ct != "" {
+				opts = append(opts, WithContentType(ct))
+			}
+			if md := metadataFromHeader(r.Header); md != nil {
+				opts = append(opts, WithMetadata(md))
+			}
+			if tagging := r.Header.Get("X-Amz-Tagging"); tagging != "" {
+				values, err := url.ParseQuery(tagging)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				tags := make(map[string]string, len(values))
+				for k := range values {
+					tags[k] = values.Get(k)
+				}
+				opts = append(opts, WithTags(tags))
+			}
+			info, err := s.Put(r.Context(), name, r.Body, r.ContentLength, opts...)
+			if err != nil {
+				writeStorageError(w, err)
+				return
+			}
+			w.Header().Set("ETag", `"`+info.ETag+`"`)
+			return
+		}
+
+		rc, info, err := s.Get(r.Context(), name)
+		if err != nil {
+			writeStorageError(w, err)
+			return
+		}
+		defer rc.Close()
+		h := w.Header()
+		h.Set("Content-Type", info.ContentType)
+		h.Set("ETag", `"`+info.ETag+`"`)
+		for k, v := range info.Metadata {
+			h.Set("X-Amz-Meta-"+k, v)
+		}
+		q := r.URL.Query()
+		for param, header := range responseOverrides {
+			if v := q.Get(param); v != "" {
+				h.Set(header, v)
+			}
+		}
+		if rs, ok := rc.(io.ReadSeeker); ok {
+			http.ServeContent(w, r, "", info.LastModified, rs)
+			return
+		}
+		h.Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+		h.Set("Content-Length", strconv.FormatInt(info.Size, 10))
+		if r.Method == http.MethodGet {
+			_, _ = io.Copy(w, rc)
+		}
+	})
+}
+
+func metadataFromHeader(h http.Header) map[string]string {
+	var md map[string]string
+	for k := range h {
+		if name, ok := strings.CutPrefix(k, "X-Amz-Meta-"); ok {
+			if md == nil {
+				md = make(map[string]string)
+			}
+			md[name] = h.Get(k)
+		}
+	}
+	return md
+}
+
+func writeStorageError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrInvalidKey):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrSignatureMismatch), errors.Is(err, ErrNotSupported):
+		status = http.StatusForbidden
+	}
+	http.Error(w, http.StatusText(status), status)
+}
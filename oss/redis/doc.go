@@ -0,0 +1,11 @@
+/*
+  租户用量计数:
+> redis 存储格式:
+>
+>   `keyPrefix{bucket/tenant}` ----> `{ bytes -- bytes, objects -- objects }`
+>
+> bytes: 已使用的字节数
+> objects: 对象数
+*/
+
+package redis
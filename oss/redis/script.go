@@ -0,0 +1,13 @@
+package redis
+
+import _ "embed"
+
+const (
+	// inner lua usage add status value
+	InnerUsageAddSuccess           = 0
+	InnerUsageAddMaxBytesReached   = 1
+	InnerUsageAddMaxObjectsReached = 2
+)
+
+//go:embed usage_add.lua
+var UsageAddScript string
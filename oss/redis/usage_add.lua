@@ -0,0 +1,19 @@
+local key = KEYS[1]                    -- 用量 key
+local deltaBytes = tonumber(ARGV[1])   -- 增加的字节数
+local deltaObjects = tonumber(ARGV[2]) -- 增加的对象数
+local maxBytes = tonumber(ARGV[3])     -- 字节数配额, 0 表示不限制
+local maxObjects = tonumber(ARGV[4])   -- 对象数配额, 0 表示不限制
+
+local bytes = tonumber(redis.call("HGET", key, "bytes") or "0")
+local objects = tonumber(redis.call("HGET", key, "objects") or "0")
+
+if deltaBytes > 0 and maxBytes > 0 and bytes + deltaBytes > maxBytes then
+    return { 1, bytes, objects } -- 超出字节数配额
+end
+if deltaObjects > 0 and maxObjects > 0 and objects + deltaObjects > maxObjects then
+    return { 2, bytes, objects } -- 超出对象数配额
+end
+
+bytes = redis.call("HINCRBY", key, "bytes", deltaBytes)
+objects = redis.call("HINCRBY", key, "objects", deltaObjects)
+return { 0, bytes, objects } -- 成功
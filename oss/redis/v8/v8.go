@@ -0,0 +1,81 @@
+package v8
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/zmicro-team/ztlib/oss"
+	redisScript "github.com/zmicro-team/ztlib/oss/redis"
+)
+
+var _ oss.UsageStore = (*RedisStore)(nil)
+
+// RedisStore oss tenant usage store
+type RedisStore struct {
+	store     *redis.Client // store redis client
+	keyPrefix string        // key prefix
+}
+
+// NewRedisStore new redis store instance, keys are keyPrefix + key.
+func NewRedisStore(store *redis.Client, keyPrefix string) *RedisStore {
+	return &RedisStore{store: store, keyPrefix: keyPrefix}
+}
+
+// Add usage atomically, returns oss.ErrQuotaExceeded if the increased part exceeds quota.
+func (v *RedisStore) Add(ctx context.Context, key string, delta oss.Usage, quota oss.Quota) (oss.Usage, error) {
+	res, err := v.store.Eval(
+		ctx,
+		redisScript.UsageAddScript,
+		[]string{v.keyPrefix + key},
+		[]string{
+			strconv.FormatInt(delta.Bytes, 10),
+			strconv.FormatInt(delta.Objects, 10),
+			strconv.FormatInt(quota.MaxBytes, 10),
+			strconv.FormatInt(quota.MaxObjects, 10),
+		},
+	).Int64Slice()
+	if err != nil {
+		return oss.Usage{}, err
+	}
+	if len(res) != 3 {
+		return oss.Usage{}, errors.New("oss: unexpected usage script result")
+	}
+	usage := oss.Usage{Bytes: res[1], Objects: res[2]}
+	switch res[0] {
+	case redisScript.InnerUsageAddSuccess:
+		return usage, nil
+	case redisScript.InnerUsageAddMaxBytesReached:
+		err = fmt.Errorf("%w: %d + %d bytes exceeds %d", oss.ErrQuotaExceeded, usage.Bytes, delta.Bytes, quota.MaxBytes)
+	case redisScript.InnerUsageAddMaxObjectsReached:
+		err = fmt.Errorf("%w: %d + %d objects exceeds %d", oss.ErrQuotaExceeded, usage.Objects, delta.Objects, quota.MaxObjects)
+	default:
+		err = errors.New("oss: unknown usage script status")
+	}
+	return usage, err
+}
+
+// Get usage, returns zero if not exist.
+func (v *RedisStore) Get(ctx context.Context, key string) (oss.Usage, error) {
+	vals, err := v.store.HMGet(ctx, v.keyPrefix+key, "bytes", "objects").Result()
+	if err != nil {
+		return oss.Usage{}, err
+	}
+	var usage oss.Usage
+	for i, p := range []*int64{&usage.Bytes, &usage.Objects} {
+		if s, ok := vals[i].(string); ok {
+			if *p, err = strconv.ParseInt(s, 10, 64); err != nil {
+				return oss.Usage{}, err
+			}
+		}
+	}
+	return usage, nil
+}
+
+// Set overwrites usage.
+func (v *RedisStore) Set(ctx context.Context, key string, usage oss.Usage) error {
+	return v.store.HSet(ctx, v.keyPrefix+key, "bytes", usage.Bytes, "objects", usage.Objects).Err()
+}
@@ -0,0 +1,36 @@
+package v8
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/oss"
+	"github.com/zmicro-team/ztlib/oss/tests"
+)
+
+func Test_RedisV8_UsageStore(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.Nil(t, err)
+	defer mr.Close()
+
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "oss:usage:")
+	tests.TestUsageStore(t, store)
+	assert.Equal(t, "1", mr.HGet("oss:usage:t1", "bytes"))
+}
+
+func Test_RedisV8_UsageStore_RedisUnavailable(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.Nil(t, err)
+	addr := mr.Addr()
+	mr.Close()
+
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: addr}), "oss:usage:")
+	_, err = store.Add(context.Background(), "t1", oss.Usage{Bytes: 1}, oss.Quota{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, oss.ErrQuotaExceeded)
+}
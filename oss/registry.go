@@ -0,0 +1,78 @@
+package oss
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrDuplicateBucket 桶名已注册
+var ErrDuplicateBucket = errors.New("oss: duplicate bucket")
+
+// Registry 按名称管理多个桶, 并创建各桶上的租户视图
+type Registry struct {
+	mu         sync.RWMutex
+	buckets    map[string]Storage
+	tenantOpts []TenantOption
+}
+
+// NewRegistry 按 configs 创建各桶的 OssUtil, opts 为所有租户的默认选项, 如 WithUsageStore
+func NewRegistry(configs map[string]OssUtilConfig, opts ...TenantOption) *Registry {
+	r := &Registry{
+		buckets:    make(map[string]Storage, len(configs)),
+		tenantOpts: opts,
+	}
+	for name, c := range configs {
+		r.buckets[name] = NewOssUtil(c)
+	}
+	return r
+}
+
+// Register 注册其他实现的存储, 如 LocalStorage
+func (r *Registry) Register(name string, storage Storage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.buckets[name]; ok {
+		return ErrDuplicateBucket
+	}
+	r.buckets[name] = storage
+	return nil
+}
+
+// Bucket 按名称获取存储
+func (r *Registry) Bucket(name string) (Storage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	storage, ok := r.buckets[name]
+	if !ok {
+		return nil, wrapError("registry", name, ErrBucketNotFound)
+	}
+	return storage, nil
+}
+
+// Names 已注册的桶名, 升序
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.buckets))
+	for name := range r.buckets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Tenant 获取桶 bucket 上租户 id 的视图, 用量以 bucket/id 为 key 计数, opts 覆盖默认选项
+func (r *Registry) Tenant(bucket, id string, opts ...TenantOption) (*Tenant, error) {
+	storage, err := r.Bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(slices.Clip(r.tenantOpts), opts...)
+	t, err := NewTenant(storage, id, opts...)
+	if err != nil {
+		return nil, wrapError("registry", id, err)
+	}
+	t.usageKey = bucket + "/" + id
+	return t, nil
+}
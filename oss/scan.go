@@ -0,0 +1,114 @@
+package oss
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrInfected 扫描器发现病毒或恶意内容
+var ErrInfected = errors.New("oss: content infected")
+
+// Scanner 内容扫描, 如杀毒; 发现问题时返回包装了 ErrInfected 的错误,
+// 其他错误表示扫描本身失败.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+// ScannerFunc 函数形式的 Scanner
+type ScannerFunc func(ctx context.Context, r io.Reader) error
+
+func (f ScannerFunc) Scan(ctx context.Context, r io.Reader) error {
+	return f(ctx, r)
+}
+
+// ClamdScanner 通过 clamd 的 INSTREAM 命令扫描
+type ClamdScanner struct {
+	// Network tcp 或 unix, 默认 tcp
+	Network string
+	// Addr 如 127.0.0.1:3310 或 /var/run/clamav/clamd.ctl
+	Addr string
+	// Timeout 连接与扫描的超时, 默认 1 分钟
+	Timeout time.Duration
+	// ChunkSize 每次发送的字节数, 默认 64KB; 总大小受 clamd 的 StreamMaxLength 限制
+	ChunkSize int
+}
+
+// Scan 发送内容到 clamd, 返回 "stream: <name> FOUND" 时包装为 ErrInfected
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) error {
+	network, timeout, chunkSize := s.Network, s.Timeout, s.ChunkSize
+	if network == "" {
+		network = "tcp"
+	}
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	if chunkSize <= 0 {
+		chunkSize = 64 << 10
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, s.Addr)
+	if err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	// ctx 取消时中断阻塞的读写
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	w := bufio.NewWriterSize(conn, chunkSize+4)
+	if _, err = w.WriteString("zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, rerr := io.ReadFull(r, buf)
+		if n > 0 {
+			_ = binary.Write(w, binary.BigEndian, uint32(n))
+			if _, err = w.Write(buf[:n]); err != nil {
+				return fmt.Errorf("clamd: %w", err)
+			}
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+	if _, err = w.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	if err = w.Flush(); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	return parseClamdReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+func parseClamdReply(reply string) error {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return fmt.Errorf("%w: %s", ErrInfected, strings.TrimSuffix(result, " FOUND"))
+	default:
+		return fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,69 @@
+package oss
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// SniffLen 识别内容类型需要的最大字节数
+const SniffLen = 512
+
+// magics 补充 http.DetectContentType 不识别的格式
+var magics = []struct {
+	offset      int
+	sig         []byte
+	contentType string
+}{
+	{0, []byte("7z\xbc\xaf\x27\x1c"), "application/x-7z-compressed"},
+	{0, []byte("BZh"), "application/x-bzip2"},
+	{0, []byte("\xfd7zXZ\x00"), "application/x-xz"},
+	{0, []byte("\x28\xb5\x2f\xfd"), "application/zstd"},
+	{257, []byte("ustar"), "application/x-tar"},
+	{0, []byte("\x7fELF"), "application/x-executable"},
+	{0, []byte("MZ"), "application/vnd.microsoft.portable-executable"},
+	{0, []byte("\xca\xfe\xba\xbe"), "application/x-mach-binary"},
+	{0, []byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary"},
+	{0, []byte("#!"), "text/x-shellscript"},
+	{0, []byte("II*\x00"), "image/tiff"},
+	{0, []byte("MM\x00*"), "image/tiff"},
+	{0, []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"), "application/x-ole-storage"},
+}
+
+// ftyp ISO BMFF 的品牌, http.DetectContentType 只识别 mp4
+var ftypBrands = map[string]string{
+	"heic": "image/heic",
+	"heix": "image/heic",
+	"mif1": "image/heif",
+	"avif": "image/avif",
+	"qt  ": "video/quicktime",
+}
+
+// SniffContentType 按文件头识别真实的内容类型, 不参考扩展名与客户端声明的类型,
+// 在 http.DetectContentType 的基础上补充了压缩包、可执行文件与 heic/avif 等格式.
+func SniffContentType(head []byte) string {
+	if len(head) > SniffLen {
+		head = head[:SniffLen]
+	}
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		if t, ok := ftypBrands[string(head[8:12])]; ok {
+			return t
+		}
+	}
+	t := http.DetectContentType(head)
+	if t != "application/octet-stream" && !strings.HasPrefix(t, "text/plain") {
+		return t
+	}
+	for _, m := range magics {
+		if len(head) >= m.offset+len(m.sig) && bytes.Equal(head[m.offset:m.offset+len(m.sig)], m.sig) {
+			return m.contentType
+		}
+	}
+	return t
+}
+
+// mediaType 去掉参数并转为小写
+func mediaType(contentType string) string {
+	t, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(t))
+}
@@ -0,0 +1,76 @@
+package oss
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	minIo "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// WithSSES3 服务端使用托管密钥加密 (SSE-S3), 仅 OssUtil 支持, 其他存储返回 ErrNotSupported;
+// 服务端复制时不保留加密方式, Copy 需再次指定
+func WithSSES3() PutOption {
+	return func(o *minIo.PutObjectOptions) {
+		o.ServerSideEncryption = encrypt.NewSSE()
+	}
+}
+
+// WithSSEC 服务端使用调用方提供的密钥加密 (SSE-C), 仅 OssUtil 支持, 其他存储返回 ErrNotSupported;
+// 服务端不保存密钥, 下载时需用 WithGetSSEC 提供同一密钥, Stat 不可用; Copy 时源对象也使用该密钥解密
+func WithSSEC(key [32]byte) PutOption {
+	sse, _ := encrypt.NewSSEC(key[:])
+	return func(o *minIo.PutObjectOptions) {
+		o.ServerSideEncryption = sse
+	}
+}
+
+// WithGetSSEC 下载 SSE-C 加密的对象
+func WithGetSSEC(key [32]byte) GetOption {
+	return func(o *minIo.GetObjectOptions) error {
+		sse, err := encrypt.NewSSEC(key[:])
+		o.ServerSideEncryption = sse
+		return err
+	}
+}
+
+// PresignedPutHeader 返回按 opts 上传的链接, opts 对应的请求头 (如 Content-Type、
+// 元数据、标签与服务端加密) 参与签名, 客户端上传时必须带上返回的 header.
+func (o *OssUtil) PresignedPutHeader(ctx context.Context, name string, expires time.Duration, opts ...PutOption) (*url.URL, http.Header, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return nil, nil, wrapError("presign", name, err)
+	}
+	options := putOptions(opts)
+	header := options.Header()
+	if options.ContentType == "" {
+		header.Del("Content-Type")
+	}
+	u, err := o.Client.PresignHeader(ctx, http.MethodPut, o.Config.BucketName, key, expires, nil, header)
+	if err != nil {
+		return nil, nil, wrapError("presign", name, err)
+	}
+	return u, header, nil
+}
+
+// PresignedGetSSEC 返回 SSE-C 加密对象的下载链接, 客户端下载时必须带上返回的 header,
+// header 中包含密钥, 只应交给可信的客户端
+func (o *OssUtil) PresignedGetSSEC(ctx context.Context, name string, expires time.Duration, sseKey [32]byte, opts ...PresignOption) (*url.URL, http.Header, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return nil, nil, wrapError("presign", name, err)
+	}
+	sse, err := encrypt.NewSSEC(sseKey[:])
+	if err != nil {
+		return nil, nil, wrapError("presign", name, err)
+	}
+	header := http.Header{}
+	sse.Marshal(header)
+	u, err := o.Client.PresignHeader(ctx, http.MethodGet, o.Config.BucketName, key, expires, presignParams(opts), header)
+	if err != nil {
+		return nil, nil, wrapError("presign", name, err)
+	}
+	return u, header, nil
+}
@@ -0,0 +1,234 @@
+package oss
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	minIo "github.com/minio/minio-go/v7"
+)
+
+// Storage 对象存储抽象, OssUtil(S3/minio)、LocalStorage、MemoryStorage 行为一致,
+// 对象名均为相对名称, 不允许包含 ".." 路径段、反斜杠与盘符.
+type Storage interface {
+	Put(ctx context.Context, name string, r io.Reader, size int64, opts ...PutOption) (ObjectInfo, error)
+	Get(ctx context.Context, name string, opts ...GetOption) (io.ReadCloser, ObjectInfo, error)
+	Stat(ctx context.Context, name string) (ObjectInfo, error)
+	List(ctx context.Context, prefix string, opts ...ListOption) iter.Seq2[ObjectInfo, error]
+	// ListPage 分页列举, limit 需大于 0, 否则返回 ErrInvalidLimit
+	ListPage(ctx context.Context, prefix, startAfter string, limit int, opts ...ListOption) ([]ObjectInfo, string, error)
+	Copy(ctx context.Context, src, dst string, opts ...PutOption) (ObjectInfo, error)
+	Delete(ctx context.Context, name string) error
+	DeleteMany(ctx context.Context, names []string) error
+	// PresignedGet 返回有效期为 expires 的下载链接, opts 可覆盖下载时的响应头
+	PresignedGet(ctx context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error)
+	// PresignedHead 返回有效期为 expires 的 HEAD 链接
+	PresignedHead(ctx context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error)
+	// PresignedPut 返回有效期为 expires 的上传链接, 使用 HTTP PUT 上传
+	PresignedPut(ctx context.Context, name string, expires time.Duration) (*url.URL, error)
+}
+
+var (
+	_ Storage = (*OssUtil)(nil)
+	_ Storage = (*LocalStorage)(nil)
+	_ Storage = (*MemoryStorage)(nil)
+)
+
+// PresignedGet 返回有效期为 expires 的下载链接, opts 可覆盖下载时的响应头
+func (o *OssUtil) PresignedGet(ctx context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	u, err := o.Client.PresignedGetObject(ctx, o.Config.BucketName, key, expires, presignParams(opts))
+	return u, wrapError("presign", name, err)
+}
+
+// PresignedHead 返回有效期为 expires 的 HEAD 链接
+func (o *OssUtil) PresignedHead(ctx context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	u, err := o.Client.PresignedHeadObject(ctx, o.Config.BucketName, key, expires, presignParams(opts))
+	return u, wrapError("presign", name, err)
+}
+
+// PresignedPut 返回有效期为 expires 的上传链接
+func (o *OssUtil) PresignedPut(ctx context.Context, name string, expires time.Duration) (*url.URL, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	u, err := o.Client.PresignedPutObject(ctx, o.Config.BucketName, key, expires)
+	return u, wrapError("presign", name, err)
+}
+
+// cleanName 去掉开头的 "/", 拒绝空名称、"." 与 ".." 路径段及跳出根目录的名称
+func cleanName(name string) (string, error) {
+	name = strings.TrimLeft(name, "/")
+	if name == "" || strings.HasSuffix(name, "/") || !isLocalName(name) {
+		return "", ErrInvalidKey
+	}
+	for _, seg := range strings.Split(name, "/") {
+		if seg == ".." || seg == "." {
+			return "", ErrInvalidKey
+		}
+	}
+	return name, nil
+}
+
+// cleanPrefix 与 cleanName 类似, 但允许为空或以 "/" 结尾
+func cleanPrefix(prefix string) (string, error) {
+	prefix = strings.TrimLeft(prefix, "/")
+	if strings.Contains("/"+prefix+"/", "/../") {
+		return "", ErrInvalidKey
+	}
+	if p := strings.TrimSuffix(prefix, "/"); p != "" && !isLocalName(p) {
+		return "", ErrInvalidKey
+	}
+	return prefix, nil
+}
+
+// isLocalName 名称作为本地相对路径时不会跳出根目录;
+// 反斜杠在 Windows 上是分隔符, 与盘符一样在所有平台上拒绝, 保证各实现行为一致
+func isLocalName(name string) bool {
+	if strings.ContainsAny(name, "\\\x00") {
+		return false
+	}
+	if len(name) >= 2 && name[1] == ':' && ('a' <= name[0]|0x20 && name[0]|0x20 <= 'z') {
+		return false
+	}
+	return filepath.IsLocal(filepath.FromSlash(name))
+}
+
+// canonicalMetadata 元数据 key 与 S3 返回的一致
+func canonicalMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		m[http.CanonicalHeaderKey(k)] = v
+	}
+	return m
+}
+
+// getRange GetOption 中的 Range 与 If-Match, 供非 S3 实现使用
+type getRange struct {
+	start, end int64 // end 为 -1 表示到结尾, start 为负数表示最后 -start 字节
+	ranged     bool
+	matchETag  string
+}
+
+func parseGetOptions(opts []GetOption) (getRange, error) {
+	var options minIo.GetObjectOptions
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return getRange{}, err
+		}
+	}
+	h := options.Header()
+	g := getRange{end: -1, matchETag: strings.Trim(h.Get("If-Match"), `"`)}
+	spec, ok := strings.CutPrefix(h.Get("Range"), "bytes=")
+	if !ok {
+		return g, nil
+	}
+	first, last, _ := strings.Cut(spec, "-")
+	var err error
+	g.ranged = true
+	if first == "" {
+		g.start, err = strconv.ParseInt("-"+last, 10, 64)
+		return g, err
+	}
+	if g.start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return g, err
+	}
+	if last != "" {
+		g.end, err = strconv.ParseInt(last, 10, 64)
+	}
+	return g, err
+}
+
+// section 返回范围对应的 [offset, offset+length)
+func (g getRange) section(size int64) (offset, length int64) {
+	if !g.ranged {
+		return 0, size
+	}
+	if g.start < 0 {
+		offset = max(size+g.start, 0)
+		return offset, size - offset
+	}
+	offset = min(g.start, size)
+	end := size - 1
+	if g.end >= 0 && g.end < end {
+		end = g.end
+	}
+	return offset, max(end-offset+1, 0)
+}
+
+// listInfos 按 S3 语义列举已按 Key 升序排列的对象
+func listInfos(infos []ObjectInfo, prefix string, opts []ListOption) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		prefix, err := cleanPrefix(prefix)
+		if err != nil {
+			yield(ObjectInfo{}, wrapError("list", prefix, err))
+			return
+		}
+		options := minIo.ListObjectsOptions{Recursive: true}
+		for _, opt := range opts {
+			opt(&options)
+		}
+		after := options.StartAfter
+		if after != "" {
+			if after, err = cleanPrefix(after); err != nil {
+				yield(ObjectInfo{}, wrapError("list", prefix, err))
+				return
+			}
+		}
+		lastDir := ""
+		for _, info := range infos {
+			if !strings.HasPrefix(info.Key, prefix) || info.Key <= after {
+				continue
+			}
+			if !options.Recursive {
+				if i := strings.IndexByte(info.Key[len(prefix):], '/'); i >= 0 {
+					dir := info.Key[:len(prefix)+i+1]
+					if dir == lastDir || dir <= after {
+						continue
+					}
+					lastDir = dir
+					info = ObjectInfo{Key: dir, IsDir: true}
+				}
+			}
+			if !yield(info, nil) {
+				return
+			}
+		}
+	}
+}
+
+// collectPage 从 seq 中取最多 limit 个, 返回下一页的 startAfter
+func collectPage(seq iter.Seq2[ObjectInfo, error], limit int) ([]ObjectInfo, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("%w: %d", ErrInvalidLimit, limit)
+	}
+	page := make([]ObjectInfo, 0, limit)
+	for info, err := range seq {
+		if err != nil {
+			return nil, "", err
+		}
+		if len(page) == limit {
+			return page, page[len(page)-1].Key, nil
+		}
+		page = append(page, info)
+	}
+	return page, "", nil
+}
@@ -0,0 +1,119 @@
+package oss_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/oss"
+	"github.com/zmicro-team/ztlib/oss/internal/fakes3"
+	"github.com/zmicro-team/ztlib/oss/tests"
+)
+
+// newPresignServer 启动预签名链接服务, 返回的 handler 需在创建存储后设置
+func newPresignServer(t *testing.T) (*httptest.Server, *http.Handler) {
+	var handler http.Handler = http.NotFoundHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &handler
+}
+
+func TestStorage_OssUtil(t *testing.T) {
+	srv := fakes3.New("bucket")
+	defer srv.Close()
+	tests.TestStorage(t, oss.NewOssUtil(oss.OssUtilConfig{
+		EndPoint:        srv.Endpoint(),
+		Region:          "us-east-1",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		BucketName:      "bucket",
+		Dir:             "temp",
+	}))
+}
+
+func TestStorage_Local(t *testing.T) {
+	srv, handler := newPresignServer(t)
+	store, err := oss.NewLocalStorage(t.TempDir(), oss.WithPresign(srv.URL+"/files", []byte("secret")))
+	require.NoError(t, err)
+	*handler = store.Handler()
+	tests.TestStorage(t, store)
+
+	_, err = store.Put(context.Background(), ".meta/a", strings.NewReader("x"), 1)
+	assert.ErrorIs(t, err, oss.ErrInvalidKey)
+}
+
+func TestStorage_Memory(t *testing.T) {
+	srv, handler := newPresignServer(t)
+	store := oss.NewMemoryStorage(oss.WithPresign(srv.URL, []byte("secret")))
+	*handler = store.Handler()
+	tests.TestStorage(t, store)
+}
+
+func TestStorage_Presign(t *testing.T) {
+	ctx := context.Background()
+	srv, handler := newPresignServer(t)
+	store := oss.NewMemoryStorage(oss.WithPresign(srv.URL+"/oss/", []byte("secret")))
+	*handler = store.Handler()
+	_, err := store.Put(ctx, "a.txt", strings.NewReader("hello"), 5)
+	require.NoError(t, err)
+
+	status := func(method, u string) int {
+		req, err := http.NewRequest(method, u, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	u, err := store.PresignedGet(ctx, "a.txt", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "/oss/a.txt", u.Path)
+	assert.Equal(t, http.StatusOK, status(http.MethodGet, u.String()))
+	assert.Equal(t, http.StatusOK, status(http.MethodHead, u.String()))
+	// GET 的签名不能用于 PUT
+	assert.Equal(t, http.StatusForbidden, status(http.MethodPut, u.String()))
+	assert.Equal(t, http.StatusMethodNotAllowed, status(http.MethodDelete, u.String()))
+
+	// 篡改对象名
+	tampered := *u
+	tampered.Path = "/oss/b.txt"
+	assert.Equal(t, http.StatusForbidden, status(http.MethodGet, tampered.String()))
+
+	// 篡改过期时间
+	q := u.Query()
+	q.Set(oss.PresignExpiresParam, "9999999999")
+	tampered.Path, tampered.RawQuery = u.Path, q.Encode()
+	assert.Equal(t, http.StatusForbidden, status(http.MethodGet, tampered.String()))
+
+	// 篡改响应头覆盖参数
+	u, err = store.PresignedGet(ctx, "a.txt", time.Minute, oss.WithResponseContentType("text/plain"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status(http.MethodGet, u.String()))
+	q = u.Query()
+	q.Set("response-content-type", "text/html")
+	tampered.Path, tampered.RawQuery = u.Path, q.Encode()
+	assert.Equal(t, http.StatusForbidden, status(http.MethodGet, tampered.String()))
+
+	// 已过期
+	u, err = store.PresignedGet(ctx, "a.txt", -time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, status(http.MethodGet, u.String()))
+
+	// 对象不存在
+	u, err = store.PresignedGet(ctx, "missing", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status(http.MethodGet, u.String()))
+
+	// 未启用预签名
+	_, err = oss.NewMemoryStorage().PresignedGet(ctx, "a.txt", time.Minute)
+	assert.ErrorIs(t, err, oss.ErrNotSupported)
+}
@@ -0,0 +1,209 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Sweeper 删除超过 TTL 仍未确认的上传, 用于不支持按标签过期的存储或需要更短周期的场景
+type Sweeper struct {
+	storage Storage
+	tagger  Tagger
+	ttl     time.Duration
+	prefix  string
+	dryRun  bool
+	output  io.Writer
+	batch   int
+	now     func() time.Time
+}
+
+// SweeperOption 清理选项
+type SweeperOption func(*Sweeper)
+
+// WithSweepPrefix 只清理 prefix 下的对象
+func WithSweepPrefix(prefix string) SweeperOption {
+	return func(s *Sweeper) {
+		s.prefix = prefix
+	}
+}
+
+// WithSweepDryRun 只输出将要删除的对象, 不删除
+func WithSweepDryRun() SweeperOption {
+	return func(s *Sweeper) {
+		s.dryRun = true
+	}
+}
+
+// WithSweepOutput 逐行输出删除的对象与汇总, 默认不输出
+func WithSweepOutput(w io.Writer) SweeperOption {
+	return func(s *Sweeper) {
+		s.output = w
+	}
+}
+
+// WithSweepBatch 每次 DeleteMany 的对象数, 默认 1000
+func WithSweepBatch(n int) SweeperOption {
+	return func(s *Sweeper) {
+		if n > 0 {
+			s.batch = n
+		}
+	}
+}
+
+// SweepResult 一次清理的结果
+type SweepResult struct {
+	// Scanned 列举的对象数
+	Scanned int
+	// Expired 超过 TTL 的未确认对象
+	Expired []ObjectInfo
+	// Deleted 实际删除的对象数, DryRun 时为 0
+	Deleted int
+	// Bytes 超过 TTL 的未确认对象总大小
+	Bytes  int64
+	DryRun bool
+}
+
+// NewSweeper 创建清理任务, storage 需实现 Tagger, 否则返回 ErrNotSupported
+func NewSweeper(storage Storage, ttl time.Duration, opts ...SweeperOption) (*Sweeper, error) {
+	tagger, ok := storage.(Tagger)
+	if !ok {
+		return nil, wrapError("sweep", "", ErrNotSupported)
+	}
+	s := &Sweeper{
+		storage: storage,
+		tagger:  tagger,
+		ttl:     ttl,
+		output:  io.Discard,
+		batch:   1000,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Sweep 执行一次清理, 先按修改时间筛选再读取标签, 删除前重新确认对象未被确认或覆盖,
+// 删除失败时返回已完成的部分结果
+func (s *Sweeper) Sweep(ctx context.Context) (SweepResult, error) {
+	res := SweepResult{DryRun: s.dryRun}
+	deadline := s.now().Add(-s.ttl)
+	var batch []ObjectInfo
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		// 列举到删除之间对象可能已被确认或覆盖
+		expired := make([]ObjectInfo, 0, len(batch))
+		for _, info := range batch {
+			ok, err := s.stillPending(ctx, info)
+			if err != nil {
+				return err
+			}
+			if ok {
+				expired = append(expired, info)
+			}
+		}
+		batch = batch[:0]
+		if len(expired) == 0 {
+			return nil
+		}
+		names := make([]string, len(expired))
+		for i, info := range expired {
+			names[i] = info.Key
+		}
+		if err := s.storage.DeleteMany(ctx, names); err != nil {
+			return err
+		}
+		for _, info := range expired {
+			_, _ = fmt.Fprintf(s.output, "deleted %s\n", info.Key)
+			res.Expired = append(res.Expired, info)
+			res.Bytes += info.Size
+		}
+		res.Deleted += len(expired)
+		return nil
+	}
+
+	for info, err := range s.storage.List(ctx, s.prefix) {
+		if err != nil {
+			return res, err
+		}
+		res.Scanned++
+		if info.IsDir || !info.LastModified.Before(deadline) {
+			continue
+		}
+		pending, err := IsPending(ctx, s.tagger, info.Key)
+		if errors.Is(err, ErrNotFound) {
+			// 列举后已被删除
+			continue
+		}
+		if err != nil {
+			return res, err
+		}
+		if !pending {
+			continue
+		}
+		if s.dryRun {
+			res.Expired = append(res.Expired, info)
+			res.Bytes += info.Size
+			_, _ = fmt.Fprintf(s.output, "would delete %s (%d bytes, modified %s)\n",
+				info.Key, info.Size, info.LastModified.UTC().Format(time.RFC3339))
+			continue
+		}
+		if batch = append(batch, info); len(batch) >= s.batch {
+			if err = flush(); err != nil {
+				return res, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return res, err
+	}
+	verb := "deleted"
+	if s.dryRun {
+		verb = "would delete"
+	}
+	_, _ = fmt.Fprintf(s.output, "sweep: scanned %d, %s %d pending objects older than %s (%d bytes)\n",
+		res.Scanned, verb, len(res.Expired), s.ttl, res.Bytes)
+	return res, nil
+}
+
+// stillPending 对象仍是列举时的版本 (ETag 一致) 且仍未确认
+func (s *Sweeper) stillPending(ctx context.Context, listed ObjectInfo) (bool, error) {
+	info, err := s.storage.Stat(ctx, listed.Key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// S3 列举返回的 ETag 带引号
+	if strings.Trim(info.ETag, `"`) != strings.Trim(listed.ETag, `"`) {
+		return false, nil
+	}
+	pending, err := IsPending(ctx, s.tagger, listed.Key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return pending, err
+}
+
+// Run 每隔 interval 执行一次清理直到 ctx 结束, 单次失败写入输出后继续
+func (s *Sweeper) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			_, _ = fmt.Fprintf(s.output, "sweep: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package oss
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/url"
+	"time"
+
+	minIo "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+// 未确认上传的标签, 确认后清除, 未确认的对象由 Sweeper 或生命周期规则删除
+const (
+	PendingTagKey   = "upload-status"
+	PendingTagValue = "pending"
+)
+
+// Tagger 支持对象标签的存储, OssUtil、MemoryStorage 与 Tenant 实现
+type Tagger interface {
+	Tags(ctx context.Context, name string) (map[string]string, error)
+	// SetTags 替换对象的全部标签, tags 为空时删除标签
+	SetTags(ctx context.Context, name string, tags map[string]string) error
+}
+
+var (
+	_ Tagger = (*OssUtil)(nil)
+	_ Tagger = (*MemoryStorage)(nil)
+	_ Tagger = (*Tenant)(nil)
+)
+
+// WithTags 上传或复制时设置对象标签
+func WithTags(tags map[string]string) PutOption {
+	return func(o *minIo.PutObjectOptions) {
+		if o.UserTags == nil {
+			o.UserTags = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			o.UserTags[k] = v
+		}
+	}
+}
+
+// WithPending 标记为未确认的上传, 需调用 Confirm 确认
+func WithPending() PutOption {
+	return WithTags(map[string]string{PendingTagKey: PendingTagValue})
+}
+
+// IsPending 对象是否未确认
+func IsPending(ctx context.Context, t Tagger, name string) (bool, error) {
+	tags, err := t.Tags(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return tags[PendingTagKey] == PendingTagValue, nil
+}
+
+// Confirm 确认上传, 清除 pending 标签并保留其他标签
+func Confirm(ctx context.Context, t Tagger, name string) error {
+	tags, err := t.Tags(ctx, name)
+	if err != nil {
+		return err
+	}
+	if _, ok := tags[PendingTagKey]; !ok {
+		return nil
+	}
+	delete(tags, PendingTagKey)
+	return t.SetTags(ctx, name, tags)
+}
+
+// Tags 获取对象标签
+func (o *OssUtil) Tags(ctx context.Context, name string) (map[string]string, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return nil, wrapError("tags", name, err)
+	}
+	t, err := o.Client.GetObjectTagging(ctx, o.Config.BucketName, key, minIo.GetObjectTaggingOptions{})
+	if err != nil {
+		return nil, wrapError("tags", name, err)
+	}
+	return t.ToMap(), nil
+}
+
+// SetTags 替换对象的全部标签, tags 为空时删除标签
+func (o *OssUtil) SetTags(ctx context.Context, name string, m map[string]string) error {
+	key, err := o.Key(name)
+	if err != nil {
+		return wrapError("tags", name, err)
+	}
+	if len(m) == 0 {
+		err = o.Client.RemoveObjectTagging(ctx, o.Config.BucketName, key, minIo.RemoveObjectTaggingOptions{})
+		return wrapError("tags", name, err)
+	}
+	t, err := tags.MapToObjectTags(m)
+	if err != nil {
+		return wrapError("tags", name, err)
+	}
+	err = o.Client.PutObjectTagging(ctx, o.Config.BucketName, key, t, minIo.PutObjectTaggingOptions{})
+	return wrapError("tags", name, err)
+}
+
+// PresignedPutPending 返回带 pending 标签的上传链接, 标签头参与签名,
+// 客户端上传时必须带上返回的 header, 否则签名校验失败.
+func (o *OssUtil) PresignedPutPending(ctx context.Context, name string, expires time.Duration) (*url.URL, http.Header, error) {
+	return o.PresignedPutHeader(ctx, name, expires, WithPending())
+}
+
+// Tags 获取对象标签
+func (m *MemoryStorage) Tags(_ context.Context, name string) (map[string]string, error) {
+	obj, err := m.object("tags", name)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(obj.tags), nil
+}
+
+// SetTags 替换对象的全部标签, tags 为空时删除标签
+func (m *MemoryStorage) SetTags(_ context.Context, name string, tags map[string]string) error {
+	obj, err := m.object("tags", name)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj.tags = maps.Clone(tags)
+	return nil
+}
+
+// Tags 获取对象标签, 内部存储不支持标签时返回 ErrNotSupported
+func (t *Tenant) Tags(ctx context.Context, name string) (map[string]string, error) {
+	key, err := t.key(name)
+	if err != nil {
+		return nil, wrapError("tags", name, err)
+	}
+	tagger, ok := t.storage.(Tagger)
+	if !ok {
+		return nil, wrapError("tags", name, ErrNotSupported)
+	}
+	tags, err := tagger.Tags(ctx, key)
+	return tags, t.unscope(err)
+}
+
+// SetTags 替换对象的全部标签, 内部存储不支持标签时返回 ErrNotSupported
+func (t *Tenant) SetTags(ctx context.Context, name string, tags map[string]string) error {
+	key, err := t.key(name)
+	if err != nil {
+		return wrapError("tags", name, err)
+	}
+	tagger, ok := t.storage.(Tagger)
+	if !ok {
+		return wrapError("tags", name, ErrNotSupported)
+	}
+	return t.unscope(tagger.SetTags(ctx, key, tags))
+}
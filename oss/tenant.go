@@ -0,0 +1,412 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"net/url"
+	"strings"
+	"time"
+
+	minIo "github.com/minio/minio-go/v7"
+)
+
+// ErrInvalidTenant 租户 ID 为空或包含 "/" 等
+var ErrInvalidTenant = errors.New("oss: invalid tenant id")
+
+var _ Storage = (*Tenant)(nil)
+
+// Tenant 租户视图, 对象名都加上租户前缀且不能访问前缀之外的对象,
+// 设置了 UsageStore 时在上传、复制与删除时更新用量并检查配额.
+// 预签名上传不经过配额检查, 可定期调用 Reconcile 核对用量.
+type Tenant struct {
+	id       string
+	storage  Storage
+	prefix   string
+	quota    Quota
+	usage    UsageStore
+	usageKey string
+}
+
+// TenantOption 租户选项
+type TenantOption func(*Tenant)
+
+// WithTenantPrefix 租户前缀, 默认 tenants/{id}/
+func WithTenantPrefix(prefix string) TenantOption {
+	return func(t *Tenant) {
+		t.prefix = prefix
+	}
+}
+
+// WithQuota 租户配额, 需同时设置 WithUsageStore
+func WithQuota(q Quota) TenantOption {
+	return func(t *Tenant) {
+		t.quota = q
+	}
+}
+
+// WithUsageStore 用量计数的存储
+func WithUsageStore(s UsageStore) TenantOption {
+	return func(t *Tenant) {
+		t.usage = s
+	}
+}
+
+// NewTenant 创建租户视图, 用量以租户 ID 为 key 计数
+func NewTenant(storage Storage, id string, opts ...TenantOption) (*Tenant, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, "/\\") {
+		return nil, ErrInvalidTenant
+	}
+	t := &Tenant{
+		id:       id,
+		storage:  storage,
+		prefix:   "tenants/" + id + "/",
+		usageKey: id,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	prefix, err := cleanPrefix(t.prefix)
+	if err != nil || prefix == "" {
+		return nil, ErrInvalidTenant
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	t.prefix = prefix
+	return t, nil
+}
+
+// ID 租户 ID
+func (t *Tenant) ID() string {
+	return t.id
+}
+
+// Prefix 租户前缀
+func (t *Tenant) Prefix() string {
+	return t.prefix
+}
+
+// Quota 租户配额
+func (t *Tenant) Quota() Quota {
+	return t.quota
+}
+
+// key 对象名加上租户前缀
+func (t *Tenant) key(name string) (string, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+	return t.prefix + name, nil
+}
+
+func (t *Tenant) info(info ObjectInfo) ObjectInfo {
+	info.Key = strings.TrimPrefix(info.Key, t.prefix)
+	return info
+}
+
+// unscope 错误中的对象名去掉租户前缀
+func (t *Tenant) unscope(err error) error {
+	if e, ok := err.(*Error); ok && strings.HasPrefix(e.Key, t.prefix) {
+		c := *e
+		c.Key = strings.TrimPrefix(e.Key, t.prefix)
+		return &c
+	}
+	return err
+}
+
+// existing 已有对象的大小, 不存在时返回 false
+func (t *Tenant) existing(ctx context.Context, key string) (int64, bool, error) {
+	info, err := t.storage.Stat(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, t.unscope(err)
+	}
+	return info.Size, true, nil
+}
+
+// delta 写入 size 字节到 key 带来的用量变化, 覆盖时减去原大小
+func (t *Tenant) delta(ctx context.Context, key string, size int64) (Usage, error) {
+	old, ok, err := t.existing(ctx, key)
+	if err != nil {
+		return Usage{}, err
+	}
+	if ok {
+		return Usage{Bytes: size - old}, nil
+	}
+	return Usage{Bytes: size, Objects: 1}, nil
+}
+
+// release 归还用量, 不检查配额
+func (t *Tenant) release(ctx context.Context, u Usage) error {
+	_, err := t.usage.Add(ctx, t.usageKey, Usage{Bytes: -u.Bytes, Objects: -u.Objects}, Quota{})
+	return err
+}
+
+// Put 上传对象, size 已知时先预留配额, 未知时按剩余配额限制读取的字节数
+func (t *Tenant) Put(ctx context.Context, name string, r io.Reader, size int64, opts ...PutOption) (ObjectInfo, error) {
+	key, err := t.key(name)
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	if t.usage == nil {
+		info, err := t.storage.Put(ctx, key, r, size, opts...)
+		return t.info(info), t.unscope(err)
+	}
+
+	if size >= 0 {
+		delta, err := t.delta(ctx, key, size)
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		if _, err = t.usage.Add(ctx, t.usageKey, delta, t.quota); err != nil {
+			return ObjectInfo{}, wrapError("put", name, err)
+		}
+		info, err := t.storage.Put(ctx, key, r, size, opts...)
+		if err != nil {
+			return ObjectInfo{}, errors.Join(t.unscope(err), t.release(ctx, delta))
+		}
+		return t.info(info), nil
+	}
+
+	delta, err := t.delta(ctx, key, 0)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	used, err := t.usage.Get(ctx, t.usageKey)
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	if err = t.quota.exceeded(used, delta); err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	if t.quota.MaxBytes > 0 {
+		r = &quotaReader{r: r, n: max(t.quota.MaxBytes-used.Bytes-delta.Bytes, 0)}
+	}
+	info, err := t.storage.Put(ctx, key, r, -1, opts...)
+	if err != nil {
+		return ObjectInfo{}, t.unscope(err)
+	}
+	// 已经写入, 只记录用量不再检查配额
+	delta.Bytes += info.Size
+	if _, err = t.usage.Add(ctx, t.usageKey, delta, Quota{}); err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+	return t.info(info), nil
+}
+
+// quotaReader 读取超过 n 字节时返回 ErrQuotaExceeded, 使上传中止
+type quotaReader struct {
+	r io.Reader
+	n int64
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > q.n+1 {
+		p = p[:q.n+1]
+	}
+	n, err := q.r.Read(p)
+	q.n -= int64(n)
+	if q.n < 0 {
+		return n, ErrQuotaExceeded
+	}
+	return n, err
+}
+
+// Get 下载对象
+func (t *Tenant) Get(ctx context.Context, name string, opts ...GetOption) (io.ReadCloser, ObjectInfo, error) {
+	key, err := t.key(name)
+	if err != nil {
+		return nil, ObjectInfo{}, wrapError("get", name, err)
+	}
+	rc, info, err := t.storage.Get(ctx, key, opts...)
+	return rc, t.info(info), t.unscope(err)
+}
+
+// Stat 获取对象信息
+func (t *Tenant) Stat(ctx context.Context, name string) (ObjectInfo, error) {
+	key, err := t.key(name)
+	if err != nil {
+		return ObjectInfo{}, wrapError("stat", name, err)
+	}
+	info, err := t.storage.Stat(ctx, key)
+	return t.info(info), t.unscope(err)
+}
+
+// List 列出租户内 prefix 下的对象
+func (t *Tenant) List(ctx context.Context, prefix string, opts ...ListOption) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		p, err := cleanPrefix(prefix)
+		if err != nil {
+			yield(ObjectInfo{}, wrapError("list", prefix, err))
+			return
+		}
+		opts = append(opts[:len(opts):len(opts)], func(o *minIo.ListObjectsOptions) {
+			if o.StartAfter != "" {
+				o.StartAfter = t.prefix + strings.TrimLeft(o.StartAfter, "/")
+			}
+		})
+		for info, err := range t.storage.List(ctx, t.prefix+p, opts...) {
+			if !yield(t.info(info), t.unscope(err)) {
+				return
+			}
+		}
+	}
+}
+
+// ListPage 分页列举, 返回最多 limit 个对象以及下一页的 startAfter, 为空表示没有更多
+func (t *Tenant) ListPage(ctx context.Context, prefix, startAfter string, limit int, opts ...ListOption) ([]ObjectInfo, string, error) {
+	return collectPage(t.List(ctx, prefix, append(opts, WithStartAfter(startAfter))...), limit)
+}
+
+// Copy 在租户内复制对象
+func (t *Tenant) Copy(ctx context.Context, src, dst string, opts ...PutOption) (ObjectInfo, error) {
+	srcKey, err := t.key(src)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", src, err)
+	}
+	dstKey, err := t.key(dst)
+	if err != nil {
+		return ObjectInfo{}, wrapError("copy", dst, err)
+	}
+	if t.usage == nil {
+		info, err := t.storage.Copy(ctx, srcKey, dstKey, opts...)
+		return t.info(info), t.unscope(err)
+	}
+
+	srcInfo, err := t.storage.Stat(ctx, srcKey)
+	if err != nil {
+		return ObjectInfo{}, t.unscope(err)
+	}
+	delta, err := t.delta(ctx, dstKey, srcInfo.Size)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if _, err = t.usage.Add(ctx, t.usageKey, delta, t.quota); err != nil {
+		return ObjectInfo{}, wrapError("copy", dst, err)
+	}
+	info, err := t.storage.Copy(ctx, srcKey, dstKey, opts...)
+	if err != nil {
+		return ObjectInfo{}, errors.Join(t.unscope(err), t.release(ctx, delta))
+	}
+	return t.info(info), nil
+}
+
+// Delete 删除对象, 对象不存在时不返回错误
+func (t *Tenant) Delete(ctx context.Context, name string) error {
+	key, err := t.key(name)
+	if err != nil {
+		return wrapError("delete", name, err)
+	}
+	if t.usage == nil {
+		return t.unscope(t.storage.Delete(ctx, key))
+	}
+	size, ok, err := t.existing(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err = t.storage.Delete(ctx, key); err != nil || !ok {
+		return t.unscope(err)
+	}
+	return t.release(ctx, Usage{Bytes: size, Objects: 1})
+}
+
+// DeleteMany 批量删除, 部分失败时只归还已删除对象的用量
+func (t *Tenant) DeleteMany(ctx context.Context, names []string) error {
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		key, err := t.key(name)
+		if err != nil {
+			return wrapError("delete", name, err)
+		}
+		keys = append(keys, key)
+	}
+	if t.usage == nil {
+		return t.storage.DeleteMany(ctx, keys)
+	}
+
+	sizes := make(map[string]int64, len(keys))
+	for _, key := range keys {
+		size, ok, err := t.existing(ctx, key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			sizes[key] = size
+		}
+	}
+	deleteErr := t.storage.DeleteMany(ctx, keys)
+	var freed Usage
+	for key, size := range sizes {
+		if deleteErr != nil {
+			if _, ok, err := t.existing(ctx, key); err != nil || ok {
+				continue
+			}
+		}
+		freed.Bytes += size
+		freed.Objects++
+	}
+	if freed == (Usage{}) {
+		return deleteErr
+	}
+	return errors.Join(deleteErr, t.release(ctx, freed))
+}
+
+// PresignedGet 返回有效期为 expires 的下载链接
+func (t *Tenant) PresignedGet(ctx context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error) {
+	key, err := t.key(name)
+	if err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	u, err := t.storage.PresignedGet(ctx, key, expires, opts...)
+	return u, t.unscope(err)
+}
+
+// PresignedHead 返回有效期为 expires 的 HEAD 链接
+func (t *Tenant) PresignedHead(ctx context.Context, name string, expires time.Duration, opts ...PresignOption) (*url.URL, error) {
+	key, err := t.key(name)
+	if err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	u, err := t.storage.PresignedHead(ctx, key, expires, opts...)
+	return u, t.unscope(err)
+}
+
+// PresignedPut 返回有效期为 expires 的上传链接, 不经过配额检查
+func (t *Tenant) PresignedPut(ctx context.Context, name string, expires time.Duration) (*url.URL, error) {
+	key, err := t.key(name)
+	if err != nil {
+		return nil, wrapError("presign", name, err)
+	}
+	u, err := t.storage.PresignedPut(ctx, key, expires)
+	return u, t.unscope(err)
+}
+
+// Usage 当前用量, 没有设置 UsageStore 时返回 ErrNotSupported
+func (t *Tenant) Usage(ctx context.Context) (Usage, error) {
+	if t.usage == nil {
+		return Usage{}, wrapError("usage", t.id, ErrNotSupported)
+	}
+	u, err := t.usage.Get(ctx, t.usageKey)
+	return u, wrapError("usage", t.id, err)
+}
+
+// Reconcile 列举租户的全部对象重新统计用量并保存
+func (t *Tenant) Reconcile(ctx context.Context) (Usage, error) {
+	if t.usage == nil {
+		return Usage{}, wrapError("usage", t.id, ErrNotSupported)
+	}
+	var u Usage
+	for info, err := range t.storage.List(ctx, t.prefix) {
+		if err != nil {
+			return Usage{}, t.unscope(err)
+		}
+		u.Bytes += info.Size
+		u.Objects++
+	}
+	return u, wrapError("usage", t.id, t.usage.Set(ctx, t.usageKey, u))
+}
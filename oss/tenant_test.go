@@ -0,0 +1,205 @@
+package oss_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/oss"
+	"github.com/zmicro-team/ztlib/oss/internal/fakes3"
+	"github.com/zmicro-team/ztlib/oss/tests"
+)
+
+func TestTenant_Storage(t *testing.T) {
+	srv, handler := newPresignServer(t)
+	store := oss.NewMemoryStorage(oss.WithPresign(srv.URL, []byte("secret")))
+	*handler = store.Handler()
+
+	usage := oss.NewMemoryUsageStore()
+	tenant, err := oss.NewTenant(store, "acme", oss.WithUsageStore(usage))
+	require.NoError(t, err)
+	tests.TestStorage(t, tenant)
+
+	// 预签名上传不计入用量, 由 Reconcile 重新统计
+	reconciled, err := tenant.Reconcile(context.Background())
+	require.NoError(t, err)
+	u, err := tenant.Usage(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, reconciled, u)
+}
+
+func TestTenant_Isolation(t *testing.T) {
+	ctx := context.Background()
+	store := oss.NewMemoryStorage()
+	acme, err := oss.NewTenant(store, "acme")
+	require.NoError(t, err)
+	other, err := oss.NewTenant(store, "other")
+	require.NoError(t, err)
+
+	info, err := acme.Put(ctx, "docs/a.txt", strings.NewReader("a"), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "docs/a.txt", info.Key)
+	_, err = other.Put(ctx, "docs/b.txt", strings.NewReader("b"), 1)
+	require.NoError(t, err)
+	_, err = store.Stat(ctx, "tenants/acme/docs/a.txt")
+	require.NoError(t, err)
+
+	for _, name := range []string{"../other/docs/b.txt", "/../other/docs/b.txt", "docs/../../other/docs/b.txt", "./a", ""} {
+		_, err = acme.Stat(ctx, name)
+		assert.ErrorIs(t, err, oss.ErrInvalidKey, name)
+		_, err = acme.Put(ctx, name, strings.NewReader("x"), 1)
+		assert.ErrorIs(t, err, oss.ErrInvalidKey, name)
+	}
+	_, err = acme.Copy(ctx, "../other/docs/b.txt", "b.txt")
+	assert.ErrorIs(t, err, oss.ErrInvalidKey)
+	for _, err = range acme.List(ctx, "../") {
+		assert.ErrorIs(t, err, oss.ErrInvalidKey)
+	}
+
+	var keys []string
+	for info, err := range acme.List(ctx, "") {
+		require.NoError(t, err)
+		keys = append(keys, info.Key)
+	}
+	assert.Equal(t, []string{"docs/a.txt"}, keys)
+
+	// 错误中的对象名不含租户前缀
+	_, err = acme.Stat(ctx, "none")
+	require.ErrorIs(t, err, oss.ErrNotFound)
+	assert.Equal(t, "oss: stat none: oss: object not found", err.Error())
+
+	for _, id := range []string{"", ".", "..", "a/b", `a\b`} {
+		_, err = oss.NewTenant(store, id)
+		assert.ErrorIs(t, err, oss.ErrInvalidTenant, id)
+	}
+	custom, err := oss.NewTenant(store, "acme", oss.WithTenantPrefix("/custom/acme"))
+	require.NoError(t, err)
+	assert.Equal(t, "custom/acme/", custom.Prefix())
+}
+
+func TestTenant_Quota(t *testing.T) {
+	ctx := context.Background()
+	store := oss.NewMemoryStorage()
+	usage := oss.NewMemoryUsageStore()
+	tenant, err := oss.NewTenant(store, "acme", oss.WithUsageStore(usage), oss.WithQuota(oss.Quota{MaxBytes: 10, MaxObjects: 3}))
+	require.NoError(t, err)
+	assertUsage := func(want oss.Usage) {
+		t.Helper()
+		u, err := tenant.Usage(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, u)
+	}
+
+	_, err = tenant.Put(ctx, "a", strings.NewReader("123456"), 6)
+	require.NoError(t, err)
+	assertUsage(oss.Usage{Bytes: 6, Objects: 1})
+
+	// 超出配额时不写入
+	_, err = tenant.Put(ctx, "b", strings.NewReader("12345"), 5)
+	assert.ErrorIs(t, err, oss.ErrQuotaExceeded)
+	_, err = tenant.Put(ctx, "b", strings.NewReader("12345"), -1)
+	assert.ErrorIs(t, err, oss.ErrQuotaExceeded)
+	_, err = tenant.Stat(ctx, "b")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+	assertUsage(oss.Usage{Bytes: 6, Objects: 1})
+
+	// 覆盖时按差值计算
+	_, err = tenant.Put(ctx, "a", strings.NewReader("1234567890"), 10)
+	require.NoError(t, err)
+	assertUsage(oss.Usage{Bytes: 10, Objects: 1})
+	_, err = tenant.Put(ctx, "a", strings.NewReader("12"), -1)
+	require.NoError(t, err)
+	assertUsage(oss.Usage{Bytes: 2, Objects: 1})
+
+	_, err = tenant.Put(ctx, "b", strings.NewReader("123"), -1)
+	require.NoError(t, err)
+	_, err = tenant.Copy(ctx, "b", "c")
+	require.NoError(t, err)
+	assertUsage(oss.Usage{Bytes: 8, Objects: 3})
+	_, err = tenant.Copy(ctx, "b", "d")
+	assert.ErrorIs(t, err, oss.ErrQuotaExceeded)
+	_, err = tenant.Put(ctx, "d", strings.NewReader(""), 0)
+	assert.ErrorIs(t, err, oss.ErrQuotaExceeded)
+
+	require.NoError(t, tenant.Delete(ctx, "a"))
+	require.NoError(t, tenant.Delete(ctx, "a"))
+	assertUsage(oss.Usage{Bytes: 6, Objects: 2})
+	require.NoError(t, tenant.DeleteMany(ctx, []string{"b", "c", "none"}))
+	assertUsage(oss.Usage{})
+
+	// 绕过租户直接写入的对象由 Reconcile 统计
+	_, err = store.Put(ctx, "tenants/acme/direct", bytes.NewReader(make([]byte, 7)), 7)
+	require.NoError(t, err)
+	u, err := tenant.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{Bytes: 7, Objects: 1}, u)
+	assertUsage(u)
+
+	noUsage, err := oss.NewTenant(store, "acme")
+	require.NoError(t, err)
+	_, err = noUsage.Usage(ctx)
+	assert.ErrorIs(t, err, oss.ErrNotSupported)
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	srv := fakes3.New("photos", "docs")
+	defer srv.Close()
+	config := func(bucket string) oss.OssUtilConfig {
+		return oss.OssUtilConfig{
+			EndPoint:        srv.Endpoint(),
+			Region:          "us-east-1",
+			AccessKeyID:     "access",
+			SecretAccessKey: "secret",
+			BucketName:      bucket,
+		}
+	}
+	usage := oss.NewMemoryUsageStore()
+	registry := oss.NewRegistry(map[string]oss.OssUtilConfig{
+		"photos": config("photos"),
+		"docs":   config("docs"),
+	}, oss.WithUsageStore(usage), oss.WithQuota(oss.Quota{MaxBytes: 4}))
+	require.NoError(t, registry.Register("local", oss.NewMemoryStorage()))
+	assert.ErrorIs(t, registry.Register("local", oss.NewMemoryStorage()), oss.ErrDuplicateBucket)
+	assert.Equal(t, []string{"docs", "local", "photos"}, registry.Names())
+
+	_, err := registry.Tenant("none", "acme")
+	assert.ErrorIs(t, err, oss.ErrBucketNotFound)
+	_, err = registry.Tenant("photos", "../acme")
+	assert.ErrorIs(t, err, oss.ErrInvalidTenant)
+
+	photos, err := registry.Tenant("photos", "acme")
+	require.NoError(t, err)
+	_, err = photos.Put(ctx, "a.jpg", strings.NewReader("abc"), 3)
+	require.NoError(t, err)
+	obj, ok := srv.Object("photos", "tenants/acme/a.jpg")
+	require.True(t, ok)
+	assert.Equal(t, "abc", string(obj.Data))
+
+	// 未知大小时 S3 上传也会在超出配额时中止
+	_, err = photos.Put(ctx, "b.jpg", io.MultiReader(strings.NewReader("ab")), -1)
+	assert.ErrorIs(t, err, oss.ErrQuotaExceeded)
+	_, ok = srv.Object("photos", "tenants/acme/b.jpg")
+	assert.False(t, ok)
+
+	// 各桶用量独立计数, 选项可覆盖默认配额
+	docs, err := registry.Tenant("docs", "acme", oss.WithQuota(oss.Quota{}))
+	require.NoError(t, err)
+	_, err = docs.Put(ctx, "big.txt", strings.NewReader("0123456789"), 10)
+	require.NoError(t, err)
+	u, err := usage.Get(ctx, "photos/acme")
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{Bytes: 3, Objects: 1}, u)
+	u, err = usage.Get(ctx, "docs/acme")
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{Bytes: 10, Objects: 1}, u)
+}
+
+func TestMemoryUsageStore(t *testing.T) {
+	tests.TestUsageStore(t, oss.NewMemoryUsageStore())
+}
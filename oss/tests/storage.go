@@ -0,0 +1,281 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/oss"
+)
+
+// TestStorage 对象存储的通用测试, store 需为空; 预签名链接返回 ErrNotSupported 时跳过相关测试
+func TestStorage(t *testing.T, store oss.Storage) {
+	t.Run("object", func(t *testing.T) { testObject(t, store) })
+	t.Run("list", func(t *testing.T) { testList(t, store) })
+	t.Run("presign", func(t *testing.T) { testPresign(t, store) })
+}
+
+func get(t *testing.T, store oss.Storage, name string, opts ...oss.GetOption) (string, oss.ObjectInfo) {
+	rc, info, err := store.Get(context.Background(), name, opts...)
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return string(data), info
+}
+
+func testObject(t *testing.T, store oss.Storage) {
+	ctx := context.Background()
+
+	info, err := store.Put(ctx, "obj/a.json", strings.NewReader(`{"a":1}`), 7,
+		oss.WithMetadata(map[string]string{"owner": "bob"}))
+	require.NoError(t, err)
+	assert.Equal(t, "obj/a.json", info.Key)
+	assert.NotEmpty(t, info.ETag)
+
+	info, err = store.Stat(ctx, "obj/a.json")
+	require.NoError(t, err)
+	assert.Equal(t, "obj/a.json", info.Key)
+	assert.Equal(t, int64(7), info.Size)
+	assert.Equal(t, "application/json", info.ContentType)
+	assert.Equal(t, "bob", info.Metadata["Owner"])
+	assert.WithinDuration(t, time.Now(), info.LastModified, time.Minute)
+	etag := info.ETag
+
+	// 未知大小, 按内容检测类型
+	_, err = store.Put(ctx, "obj/page", strings.NewReader("<html><body>hello</body></html>"), -1)
+	require.NoError(t, err)
+	data, info := get(t, store, "obj/page")
+	assert.Equal(t, "<html><body>hello</body></html>", data)
+	assert.Equal(t, "text/html; charset=utf-8", info.ContentType)
+
+	data, _ = get(t, store, "obj/page", oss.WithRange(6, 11))
+	assert.Equal(t, "<body>", data)
+	data, _ = get(t, store, "obj/page", oss.WithRange(12, -1))
+	assert.Equal(t, "hello</body></html>", data)
+	data, _ = get(t, store, "obj/a.json", oss.WithMatchETag(etag))
+	assert.Equal(t, `{"a":1}`, data)
+	_, _, err = store.Get(ctx, "obj/a.json", oss.WithMatchETag("0123"))
+	assert.ErrorIs(t, err, oss.ErrPreconditionFailed)
+
+	// 覆盖
+	_, err = store.Put(ctx, "obj/page", strings.NewReader("bye"), 3, oss.WithContentType("text/plain"))
+	require.NoError(t, err)
+	data, info = get(t, store, "obj/page")
+	assert.Equal(t, "bye", data)
+	assert.Equal(t, "text/plain", info.ContentType)
+
+	// 复制保留元数据, 指定选项时替换
+	_, err = store.Copy(ctx, "obj/a.json", "obj/b.json")
+	require.NoError(t, err)
+	data, info = get(t, store, "obj/b.json")
+	assert.Equal(t, `{"a":1}`, data)
+	assert.Equal(t, "application/json", info.ContentType)
+	assert.Equal(t, "bob", info.Metadata["Owner"])
+	_, err = store.Copy(ctx, "obj/a.json", "obj/c.txt",
+		oss.WithContentType("text/plain"), oss.WithMetadata(map[string]string{"owner": "alice"}))
+	require.NoError(t, err)
+	info, err = store.Stat(ctx, "obj/c.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", info.ContentType)
+	assert.Equal(t, "alice", info.Metadata["Owner"])
+	_, err = store.Copy(ctx, "obj/missing", "obj/d")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+
+	// 不存在
+	_, err = store.Stat(ctx, "obj/missing")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+	_, _, err = store.Get(ctx, "obj/missing")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+	_, err = store.Stat(ctx, "obj")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+
+	// 非法名称
+	for _, name := range []string{"", "../a", "obj/../../a", `a\..\..\outside`, `..\outside`, `obj\a`, "C:/Windows/a", "c:outside", "a\x00b"} {
+		_, err = store.Put(ctx, name, strings.NewReader("x"), 1)
+		assert.ErrorIs(t, err, oss.ErrInvalidKey, name)
+		_, err = store.Stat(ctx, name)
+		assert.ErrorIs(t, err, oss.ErrInvalidKey, name)
+	}
+
+	// 删除
+	require.NoError(t, store.Delete(ctx, "obj/a.json"))
+	require.NoError(t, store.Delete(ctx, "obj/a.json"))
+	_, err = store.Stat(ctx, "obj/a.json")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+	require.NoError(t, store.DeleteMany(ctx, []string{"obj/b.json", "obj/c.txt", "obj/page", "obj/missing"}))
+	for info, err := range store.List(ctx, "obj/") {
+		require.NoError(t, err)
+		t.Errorf("unexpected object %s", info.Key)
+	}
+}
+
+func testList(t *testing.T, store oss.Storage) {
+	ctx := context.Background()
+	names := []string{"list/a", "list/b/1", "list/b/2", "list/c", "list/d/e/3", "list0"}
+	for _, name := range names {
+		_, err := store.Put(ctx, name, strings.NewReader(name), int64(len(name)))
+		require.NoError(t, err)
+	}
+	keys := func(prefix string, opts ...oss.ListOption) []string {
+		var keys []string
+		for info, err := range store.List(ctx, prefix, opts...) {
+			require.NoError(t, err)
+			keys = append(keys, info.Key)
+		}
+		return keys
+	}
+
+	assert.Equal(t, names, keys("list"))
+	assert.Equal(t, names[:5], keys("list/"))
+	assert.Equal(t, []string{"list/b/1", "list/b/2"}, keys("list/b"))
+	assert.Equal(t, []string{"list/a", "list/b/", "list/c", "list/d/"}, keys("list/", oss.WithNonRecursive()))
+	assert.Equal(t, []string{"list/c", "list/d/e/3"}, keys("list/", oss.WithStartAfter("list/b/2")))
+	assert.Empty(t, keys("none/"))
+
+	for info, err := range store.List(ctx, "list/", oss.WithNonRecursive()) {
+		require.NoError(t, err)
+		assert.Equal(t, strings.HasSuffix(info.Key, "/"), info.IsDir)
+		if !info.IsDir {
+			assert.Equal(t, int64(len(info.Key)), info.Size)
+		}
+	}
+
+	// 中途停止
+	n := 0
+	for range store.List(ctx, "list/") {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	assert.Equal(t, 2, n)
+
+	var got []string
+	after := ""
+	for {
+		page, next, err := store.ListPage(ctx, "list/", after, 2)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page), 2)
+		for _, info := range page {
+			got = append(got, info.Key)
+		}
+		if next == "" {
+			break
+		}
+		after = next
+	}
+	assert.Equal(t, names[:5], got)
+
+	got = got[:0]
+	after = ""
+	for {
+		page, next, err := store.ListPage(ctx, "list/", after, 1, oss.WithNonRecursive())
+		require.NoError(t, err)
+		for _, info := range page {
+			got = append(got, info.Key)
+		}
+		if next == "" {
+			break
+		}
+		after = next
+	}
+	assert.Equal(t, []string{"list/a", "list/b/", "list/c", "list/d/"}, got)
+
+	for _, limit := range []int{0, -1} {
+		_, _, err := store.ListPage(ctx, "list/", "", limit)
+		assert.ErrorIs(t, err, oss.ErrInvalidLimit, limit)
+	}
+
+	for _, prefix := range []string{"../", `..\`, `a\..\..\`, "C:/"} {
+		_, _, err := store.ListPage(ctx, prefix, "", 1)
+		assert.ErrorIs(t, err, oss.ErrInvalidKey, prefix)
+	}
+	require.NoError(t, store.DeleteMany(ctx, names))
+}
+
+func testPresign(t *testing.T, store oss.Storage) {
+	ctx := context.Background()
+
+	u, err := store.PresignedPut(ctx, "presign/a.txt", time.Minute)
+	if errors.Is(err, oss.ErrNotSupported) {
+		t.Skip("presign not supported")
+	}
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader([]byte("hello")))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, info := get(t, store, "presign/a.txt")
+	assert.Equal(t, "hello", data)
+	assert.Equal(t, "text/plain", info.ContentType)
+
+	u, err = store.PresignedGet(ctx, "presign/a.txt", time.Minute)
+	require.NoError(t, err)
+	resp, err = http.Get(u.String())
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+
+	// 覆盖响应头, 强制下载中文文件名
+	u, err = store.PresignedGet(ctx, "presign/a.txt", time.Minute,
+		oss.WithAttachment("报告.txt"), oss.WithResponseContentType("application/octet-stream"))
+	require.NoError(t, err)
+	resp, err = http.Get(u.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `attachment; filename="__.txt"; filename*=UTF-8''%E6%8A%A5%E5%91%8A.txt`, resp.Header.Get("Content-Disposition"))
+	assert.Equal(t, "application/octet-stream", resp.Header.Get("Content-Type"))
+
+	u, err = store.PresignedHead(ctx, "presign/a.txt", time.Minute)
+	require.NoError(t, err)
+	resp, err = http.Head(u.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(5), resp.ContentLength)
+
+	// 对象名中的特殊字符需转义
+	for _, name := range []string{"presign/100%.pdf", "presign/a#b.txt", "presign/a?b=1.txt", "presign/a b+c.txt"} {
+		u, err = store.PresignedPut(ctx, name, time.Minute)
+		require.NoError(t, err, name)
+		req, err = http.NewRequest(http.MethodPut, u.String(), strings.NewReader(name))
+		require.NoError(t, err, name)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err, name)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, name)
+		data, _ = get(t, store, name)
+		assert.Equal(t, name, data)
+
+		u, err = store.PresignedGet(ctx, name, time.Minute)
+		require.NoError(t, err, name)
+		resp, err = http.Get(u.String())
+		require.NoError(t, err, name)
+		body, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, name)
+		assert.Equal(t, name, string(body))
+		require.NoError(t, store.Delete(ctx, name))
+	}
+
+	_, err = store.PresignedGet(ctx, "../a", time.Minute)
+	assert.ErrorIs(t, err, oss.ErrInvalidKey)
+	require.NoError(t, store.Delete(ctx, "presign/a.txt"))
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/oss"
+)
+
+// TestUsageStore 用量计数的通用测试, store 需为空
+func TestUsageStore(t *testing.T, store oss.UsageStore) {
+	ctx := context.Background()
+	quota := oss.Quota{MaxBytes: 100, MaxObjects: 2}
+
+	u, err := store.Get(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{}, u)
+
+	u, err = store.Add(ctx, "t1", oss.Usage{Bytes: 60, Objects: 1}, quota)
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{Bytes: 60, Objects: 1}, u)
+
+	// 超出字节数配额时不修改
+	_, err = store.Add(ctx, "t1", oss.Usage{Bytes: 41, Objects: 1}, quota)
+	assert.ErrorIs(t, err, oss.ErrQuotaExceeded)
+	u, err = store.Add(ctx, "t1", oss.Usage{Bytes: 40, Objects: 1}, quota)
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{Bytes: 100, Objects: 2}, u)
+
+	// 超出对象数配额
+	_, err = store.Add(ctx, "t1", oss.Usage{Objects: 1}, quota)
+	assert.ErrorIs(t, err, oss.ErrQuotaExceeded)
+
+	// 只检查增加的项, 减少用量不受配额限制
+	u, err = store.Add(ctx, "t1", oss.Usage{Bytes: -50}, quota)
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{Bytes: 50, Objects: 2}, u)
+	u, err = store.Add(ctx, "t1", oss.Usage{Bytes: 10}, oss.Quota{MaxBytes: 100})
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{Bytes: 60, Objects: 2}, u)
+	u, err = store.Add(ctx, "t1", oss.Usage{Bytes: 500, Objects: 5}, oss.Quota{})
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{Bytes: 560, Objects: 7}, u)
+
+	// 不同 key 独立计数
+	u, err = store.Get(ctx, "t2")
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{}, u)
+
+	require.NoError(t, store.Set(ctx, "t1", oss.Usage{Bytes: 1, Objects: 1}))
+	u, err = store.Get(ctx, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, oss.Usage{Bytes: 1, Objects: 1}, u)
+
+	// 并发增加时不能超出配额
+	var (
+		wg sync.WaitGroup
+		ok atomic.Int64
+	)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Add(ctx, "t3", oss.Usage{Bytes: 3}, oss.Quota{MaxBytes: 60}); err == nil {
+				ok.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(20), ok.Load())
+	u, err = store.Get(ctx, "t3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), u.Bytes)
+}
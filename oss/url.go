@@ -0,0 +1,127 @@
+package oss
+
+import (
+	"net/url"
+	"strings"
+)
+
+// 预签名下载链接支持的响应头覆盖参数与对应的响应头
+var responseOverrides = map[string]string{
+	"response-content-type":        "Content-Type",
+	"response-content-disposition": "Content-Disposition",
+	"response-content-language":    "Content-Language",
+	"response-content-encoding":    "Content-Encoding",
+	"response-cache-control":       "Cache-Control",
+	"response-expires":             "Expires",
+}
+
+// PresignOption 预签名下载链接选项, 用于覆盖下载时的响应头
+type PresignOption func(url.Values)
+
+// WithResponseContentType 覆盖响应的 Content-Type
+func WithResponseContentType(contentType string) PresignOption {
+	return func(v url.Values) {
+		v.Set("response-content-type", contentType)
+	}
+}
+
+// WithResponseContentDisposition 覆盖响应的 Content-Disposition
+func WithResponseContentDisposition(disposition string) PresignOption {
+	return func(v url.Values) {
+		v.Set("response-content-disposition", disposition)
+	}
+}
+
+// WithResponseCacheControl 覆盖响应的 Cache-Control
+func WithResponseCacheControl(cacheControl string) PresignOption {
+	return func(v url.Values) {
+		v.Set("response-cache-control", cacheControl)
+	}
+}
+
+// WithAttachment 强制浏览器下载并保存为 filename, 支持中文文件名
+func WithAttachment(filename string) PresignOption {
+	return WithResponseContentDisposition(ContentDisposition("attachment", filename))
+}
+
+func presignParams(opts []PresignOption) url.Values {
+	if len(opts) == 0 {
+		return nil
+	}
+	v := make(url.Values)
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// ContentDisposition 生成 Content-Disposition, 同时带 ASCII 的 filename 与 RFC 5987 的 filename*,
+// 兼容不支持 filename* 的客户端.
+func ContentDisposition(dispositionType, filename string) string {
+	if filename == "" {
+		return dispositionType
+	}
+	ascii := true
+	fallback := make([]byte, 0, len(filename))
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			fallback = append(fallback, '\\', byte(r))
+		case r < 0x20 || r == 0x7f:
+			fallback = append(fallback, '_')
+		case r > 0x7f:
+			ascii = false
+			fallback = append(fallback, '_')
+		default:
+			fallback = append(fallback, byte(r))
+		}
+	}
+	s := dispositionType + `; filename="` + string(fallback) + `"`
+	if !ascii {
+		s += "; filename*=UTF-8''" + encodeExtValue(filename)
+	}
+	return s
+}
+
+// encodeExtValue RFC 5987 百分号编码, 只保留 attr-char
+func encodeExtValue(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0xf])
+	}
+	return b.String()
+}
+
+// escapeKey 按路径段转义对象名
+func escapeKey(key string) string {
+	segs := strings.Split(key, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
+
+// PublicURL 公共读桶的访问地址, 未配置 PublicBaseURL 时使用 EndPoint/BucketName
+func (o *OssUtil) PublicURL(name string) (string, error) {
+	key, err := o.Key(name)
+	if err != nil {
+		return "", wrapError("url", name, err)
+	}
+	base := strings.TrimRight(o.Config.PublicBaseURL, "/")
+	if base == "" {
+		scheme := "http"
+		if o.Config.UseSSL {
+			scheme = "https"
+		}
+		base = scheme + "://" + o.Config.EndPoint + "/" + o.Config.BucketName
+	}
+	return base + "/" + escapeKey(key), nil
+}
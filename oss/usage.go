@@ -0,0 +1,81 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrQuotaExceeded 超出租户配额
+var ErrQuotaExceeded = errors.New("oss: quota exceeded")
+
+// Usage 存储用量
+type Usage struct {
+	Bytes   int64 `json:"bytes" yaml:"bytes"`
+	Objects int64 `json:"objects" yaml:"objects"`
+}
+
+// Quota 配额, 为 0 的项不限制
+type Quota struct {
+	MaxBytes   int64 `json:"max_bytes" yaml:"maxBytes"`
+	MaxObjects int64 `json:"max_objects" yaml:"maxObjects"`
+}
+
+// exceeded 用量 u 增加 delta 之后是否超出配额, 只检查增加的项
+func (q Quota) exceeded(u, delta Usage) error {
+	if delta.Bytes > 0 && q.MaxBytes > 0 && u.Bytes+delta.Bytes > q.MaxBytes {
+		return fmt.Errorf("%w: %d + %d bytes exceeds %d", ErrQuotaExceeded, u.Bytes, delta.Bytes, q.MaxBytes)
+	}
+	if delta.Objects > 0 && q.MaxObjects > 0 && u.Objects+delta.Objects > q.MaxObjects {
+		return fmt.Errorf("%w: %d + %d objects exceeds %d", ErrQuotaExceeded, u.Objects, delta.Objects, q.MaxObjects)
+	}
+	return nil
+}
+
+// UsageStore 用量计数的存储, 如 oss/redis/v8, oss/redis/v9; Add 需为原子操作
+type UsageStore interface {
+	// Add 增加用量并返回增加后的用量, 增加的项超出 quota 时不修改并返回 ErrQuotaExceeded
+	Add(ctx context.Context, key string, delta Usage, quota Quota) (Usage, error)
+	// Get 获取用量, 不存在时返回 0
+	Get(ctx context.Context, key string) (Usage, error)
+	// Set 覆盖用量, 用于与实际对象重新核对
+	Set(ctx context.Context, key string, usage Usage) error
+}
+
+// MemoryUsageStore 进程内的用量计数, 用于单机或测试
+type MemoryUsageStore struct {
+	mu    sync.Mutex
+	usage map[string]Usage
+}
+
+// NewMemoryUsageStore 创建进程内的用量计数
+func NewMemoryUsageStore() *MemoryUsageStore {
+	return &MemoryUsageStore{usage: make(map[string]Usage)}
+}
+
+func (m *MemoryUsageStore) Add(_ context.Context, key string, delta Usage, quota Quota) (Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u := m.usage[key]
+	if err := quota.exceeded(u, delta); err != nil {
+		return u, err
+	}
+	u.Bytes += delta.Bytes
+	u.Objects += delta.Objects
+	m.usage[key] = u
+	return u, nil
+}
+
+func (m *MemoryUsageStore) Get(_ context.Context, key string) (Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage[key], nil
+}
+
+func (m *MemoryUsageStore) Set(_ context.Context, key string, usage Usage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage[key] = usage
+	return nil
+}
@@ -0,0 +1,311 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+)
+
+var (
+	ErrTypeNotAllowed   = errors.New("oss: content type not allowed")
+	ErrTooLarge         = errors.New("oss: object too large")
+	ErrChecksumMismatch = errors.New("oss: checksum mismatch")
+)
+
+// 校验通过后保存在元数据中的校验和 (hex), 客户端也可以通过 WithChecksums 或表单字段
+// x-amz-meta-content-md5, x-amz-meta-content-sha256 提供
+const (
+	MetaMD5    = "Content-Md5"
+	MetaSHA256 = "Content-Sha256"
+)
+
+// Checksums 客户端提供的校验和, hex 或 base64 编码, 为空时不比较
+type Checksums struct {
+	MD5    string
+	SHA256 string
+}
+
+// WithChecksums 上传时提供客户端计算的校验和, 由 ValidatedStorage 比较
+func WithChecksums(c Checksums) PutOption {
+	m := make(map[string]string, 2)
+	if c.MD5 != "" {
+		m[MetaMD5] = c.MD5
+	}
+	if c.SHA256 != "" {
+		m[MetaSHA256] = c.SHA256
+	}
+	return WithMetadata(m)
+}
+
+// Report 校验结果
+type Report struct {
+	// ContentType 按文件头识别的真实类型
+	ContentType string
+	Size        int64
+	// MD5, SHA256 hex 编码
+	MD5    string
+	SHA256 string
+}
+
+// Validator 上传内容校验: 真实类型白名单, 大小上限, 校验和, 压缩包解压限制与扫描器
+type Validator struct {
+	allowed  []string
+	maxSize  int64
+	archive  ArchiveLimits
+	scanner  Scanner
+	memLimit int64
+}
+
+// ValidatorOption 校验选项
+type ValidatorOption func(*Validator)
+
+// WithAllowedTypes 允许的真实类型, 支持 image/* 形式, 默认不限制
+func WithAllowedTypes(types ...string) ValidatorOption {
+	return func(v *Validator) {
+		v.allowed = types
+	}
+}
+
+// WithMaxSize 对象大小上限, 默认不限制
+func WithMaxSize(n int64) ValidatorOption {
+	return func(v *Validator) {
+		v.maxSize = n
+	}
+}
+
+// WithArchiveLimits 压缩包的解压限制, 默认 DefaultArchiveLimits
+func WithArchiveLimits(limits ArchiveLimits) ValidatorOption {
+	return func(v *Validator) {
+		v.archive = limits
+	}
+}
+
+// WithScanner 扫描器, 如 ClamdScanner, 在其他校验通过后调用
+func WithScanner(s Scanner) ValidatorOption {
+	return func(v *Validator) {
+		v.scanner = s
+	}
+}
+
+// WithSpoolMemory 校验时缓存在内存中的最大字节数, 超过后写入临时文件, 默认 8MB
+func WithSpoolMemory(n int64) ValidatorOption {
+	return func(v *Validator) {
+		v.memLimit = n
+	}
+}
+
+// NewValidator 创建校验
+func NewValidator(opts ...ValidatorOption) *Validator {
+	v := &Validator{
+		archive:  DefaultArchiveLimits,
+		memLimit: 8 << 20,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Validate 校验 r 的内容, size 未知时传 -1
+func (v *Validator) Validate(ctx context.Context, name string, r io.Reader, size int64, sums Checksums) (Report, error) {
+	sp, report, err := v.check(ctx, r, size, sums)
+	if sp != nil {
+		_ = sp.Close()
+	}
+	return report, wrapError("validate", name, err)
+}
+
+// Verify 上传后校验存储中的对象, sums 为空时使用元数据中客户端提供的校验和
+func (v *Validator) Verify(ctx context.Context, store Storage, name string, sums Checksums) (Report, error) {
+	report, err := v.verify(ctx, store, name, sums)
+	return report, wrapError("validate", name, err)
+}
+
+func (v *Validator) verify(ctx context.Context, store Storage, name string, sums Checksums) (Report, error) {
+	info, err := store.Stat(ctx, name)
+	if err != nil {
+		return Report{}, err
+	}
+	// 先按大小拒绝, 避免下载过大的对象
+	if v.maxSize > 0 && info.Size > v.maxSize {
+		return Report{}, fmt.Errorf("%w: %d bytes exceeds %d", ErrTooLarge, info.Size, v.maxSize)
+	}
+	if sums.MD5 == "" && sums.SHA256 == "" {
+		sums = Checksums{MD5: info.Metadata[MetaMD5], SHA256: info.Metadata[MetaSHA256]}
+	}
+	rc, _, err := store.Get(ctx, name)
+	if err != nil {
+		return Report{}, err
+	}
+	defer rc.Close()
+	sp, report, err := v.check(ctx, rc, info.Size, sums)
+	if sp != nil {
+		_ = sp.Close()
+	}
+	return report, err
+}
+
+// check 读取全部内容并依次校验, 返回的 spool 需由调用方关闭
+func (v *Validator) check(ctx context.Context, r io.Reader, size int64, sums Checksums) (*spool, Report, error) {
+	if v.maxSize > 0 && size > v.maxSize {
+		return nil, Report{}, fmt.Errorf("%w: %d bytes exceeds %d", ErrTooLarge, size, v.maxSize)
+	}
+	sp := &spool{limit: v.memLimit}
+	md5h, sha256h := md5.New(), sha256.New()
+	if v.maxSize > 0 {
+		r = io.LimitReader(r, v.maxSize+1)
+	}
+	n, err := io.Copy(io.MultiWriter(sp, md5h, sha256h), r)
+	if err != nil {
+		return sp, Report{}, err
+	}
+	if v.maxSize > 0 && n > v.maxSize {
+		return sp, Report{}, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, v.maxSize)
+	}
+	if size >= 0 && n != size {
+		return sp, Report{}, fmt.Errorf("%w: read %d bytes, want %d", ErrChecksumMismatch, n, size)
+	}
+
+	head := make([]byte, min(n, SniffLen))
+	_, _ = sp.ReadAt(head, 0)
+	report := Report{
+		ContentType: SniffContentType(head),
+		Size:        n,
+		MD5:         hex.EncodeToString(md5h.Sum(nil)),
+		SHA256:      hex.EncodeToString(sha256h.Sum(nil)),
+	}
+	if err = compareChecksum("md5", sums.MD5, md5h.Sum(nil)); err != nil {
+		return sp, report, err
+	}
+	if err = compareChecksum("sha256", sums.SHA256, sha256h.Sum(nil)); err != nil {
+		return sp, report, err
+	}
+	if !matchContentType(report.ContentType, v.allowed) {
+		return sp, report, fmt.Errorf("%w: %s", ErrTypeNotAllowed, report.ContentType)
+	}
+	if err = checkArchive(mediaType(report.ContentType), sp, n, v.archive); err != nil {
+		return sp, report, err
+	}
+	if v.scanner != nil {
+		if err = v.scanner.Scan(ctx, io.NewSectionReader(sp, 0, n)); err != nil {
+			return sp, report, err
+		}
+	}
+	return sp, report, nil
+}
+
+// IsRejected 是否为内容不合规导致的校验失败, 其他错误 (如存储或扫描器不可用) 返回 false
+func IsRejected(err error) bool {
+	for _, kind := range []error{ErrUploadRejected, ErrTypeNotAllowed, ErrTooLarge, ErrChecksumMismatch, ErrArchiveTooLarge, ErrInfected} {
+		if errors.Is(err, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareChecksum want 为 hex 或 base64 编码
+func compareChecksum(alg, want string, got []byte) error {
+	if want == "" {
+		return nil
+	}
+	b, err := hex.DecodeString(want)
+	if err != nil || len(b) != len(got) {
+		b, err = base64.StdEncoding.DecodeString(want)
+	}
+	if err != nil || !bytes.Equal(b, got) {
+		return fmt.Errorf("%w: %s is %x", ErrChecksumMismatch, alg, got)
+	}
+	return nil
+}
+
+// spool 缓存读取的内容, 超过 limit 后转存到临时文件
+type spool struct {
+	mem   []byte
+	f     *os.File
+	n     int64
+	limit int64
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	if s.f == nil && int64(len(s.mem)+len(p)) > s.limit {
+		f, err := os.CreateTemp("", "oss-spool-*")
+		if err != nil {
+			return 0, err
+		}
+		s.f = f
+		if _, err = f.Write(s.mem); err != nil {
+			return 0, err
+		}
+		s.mem = nil
+	}
+	if s.f != nil {
+		n, err := s.f.Write(p)
+		s.n += int64(n)
+		return n, err
+	}
+	s.mem = append(s.mem, p...)
+	s.n += int64(len(p))
+	return len(p), nil
+}
+
+func (s *spool) ReadAt(p []byte, off int64) (int, error) {
+	if s.f != nil {
+		return s.f.ReadAt(p, off)
+	}
+	return bytes.NewReader(s.mem).ReadAt(p, off)
+}
+
+func (s *spool) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	_ = s.f.Close()
+	return os.Remove(s.f.Name())
+}
+
+// ValidatedStorage 上传前校验内容的 Storage, 其他方法直接调用内部的 Storage
+type ValidatedStorage struct {
+	Storage
+	validator *Validator
+}
+
+// NewValidatedStorage 为 store 的 Put 加上校验
+func NewValidatedStorage(store Storage, v *Validator) *ValidatedStorage {
+	return &ValidatedStorage{Storage: store, validator: v}
+}
+
+// Put 校验通过后上传; Content-Type 与识别出的类型不一致时以识别结果为准,
+// 校验和以 hex 保存到元数据 MetaMD5 与 MetaSHA256.
+func (s *ValidatedStorage) Put(ctx context.Context, name string, r io.Reader, size int64, opts ...PutOption) (ObjectInfo, error) {
+	options := putOptions(opts)
+	sums := Checksums{MD5: options.UserMetadata[MetaMD5], SHA256: options.UserMetadata[MetaSHA256]}
+	sp, report, err := s.validator.check(ctx, r, size, sums)
+	if sp != nil {
+		defer sp.Close()
+	}
+	if err != nil {
+		return ObjectInfo{}, wrapError("put", name, err)
+	}
+
+	contentType := options.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if mediaType(contentType) != mediaType(report.ContentType) {
+		contentType = report.ContentType
+	}
+	opts = append(opts[:len(opts):len(opts)],
+		WithContentType(contentType),
+		WithMetadata(map[string]string{MetaMD5: report.MD5, MetaSHA256: report.SHA256}))
+	return s.Storage.Put(ctx, name, io.NewSectionReader(sp, 0, report.Size), report.Size, opts...)
+}
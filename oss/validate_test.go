@@ -0,0 +1,233 @@
+package oss_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zmicro-team/ztlib/oss"
+	"github.com/zmicro-team/ztlib/oss/internal/fakeclamd"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestSniffContentType(t *testing.T) {
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar")
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{pngHeader, "image/png"},
+		{[]byte("<html><script>alert(1)</script>"), "text/html; charset=utf-8"},
+		{[]byte("PK\x03\x04"), "application/zip"},
+		{[]byte("7z\xbc\xaf\x27\x1c\x00\x04"), "application/x-7z-compressed"},
+		{[]byte("\x7fELF\x02\x01\x01"), "application/x-executable"},
+		{[]byte("MZ\x90\x00"), "application/vnd.microsoft.portable-executable"},
+		{[]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "image/heic"},
+		{[]byte("#!/bin/sh\nrm -rf /"), "text/x-shellscript"},
+		{tar, "application/x-tar"},
+		{[]byte("hello"), "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, oss.SniffContentType(tt.data), "%q", tt.data)
+	}
+}
+
+func TestValidator(t *testing.T) {
+	ctx := context.Background()
+	data := append(pngHeader, bytes.Repeat([]byte{0}, 100)...)
+	md5sum := md5.Sum(data)
+	sha := sha256.Sum256(data)
+
+	v := oss.NewValidator(oss.WithAllowedTypes("image/*"), oss.WithMaxSize(200))
+	report, err := v.Validate(ctx, "a.png", bytes.NewReader(data), int64(len(data)), oss.Checksums{
+		MD5:    base64.StdEncoding.EncodeToString(md5sum[:]),
+		SHA256: hex.EncodeToString(sha[:]),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", report.ContentType)
+	assert.Equal(t, int64(len(data)), report.Size)
+	assert.Equal(t, hex.EncodeToString(md5sum[:]), report.MD5)
+	assert.Equal(t, hex.EncodeToString(sha[:]), report.SHA256)
+
+	tests := []struct {
+		name string
+		data []byte
+		size int64
+		sums oss.Checksums
+		want error
+	}{
+		{"html disguised as image", []byte("<html><script>alert(1)</script>"), -1, oss.Checksums{}, oss.ErrTypeNotAllowed},
+		{"declared too large", data, 201, oss.Checksums{}, oss.ErrTooLarge},
+		{"streamed too large", append(pngHeader, make([]byte, 200)...), -1, oss.Checksums{}, oss.ErrTooLarge},
+		{"size mismatch", data, 10, oss.Checksums{}, oss.ErrChecksumMismatch},
+		{"md5 mismatch", data, -1, oss.Checksums{MD5: strings.Repeat("0", 32)}, oss.ErrChecksumMismatch},
+		{"sha256 mismatch", data, -1, oss.Checksums{SHA256: "bad"}, oss.ErrChecksumMismatch},
+	}
+	for _, tt := range tests {
+		_, err := v.Validate(ctx, "a.png", bytes.NewReader(tt.data), tt.size, tt.sums)
+		assert.ErrorIs(t, err, tt.want, tt.name)
+		assert.True(t, oss.IsRejected(err), tt.name)
+	}
+}
+
+func zipOf(t *testing.T, entries map[string][]byte) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range entries {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestValidator_Archive(t *testing.T) {
+	ctx := context.Background()
+	v := oss.NewValidator(oss.WithArchiveLimits(oss.ArchiveLimits{MaxEntries: 10, MaxSize: 1 << 20, MaxRatio: 50}))
+	validate := func(data []byte) error {
+		_, err := v.Validate(ctx, "a", bytes.NewReader(data), int64(len(data)), oss.Checksums{})
+		return err
+	}
+
+	assert.NoError(t, validate(zipOf(t, map[string][]byte{"a.txt": []byte("hello"), "b.txt": []byte("world")})))
+
+	// 4MB 的 0 压缩后只有几 KB
+	bomb := zipOf(t, map[string][]byte{"zeros": make([]byte, 4<<20)})
+	assert.ErrorIs(t, validate(bomb), oss.ErrArchiveTooLarge)
+
+	many := make(map[string][]byte)
+	for i := range 11 {
+		many[fmt.Sprintf("%d.txt", i)] = []byte("x")
+	}
+	assert.ErrorIs(t, validate(zipOf(t, many)), oss.ErrArchiveTooLarge)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(make([]byte, 2<<20))
+	require.NoError(t, zw.Close())
+	assert.ErrorIs(t, validate(gz.Bytes()), oss.ErrArchiveTooLarge)
+
+	// 只限制压缩比
+	v = oss.NewValidator(oss.WithArchiveLimits(oss.ArchiveLimits{MaxRatio: 50}))
+	assert.ErrorIs(t, validate(bomb), oss.ErrArchiveTooLarge)
+	v = oss.NewValidator(oss.WithArchiveLimits(oss.ArchiveLimits{}))
+	assert.NoError(t, validate(bomb))
+}
+
+func TestValidator_Scanner(t *testing.T) {
+	ctx := context.Background()
+	clamd := fakeclamd.New()
+	defer clamd.Close()
+
+	scanner := &oss.ClamdScanner{Addr: clamd.Addr(), ChunkSize: 16}
+	v := oss.NewValidator(oss.WithScanner(scanner))
+	_, err := v.Validate(ctx, "a.txt", strings.NewReader("clean content"), -1, oss.Checksums{})
+	assert.NoError(t, err)
+
+	_, err = v.Validate(ctx, "eicar.txt", strings.NewReader("prefix "+fakeclamd.EICAR), -1, oss.Checksums{})
+	assert.ErrorIs(t, err, oss.ErrInfected)
+	assert.ErrorContains(t, err, "Eicar-Signature")
+	assert.Equal(t, 2, clamd.Scans())
+
+	// 超过 StreamMaxLength 与扫描器不可用都不是内容不合规
+	clamd.StreamMaxLength = 8
+	_, err = v.Validate(ctx, "a.txt", strings.NewReader("clean content"), -1, oss.Checksums{})
+	assert.Error(t, err)
+	assert.False(t, oss.IsRejected(err))
+
+	addr := clamd.Addr()
+	clamd.Close()
+	_, err = oss.NewValidator(oss.WithScanner(&oss.ClamdScanner{Addr: addr, Timeout: time.Second})).
+		Validate(ctx, "a.txt", strings.NewReader("x"), 1, oss.Checksums{})
+	assert.Error(t, err)
+	assert.False(t, oss.IsRejected(err))
+}
+
+func TestValidatedStorage(t *testing.T) {
+	ctx := context.Background()
+	store := oss.NewMemoryStorage()
+	// 内存缓存只有 16 字节, 内容会写入临时文件
+	vs := oss.NewValidatedStorage(store, oss.NewValidator(
+		oss.WithAllowedTypes("image/*", "text/plain"),
+		oss.WithSpoolMemory(16),
+	))
+
+	data := append(pngHeader, bytes.Repeat([]byte{1}, 64)...)
+	sha := sha256.Sum256(data)
+	info, err := vs.Put(ctx, "avatar.jpg", bytes.NewReader(data), -1,
+		oss.WithChecksums(oss.Checksums{SHA256: base64.StdEncoding.EncodeToString(sha[:])}))
+	require.NoError(t, err)
+	// 扩展名与内容不符时以内容为准
+	assert.Equal(t, "image/png", info.ContentType)
+	assert.Equal(t, int64(len(data)), info.Size)
+
+	rc, stored, err := store.Get(ctx, "avatar.jpg")
+	require.NoError(t, err)
+	got, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	assert.Equal(t, data, got)
+	assert.Equal(t, hex.EncodeToString(sha[:]), stored.Metadata[oss.MetaSHA256])
+
+	_, err = vs.Put(ctx, "a.txt", strings.NewReader("hello"), 5, oss.WithContentType("text/plain; charset=gbk"))
+	require.NoError(t, err)
+	_, stored, err = store.Get(ctx, "a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=gbk", stored.ContentType)
+
+	_, err = vs.Put(ctx, "b.png", strings.NewReader("hello"), 5, oss.WithChecksums(oss.Checksums{MD5: "00"}))
+	assert.ErrorIs(t, err, oss.ErrChecksumMismatch)
+	_, err = vs.Put(ctx, "c.png", strings.NewReader("<html></html>"), -1)
+	assert.ErrorIs(t, err, oss.ErrTypeNotAllowed)
+	for _, name := range []string{"b.png", "c.png"} {
+		_, err = store.Stat(ctx, name)
+		assert.ErrorIs(t, err, oss.ErrNotFound)
+	}
+}
+
+func TestPostVerifier_Validator(t *testing.T) {
+	ctx := context.Background()
+	store := oss.NewMemoryStorage()
+	form, err := newPostOss(t).PresignedPostPolicy(ctx, "up.png", time.Minute,
+		oss.WithPostContentTypes("image/png"), oss.WithPostToken(postSecret))
+	require.NoError(t, err)
+	verifier := oss.NewPostVerifier(store, postSecret, oss.WithPostDeleteRejected(),
+		oss.WithPostValidator(oss.NewValidator(oss.WithAllowedTypes("image/*"))))
+
+	// 客户端声明的类型符合策略, 但真实内容是 html
+	_, err = store.Put(ctx, "up.png", strings.NewReader("<html></html>"), -1, oss.WithContentType("image/png"))
+	require.NoError(t, err)
+	_, err = verifier.Verify(ctx, form.Token, "up.png", "")
+	assert.ErrorIs(t, err, oss.ErrUploadRejected)
+	assert.ErrorIs(t, err, oss.ErrTypeNotAllowed)
+	_, err = store.Stat(ctx, "up.png")
+	assert.ErrorIs(t, err, oss.ErrNotFound)
+
+	// 元数据中客户端提供的校验和与内容不符
+	_, err = store.Put(ctx, "up.png", bytes.NewReader(pngHeader), -1, oss.WithContentType("image/png"),
+		oss.WithChecksums(oss.Checksums{SHA256: strings.Repeat("ab", 32)}))
+	require.NoError(t, err)
+	_, err = verifier.Verify(ctx, form.Token, "up.png", "")
+	assert.ErrorIs(t, err, oss.ErrChecksumMismatch)
+
+	_, err = store.Put(ctx, "up.png", bytes.NewReader(pngHeader), -1, oss.WithContentType("image/png"))
+	require.NoError(t, err)
+	_, err = verifier.Verify(ctx, form.Token, "up.png", "")
+	assert.NoError(t, err)
+}
@@ -90,6 +90,35 @@ func Randn(n int, letterBytes string) string {
 	return string(b)
 }
 
+// SecureRandn returns a random string with length n, using crypto/rand.
+// SecureRandn 使用 crypto/rand 生成指定长度的随机字符串, 用于 token、密钥等安全场景。
+// 参数 letterBytes 为空时使用默认的字符集 defaultLetterBytes, 长度不能超过 64。
+func SecureRandn(n int, letterBytes string) string {
+	if letterBytes == "" {
+		letterBytes = defaultLetterBytes
+	}
+
+	b := make([]byte, n)
+	buf := make([]byte, n+n/4+1)
+	for i := 0; i < n; {
+		if _, err := crand.Read(buf); err != nil {
+			panic(err)
+		}
+		// 丢弃超出字符集的索引, 保证每个字符概率相同
+		for _, c := range buf {
+			if idx := int(c & letterIdxMask); idx < len(letterBytes) {
+				b[i] = letterBytes[idx]
+				i++
+				if i == n {
+					break
+				}
+			}
+		}
+	}
+
+	return string(b)
+}
+
 // Seed sets the seed to seed.
 func Seed(seed int64) {
 	src.Seed(seed)
@@ -16,6 +16,17 @@ func TestRand(t *testing.T) {
 	assert.True(t, len(Randn(size, "")) == size)
 }
 
+func TestSecureRandn(t *testing.T) {
+	const size = 32
+	s := SecureRandn(size, "")
+	assert.Len(t, s, size)
+	assert.NotEqual(t, s, SecureRandn(size, ""))
+
+	for _, c := range SecureRandn(size, "ab") {
+		assert.Contains(t, "ab", string(c))
+	}
+}
+
 func BenchmarkRandString(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		b.Log(Randn(10, "0123456789"))